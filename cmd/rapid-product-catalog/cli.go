@@ -1,11 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/locale"
+	"github.com/roppenlabs/rapid-product-catalog/internal/migration"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
 
 	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	logger "github.com/roppenlabs/rapido-logger-go"
 )
@@ -17,28 +28,236 @@ func initCLI() *cobra.Command {
 	}
 
 	cliCmd.AddCommand(startCommand())
+	cliCmd.AddCommand(migratePricesCommand())
+	cliCmd.AddCommand(migrateCommand())
+	cliCmd.AddCommand(apiKeyCommand())
 	return cliCmd
 }
 
+func initConfig() config.Config {
+	configConfig, err := config.NewConfig()
+	if err != nil {
+		panic(fmt.Errorf("failed to initialize config: %w", err))
+	}
+	config.SetConfig(configConfig)
+	logger.Init(configConfig.Get().Log.Level)
+	money.SetDefaultCurrency(configConfig.Get().BaseCurrency())
+	locale.SetDefault(configConfig.Get().DefaultLocale())
+	tenant.SetDefault(configConfig.Get().DefaultTenant())
+
+	return configConfig
+}
+
 func startCommand() *cobra.Command {
+	var runMigrations bool
 	var startCmd = &cobra.Command{
 		Use:   "start",
 		Short: "Starts the service",
 		Run: func(cmd *cobra.Command, args []string) {
-			configConfig, err := config.NewConfig()
-			if err != nil {
-				panic(fmt.Errorf("failed to initialize config: %w", err))
+			configConfig := initConfig()
+
+			if runMigrations {
+				withMigrator(configConfig, func(ctx context.Context, migrator *migration.Migrator) {
+					applied, err := migrator.Up(ctx, 0)
+					if err != nil {
+						panic(fmt.Errorf("failed to run migrations: %w", err))
+					}
+					logger.Info(logger.Format{Message: fmt.Sprintf("Applied %d pending migrations", len(applied))})
+				})
 			}
-			config.SetConfig(configConfig)
-			logger.Init(configConfig.Get().Log.Level)
 
 			serverDependencies, err := InitDependencies()
 			if err != nil {
 				panic(fmt.Errorf("failed to initialize dependencies: %w", err))
 			}
-			serverDependencies.server.Run(serverDependencies.handlers)
+			serverDependencies.server.Run(serverDependencies.handlers, serverDependencies.workers)
 		},
 	}
 
+	startCmd.Flags().BoolVar(&runMigrations, "migrate", false, "Apply pending migrations before starting")
 	return startCmd
 }
+
+func migratePricesCommand() *cobra.Command {
+	var migrateCmd = &cobra.Command{
+		Use:        "migrate-prices",
+		Short:      "Converts prices stored as plain numbers to amounts with a currency",
+		Deprecated: "the conversion is migration 2, applied by \"migrate up\"",
+		Run: func(cmd *cobra.Command, args []string) {
+			configConfig := initConfig()
+
+			db, err := utils.NewDBInstance(configConfig)
+			if err != nil {
+				panic(fmt.Errorf("failed to connect to database: %w", err))
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			defer db.Close(ctx)
+
+			migrated, err := product.MigrateLegacyPrices(ctx, db, configConfig.Get().BaseCurrency())
+			if err != nil {
+				panic(fmt.Errorf("failed to migrate prices: %w", err))
+			}
+			fmt.Printf("Migrated %d products to %s prices\n", migrated, configConfig.Get().BaseCurrency())
+		},
+	}
+
+	return migrateCmd
+}
+
+func migrateCommand() *cobra.Command {
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Manages schema migrations and indexes",
+	}
+
+	var target int
+	var upCmd = &cobra.Command{
+		Use:   "up",
+		Short: "Applies pending migrations",
+		Run: func(cmd *cobra.Command, args []string) {
+			withMigrator(initConfig(), func(ctx context.Context, migrator *migration.Migrator) {
+				applied, err := migrator.Up(ctx, target)
+				if err != nil {
+					panic(fmt.Errorf("failed to apply migrations: %w", err))
+				}
+				fmt.Printf("Applied %d migrations %v\n", len(applied), applied)
+			})
+		},
+	}
+	upCmd.Flags().IntVar(&target, "to", 0, "Version to migrate up to; all pending when 0")
+
+	var steps int
+	var downCmd = &cobra.Command{
+		Use:   "down",
+		Short: "Reverts the most recently applied migrations",
+		Run: func(cmd *cobra.Command, args []string) {
+			withMigrator(initConfig(), func(ctx context.Context, migrator *migration.Migrator) {
+				reverted, err := migrator.Down(ctx, steps)
+				if err != nil {
+					panic(fmt.Errorf("failed to revert migrations: %w", err))
+				}
+				fmt.Printf("Reverted %d migrations %v\n", len(reverted), reverted)
+			})
+		},
+	}
+	downCmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to revert")
+
+	var statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Lists migrations and whether they are applied",
+		Run: func(cmd *cobra.Command, args []string) {
+			withMigrator(initConfig(), func(ctx context.Context, migrator *migration.Migrator) {
+				statuses, err := migrator.Status(ctx)
+				if err != nil {
+					panic(fmt.Errorf("failed to read migration status: %w", err))
+				}
+				for _, status := range statuses {
+					appliedAt := "pending"
+					if status.Applied {
+						appliedAt = status.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Printf("%4d  %-25s  %s\n", status.Version, appliedAt, status.Description)
+				}
+			})
+		},
+	}
+
+	migrateCmd.AddCommand(upCmd, downCmd, statusCmd)
+	return migrateCmd
+}
+
+func withMigrator(configConfig config.Config, run func(ctx context.Context, migrator *migration.Migrator)) {
+	db, err := utils.NewDBInstance(configConfig)
+	if err != nil {
+		panic(fmt.Errorf("failed to connect to database: %w", err))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	defer db.Close(ctx)
+
+	run(ctx, migration.NewMigrator(db, migration.NewRepository(db)))
+}
+
+func apiKeyCommand() *cobra.Command {
+	var apiKeyCmd = &cobra.Command{
+		Use:   "apikey",
+		Short: "Issues, lists and revokes API keys",
+	}
+
+	var name, tenantID string
+	var roles []string
+	var createCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Issues an API key and prints it; the key cannot be shown again",
+		Run: func(cmd *cobra.Command, args []string) {
+			withAuthService(initConfig(), func(ctx context.Context, service auth.Service) {
+				key, secret, err := service.CreateKey(ctx, auth.CreateKeyRequest{Name: name, TenantID: tenantID, Roles: roles})
+				if err != nil {
+					panic(fmt.Errorf("failed to create API key: %w", err))
+				}
+				fmt.Printf("Created API key %s for tenant %s\n%s\n", key.ID.Hex(), key.TenantID, secret)
+			})
+		},
+	}
+	createCmd.Flags().StringVar(&name, "name", "", "Name of the caller the key is issued to")
+	createCmd.Flags().StringVar(&tenantID, "tenant", "", "Tenant the key belongs to; the default tenant when empty")
+	createCmd.Flags().StringSliceVar(&roles, "role", nil, "Role granted to the key; repeat for several")
+	createCmd.MarkFlagRequired("name")
+
+	var listTenantID string
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists issued API keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			withAuthService(initConfig(), func(ctx context.Context, service auth.Service) {
+				keys, err := service.ListKeys(ctx, listTenantID)
+				if err != nil {
+					panic(fmt.Errorf("failed to list API keys: %w", err))
+				}
+				for _, key := range keys {
+					status := "active"
+					if key.RevokedAt != nil {
+						status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+					}
+					fmt.Printf("%s  %-12s  %-15s  %-20s  %-30s  %s\n", key.ID.Hex(), key.Prefix, key.TenantID, key.Name, strings.Join(key.Roles, ","), status)
+				}
+			})
+		},
+	}
+	listCmd.Flags().StringVar(&listTenantID, "tenant", "", "Only list the keys of this tenant")
+
+	var revokeCmd = &cobra.Command{
+		Use:   "revoke <key-id>",
+		Short: "Revokes an API key",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			keyID, err := primitive.ObjectIDFromHex(args[0])
+			if err != nil {
+				panic(fmt.Errorf("invalid API key ID %q: %w", args[0], err))
+			}
+			withAuthService(initConfig(), func(ctx context.Context, service auth.Service) {
+				key, err := service.RevokeKey(ctx, keyID)
+				if err != nil {
+					panic(fmt.Errorf("failed to revoke API key: %w", err))
+				}
+				fmt.Printf("Revoked API key %s (%s)\n", key.ID.Hex(), key.Name)
+			})
+		},
+	}
+
+	apiKeyCmd.AddCommand(createCmd, listCmd, revokeCmd)
+	return apiKeyCmd
+}
+
+func withAuthService(configConfig config.Config, run func(ctx context.Context, service auth.Service)) {
+	db, err := utils.NewDBInstance(configConfig)
+	if err != nil {
+		panic(fmt.Errorf("failed to connect to database: %w", err))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	defer db.Close(ctx)
+
+	run(ctx, auth.NewService(configConfig, auth.NewRepository(db), tenant.NewRegistry(configConfig)))
+}
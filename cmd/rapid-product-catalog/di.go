@@ -5,26 +5,54 @@ package main
 
 import (
 	"github.com/google/wire"
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/currency"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
 	"github.com/roppenlabs/rapid-product-catalog/internal/health"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
 	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/ratelimit"
 	"github.com/roppenlabs/rapid-product-catalog/internal/server"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/stream"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
 	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/webhook"
 )
 
 type ServerDependencies struct {
 	config   config.Config
 	server   *server.Server
 	handlers server.Handlers
+	workers  server.Workers
 }
 
 func InitDependencies() (ServerDependencies, error) {
 	wire.Build(
 		wire.Struct(new(ServerDependencies), "*"),
 		wire.Struct(new(server.Handlers), "*"),
+		wire.Struct(new(server.Workers), "*"),
 		server.WireSet,
+		auth.WireSet,
+		ratelimit.WireSet,
+		tenant.WireSet,
 		product.WireSet,
+		price.WireSet,
+		promotion.WireSet,
+		currency.WireSet,
+		pricelist.WireSet,
+		events.WireSet,
+		audit.WireSet,
+		webhook.WireSet,
+		stream.WireSet,
 		health.WireSet,
+		metrics.WireSet,
+		tracing.WireSet,
 		utils.WireSet,
 		config.GetConfig,
 	)
@@ -7,34 +7,114 @@
 package main
 
 import (
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/currency"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
 	"github.com/roppenlabs/rapid-product-catalog/internal/health"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
 	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/ratelimit"
 	"github.com/roppenlabs/rapid-product-catalog/internal/server"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/stream"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
 	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/webhook"
 )
 
 // Injectors from di.go:
 
 func InitDependencies() (ServerDependencies, error) {
 	configConfig := config.GetConfig()
-	serverServer := server.NewServer(configConfig)
-	handler := health.NewHandler()
-	dbInstance, err := utils.NewDBInstance(configConfig)
+	healthRegistry := health.NewRegistry(configConfig)
+	serverServer := server.NewServer(configConfig, healthRegistry)
+	registry := tenant.NewRegistry(configConfig)
+	tracer := tracing.NewTracer(configConfig)
+	tracingHandler := tracing.NewHandler(tracer)
+	dbInstance, err := utils.NewCheckedDBInstance(configConfig, healthRegistry)
 	if err != nil {
 		return ServerDependencies{}, err
 	}
-	productRepository := product.NewRepository(dbInstance)
-	service := product.NewService(configConfig, productRepository)
-	productHandler := product.NewHandler(service)
+	authRepository := auth.NewRepository(dbInstance)
+	authService := auth.NewService(configConfig, authRepository, registry)
+	verifier := auth.NewVerifier(configConfig)
+	authHandler := auth.NewHandler(configConfig, authService, verifier)
+	limiter := ratelimit.NewLimiter()
+	ratelimitHandler := ratelimit.NewHandler(configConfig, limiter)
+	tenantHandler := tenant.NewHandler(registry)
+	handler := health.NewHandler(healthRegistry)
+	eventsRepository := events.NewRepository(configConfig, dbInstance)
+	broker := events.NewBroker()
+	searchCache := product.NewSearchCache(configConfig)
+	cachedRepository := product.NewCachedRepository(configConfig, dbInstance, eventsRepository, searchCache, broker)
+	priceRepository := price.NewRepository(dbInstance, eventsRepository)
+	priceService := price.NewService(priceRepository)
+	promotionRepository := promotion.NewRepository(dbInstance)
+	promotionService := promotion.NewService(configConfig, promotionRepository)
+	currencyRepository := currency.NewRepository(dbInstance)
+	currencyService := currency.NewService(configConfig, currencyRepository)
+	pricelistRepository := pricelist.NewRepository(dbInstance, eventsRepository)
+	pricelistService := pricelist.NewService(configConfig, pricelistRepository)
+	repository := product.NewTracedRepository(cachedRepository)
+	service := product.NewService(configConfig, repository, priceService, promotionService, currencyService, pricelistService)
+	productHandler := product.NewHandler(configConfig, service)
+	priceHandler := price.NewHandler(priceService)
+	promotionHandler := promotion.NewHandler(promotionService)
+	currencyHandler := currency.NewHandler(currencyService)
+	pricelistHandler := pricelist.NewHandler(pricelistService)
+	webhookRepository := webhook.NewRepository(dbInstance)
+	httpClient := utils.GetHTTPClient()
+	webhookService := webhook.NewService(configConfig, webhookRepository, eventsRepository, httpClient)
+	webhookHandler := webhook.NewHandler(webhookService)
+	hub := stream.NewHub(configConfig, broker, eventsRepository)
+	streamHandler := stream.NewHandler(configConfig, hub)
+	auditRepository := audit.NewRepository(dbInstance)
+	auditService := audit.NewService(auditRepository)
+	auditHandler := audit.NewHandler(auditService)
+	cacheHandler := product.NewCacheHandler(cachedRepository)
+	metricsHandler := metrics.NewHandler()
 	handlers := server.Handlers{
-		HealthHandler:  handler,
-		ProductHandler: productHandler,
+		MetricsHandler:   metricsHandler,
+		TracingHandler:   tracingHandler,
+		AuthHandler:      authHandler,
+		RateLimitHandler: ratelimitHandler,
+		TenantHandler:    tenantHandler,
+		AuditHandler:     auditHandler,
+		HealthHandler:    handler,
+		ProductHandler:   productHandler,
+		CacheHandler:     cacheHandler,
+		PriceHandler:     priceHandler,
+		PromotionHandler: promotionHandler,
+		CurrencyHandler:  currencyHandler,
+		PriceListHandler: pricelistHandler,
+		WebhookHandler:   webhookHandler,
+		StreamHandler:    streamHandler,
+	}
+	scheduler := price.NewScheduler(configConfig, priceService, auditService, registry)
+	sink := webhook.NewSink(webhookService)
+	sinks := events.NewSinks(configConfig, broker, sink, httpClient)
+	dispatcher := events.NewDispatcher(configConfig, eventsRepository, sinks)
+	deliverer := webhook.NewDeliverer(configConfig, webhookService, registry)
+	catalogMetrics := product.NewCatalogMetrics(configConfig, repository, registry)
+	workers := server.Workers{
+		PriceScheduler:   scheduler,
+		EventDispatcher:  dispatcher,
+		WebhookDeliverer: deliverer,
+		StreamHub:        hub,
+		ProductCache:     cachedRepository,
+		CatalogMetrics:   catalogMetrics,
+		Tracer:           tracer,
 	}
 	serverDependencies := ServerDependencies{
 		config:   configConfig,
 		server:   serverServer,
 		handlers: handlers,
+		workers:  workers,
 	}
 	return serverDependencies, nil
 }
@@ -45,4 +125,5 @@ type ServerDependencies struct {
 	config   config.Config
 	server   *server.Server
 	handlers server.Handlers
+	workers  server.Workers
 }
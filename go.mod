@@ -13,4 +13,5 @@ require (
 	github.com/spf13/viper v1.7.0
 	github.com/stretchr/testify v1.6.1
 	go.mongodb.org/mongo-driver v1.17.6
+	golang.org/x/sync v0.8.0
 )
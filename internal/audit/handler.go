@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const recordTimeout = 5 * time.Second
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// Middleware scopes every mutating request so the writes it makes are
+// collected, and stores them as one audit entry once the request completes
+func (h *Handler) Middleware(ctx *gin.Context) {
+	switch ctx.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		ctx.Next()
+		return
+	}
+
+	endpoint := ctx.FullPath()
+	if endpoint == "" {
+		endpoint = ctx.Request.URL.Path
+	}
+	scopedCtx, scope := WithScope(ctx.Request.Context(), actor(ctx), requestID(ctx), ctx.Request.Method, endpoint)
+	ctx.Request = ctx.Request.WithContext(scopedCtx)
+
+	ctx.Next()
+
+	if !scope.HasChanges() {
+		return
+	}
+	entry := scope.Entry(ctx.Writer.Status())
+	// Authentication may run after this middleware, so the actor is resolved
+	// again once the request is handled
+	entry.Actor = actor(ctx)
+	recordCtx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	if err := h.service.Record(recordCtx, entry); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{
+			Message: "Error recording audit entry",
+			Data: map[string]string{
+				"error":     err.Error(),
+				"actor":     entry.Actor,
+				"requestID": entry.RequestID,
+			},
+		})
+	}
+}
+
+func (h *Handler) QueryHandler(ctx *gin.Context) {
+	params := QueryParams{Actor: ctx.Query("actor")}
+
+	if productID := ctx.Query("productId"); productID != "" {
+		parsed, err := primitive.ObjectIDFromHex(productID)
+		if err != nil {
+			logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+			ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
+			return
+		}
+		params.ProductID = parsed
+	}
+	for name, target := range map[string]*time.Time{"from": &params.From, "to": &params.To} {
+		if value := ctx.Query(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("%s must be an RFC 3339 timestamp", name))))
+				return
+			}
+			*target = parsed
+		}
+	}
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("limit must be a positive integer")))
+			return
+		}
+		params.Limit = parsed
+	}
+
+	entries, err := h.service.Query(ctx.Request.Context(), params)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, QueryResponse{
+		Success: true,
+		Count:   len(entries),
+		Entries: entries,
+	})
+}
+
+func actor(ctx *gin.Context) string {
+	if value := ctx.GetString(ActorKey); value != "" {
+		return value
+	}
+	if value := ctx.GetHeader(ActorHeader); value != "" {
+		return value
+	}
+	return AnonymousActor
+}
+
+// requestID is the ID the request was assigned, falling back to the
+// caller's header where the request ID middleware does not run
+func requestID(ctx *gin.Context) string {
+	if value := requestid.FromContext(ctx.Request.Context()); value != "" {
+		return value
+	}
+	if value := ctx.GetHeader(RequestIDHeader); value != "" {
+		return value
+	}
+	return requestid.New()
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditHandlerTestSuite struct {
+	suite.Suite
+	service   *MockService
+	server    *testutils.TestServer
+	productID primitive.ObjectID
+}
+
+func (ah *AuditHandlerTestSuite) SetupTest() {
+	ah.service = new(MockService)
+	ah.server = testutils.NewServer()
+	ah.productID = primitive.NewObjectID()
+	handler := NewHandler(ah.service)
+
+	router := ah.server.Router()
+	router.Use(handler.Middleware)
+	router.PUT("/products/:productId", func(ctx *gin.Context) {
+		Record(ctx.Request.Context(), Change{ProductID: ah.productID, Field: "price", Before: 100, After: 120})
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/products/:productId", func(ctx *gin.Context) {
+		Record(ctx.Request.Context(), Change{ProductID: ah.productID, Field: "price"})
+		ctx.Status(http.StatusOK)
+	})
+	router.POST("/noop", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/audit-log", handler.QueryHandler)
+
+	logger.Init("debug")
+}
+
+func TestAuditHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AuditHandlerTestSuite))
+}
+
+func (ah *AuditHandlerTestSuite) TestShouldRecordChangesOfMutatingCall() {
+	ah.service.On("Record", mock.Anything, mock.Anything).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/products/"+ah.productID.Hex(), nil)
+	req.Header.Set(ActorHeader, "catalog-admin")
+	req.Header.Set(RequestIDHeader, "req-1")
+	ah.server.Start(req)
+
+	ah.service.AssertNumberOfCalls(ah.T(), "Record", 1)
+	entry := ah.service.Calls[0].Arguments.Get(1).(Entry)
+	assert.Equal(ah.T(), "catalog-admin", entry.Actor)
+	assert.Equal(ah.T(), "req-1", entry.RequestID)
+	assert.Equal(ah.T(), "/products/:productId", entry.Endpoint)
+	assert.Equal(ah.T(), http.StatusOK, entry.StatusCode)
+	assert.Equal(ah.T(), []primitive.ObjectID{ah.productID}, entry.ProductIDs)
+	assert.Equal(ah.T(), []Change{{ProductID: ah.productID, Field: "price", Before: 100, After: 120}}, entry.Changes)
+}
+
+func (ah *AuditHandlerTestSuite) TestShouldPreferAuthenticatedActor() {
+	ah.service.On("Record", mock.Anything, mock.Anything).Return(nil)
+	ah.server.Router().PUT("/authenticated/:productId", func(ctx *gin.Context) {
+		ctx.Set(ActorKey, "key:partner")
+		Record(ctx.Request.Context(), Change{ProductID: ah.productID, Field: "availableQty", Before: 3, After: 2})
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/authenticated/"+ah.productID.Hex(), nil)
+	req.Header.Set(ActorHeader, "spoofed")
+	ah.server.Start(req)
+
+	entry := ah.service.Calls[0].Arguments.Get(1).(Entry)
+	assert.Equal(ah.T(), "key:partner", entry.Actor)
+}
+
+func (ah *AuditHandlerTestSuite) TestShouldSkipReadsAndCallsWithoutChanges() {
+	ah.server.PerformRequest("/products/"+ah.productID.Hex(), "get", nil)
+	ah.server.PerformRequest("/noop", "post", nil)
+
+	ah.service.AssertNotCalled(ah.T(), "Record", mock.Anything, mock.Anything)
+}
+
+func (ah *AuditHandlerTestSuite) TestShouldRejectInvalidQuery() {
+	ah.server.PerformRequest("/audit-log?from=yesterday", "get", nil)
+
+	assert.Equal(ah.T(), http.StatusBadRequest, ah.server.Recorder().Code)
+	ah.service.AssertNotCalled(ah.T(), "Query", mock.Anything, mock.Anything)
+}
+
+func (ah *AuditHandlerTestSuite) TestShouldQueryByActorAndProduct() {
+	ah.service.On("Query", mock.Anything, QueryParams{Actor: "catalog-admin", ProductID: ah.productID, Limit: 10}).Return([]Entry{{Actor: "catalog-admin"}}, nil)
+
+	ah.server.PerformRequest("/audit-log?actor=catalog-admin&productId="+ah.productID.Hex()+"&limit=10", "get", nil)
+
+	assert.Equal(ah.T(), http.StatusOK, ah.server.Recorder().Code)
+	ah.service.AssertExpectations(ah.T())
+}
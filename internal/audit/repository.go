@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	InsertEntry(ctx context.Context, entry Entry) error
+	FindEntries(ctx context.Context, params QueryParams) ([]Entry, error)
+}
+
+const defaultRetentionDays = 365
+
+// Retention is how long entries are kept, from audit.retentionDays. The TTL
+// index that expires them is created and kept in step with it by the
+// migrations.
+func Retention(cfg config.Config) time.Duration {
+	retentionDays := cfg.Get().Audit.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultRetentionDays
+	}
+	return time.Duration(retentionDays) * 24 * time.Hour
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+	// reads serves FindEntries, which may lag behind writes
+	reads *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	// Before and after values are decoded as maps so they read back as the
+	// JSON objects they were
+	collectionOptions := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidAuditLog", collectionOptions),
+		reads:      db.ReadCollection("rapidAuditLog", collectionOptions),
+	}
+}
+
+func (r *repositoryImpl) InsertEntry(ctx context.Context, entry Entry) error {
+	err := r.db.Write(ctx, "audit.InsertEntry", func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, entry)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error inserting audit entry",
+			Data: map[string]string{
+				"error":     err.Error(),
+				"requestID": entry.RequestID,
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) FindEntries(ctx context.Context, params QueryParams) ([]Entry, error) {
+	filter := tenant.Filter(ctx, bson.M{})
+	if params.Actor != "" {
+		filter["actor"] = params.Actor
+	}
+	if !params.ProductID.IsZero() {
+		filter["productIds"] = params.ProductID
+	}
+	if !params.From.IsZero() || !params.To.IsZero() {
+		at := bson.M{}
+		if !params.From.IsZero() {
+			at["$gte"] = params.From
+		}
+		if !params.To.IsZero() {
+			at["$lte"] = params.To
+		}
+		filter["at"] = at
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(int64(params.Limit))
+	entries := []Entry{}
+	err := r.db.Read(ctx, "audit.FindEntries", func(ctx context.Context) error {
+		cursor, err := r.reads.Find(ctx, filter, findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching audit entries",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return entries, nil
+}
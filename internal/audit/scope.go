@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type scopeKey struct{}
+
+// Scope collects the changes made on behalf of one call, so the write paths
+// can report them without knowing who the caller is
+type Scope struct {
+	mu    sync.Mutex
+	entry Entry
+	seen  map[primitive.ObjectID]bool
+}
+
+// WithScope returns a context that collects the changes made under it into
+// an entry for actor, owned by the tenant of ctx
+func WithScope(ctx context.Context, actor, requestID, method, endpoint string) (context.Context, *Scope) {
+	scope := &Scope{
+		entry: Entry{
+			TenantID:  tenant.ID(ctx),
+			Actor:     actor,
+			RequestID: requestID,
+			Method:    method,
+			Endpoint:  endpoint,
+		},
+		seen: map[primitive.ObjectID]bool{},
+	}
+	return context.WithValue(ctx, scopeKey{}, scope), scope
+}
+
+// Active reports whether writes made under ctx are audited, so write paths
+// only read the state before a write when it will be recorded
+func Active(ctx context.Context) bool {
+	_, ok := ctx.Value(scopeKey{}).(*Scope)
+	return ok
+}
+
+// Record adds changes to the scope of ctx. Writes made outside a scope are
+// not audited.
+func Record(ctx context.Context, changes ...Change) {
+	scope, ok := ctx.Value(scopeKey{}).(*Scope)
+	if !ok {
+		return
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	for _, change := range changes {
+		if !scope.seen[change.ProductID] {
+			scope.seen[change.ProductID] = true
+			scope.entry.ProductIDs = append(scope.entry.ProductIDs, change.ProductID)
+		}
+		scope.entry.Changes = append(scope.entry.Changes, change)
+	}
+}
+
+// Diff lists a change for each of fields whose value differs between the
+// before and after documents of a product; a missing document is nil
+func Diff(productID primitive.ObjectID, fields []string, before, after map[string]interface{}) []Change {
+	changes := []Change{}
+	for _, field := range fields {
+		var beforeValue, afterValue interface{}
+		if before != nil {
+			beforeValue = before[field]
+		}
+		if after != nil {
+			afterValue = after[field]
+		}
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		changes = append(changes, Change{ProductID: productID, Field: field, Before: beforeValue, After: afterValue})
+	}
+	return changes
+}
+
+// HasChanges reports whether any change was recorded in the scope
+func (s *Scope) HasChanges() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entry.Changes) > 0
+}
+
+// Entry returns the entry for the scope, completed with the call's outcome
+func (s *Scope) Entry(statusCode int) Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entry
+	entry.At = time.Now().UTC()
+	entry.StatusCode = statusCode
+	return entry
+}
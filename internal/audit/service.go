@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+)
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 500
+)
+
+type Service interface {
+	// Record stores entry in the audit log
+	Record(ctx context.Context, entry Entry) error
+	// Query returns the entries matching params, newest first
+	Query(ctx context.Context, params QueryParams) ([]Entry, error)
+}
+
+type serviceImpl struct {
+	repository Repository
+}
+
+func NewService(repo Repository) Service {
+	return &serviceImpl{
+		repository: repo,
+	}
+}
+
+func (s *serviceImpl) Record(ctx context.Context, entry Entry) error {
+	return s.repository.InsertEntry(ctx, entry)
+}
+
+func (s *serviceImpl) Query(ctx context.Context, params QueryParams) ([]Entry, error) {
+	if !params.From.IsZero() && !params.To.IsZero() && params.To.Before(params.From) {
+		return nil, types.NewValidationError("to must not be before from")
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultQueryLimit
+	}
+	if params.Limit > maxQueryLimit {
+		params.Limit = maxQueryLimit
+	}
+
+	return s.repository.FindEntries(ctx, params)
+}
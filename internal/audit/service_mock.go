@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) Record(ctx context.Context, entry Entry) error {
+	ret := s.Mock.Called(ctx, entry)
+	return ret.Error(0)
+}
+
+func (s *MockService) Query(ctx context.Context, params QueryParams) ([]Entry, error) {
+	ret := s.Mock.Called(ctx, params)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (r *MockRepository) InsertEntry(ctx context.Context, entry Entry) error {
+	ret := r.Mock.Called(ctx, entry)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) FindEntries(ctx context.Context, params QueryParams) ([]Entry, error) {
+	ret := r.Mock.Called(ctx, params)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
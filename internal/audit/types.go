@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActorKey is the gin context key authentication sets to the caller's
+// identity. Without it the X-Actor header is used, and "anonymous" without
+// either.
+const ActorKey = "actor"
+
+const (
+	ActorHeader     = "X-Actor"
+	RequestIDHeader = requestid.Header
+	AnonymousActor  = "anonymous"
+)
+
+// Entry records one mutating call: who made it, through which endpoint, and
+// the product fields it changed
+type Entry struct {
+	ID         primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TenantID   string               `json:"-" bson:"tenantId"`
+	At         time.Time            `json:"at" bson:"at"`
+	Actor      string               `json:"actor" bson:"actor"`
+	RequestID  string               `json:"requestId" bson:"requestId"`
+	Method     string               `json:"method" bson:"method"`
+	Endpoint   string               `json:"endpoint" bson:"endpoint"`
+	StatusCode int                  `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	ProductIDs []primitive.ObjectID `json:"productIds,omitempty" bson:"productIds,omitempty"`
+	Changes    []Change             `json:"changes,omitempty" bson:"changes,omitempty"`
+}
+
+// Change is the before and after value of one product field. Before is nil
+// for a created product and After for a deleted one.
+type Change struct {
+	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
+	Field     string             `json:"field" bson:"field"`
+	Before    interface{}        `json:"before" bson:"before"`
+	After     interface{}        `json:"after" bson:"after"`
+}
+
+// QueryParams filters the audit log; zero values match everything
+type QueryParams struct {
+	Actor     string
+	ProductID primitive.ObjectID
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+type QueryResponse struct {
+	Success bool    `json:"success"`
+	Count   int     `json:"count"`
+	Entries []Entry `json:"entries"`
+}
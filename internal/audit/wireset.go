@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+)
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
@@ -66,6 +67,30 @@ func (c *Values) LogLevel() string {
 	return c.Log.Level
 }
 
+func (c *Values) BaseCurrency() string {
+	if "" == c.Currencies.Base {
+		return "INR"
+	}
+
+	return strings.ToUpper(c.Currencies.Base)
+}
+
+func (c *Values) DefaultLocale() string {
+	if "" == c.Locales.Default {
+		return "en"
+	}
+
+	return c.Locales.Default
+}
+
+func (c *Values) DefaultTenant() string {
+	if "" == c.Tenancy.Default {
+		return "default"
+	}
+
+	return c.Tenancy.Default
+}
+
 func (c *Values) ListenAddress() string {
 	return ":" + strconv.Itoa(c.Server.Port)
 }
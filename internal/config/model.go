@@ -6,31 +6,174 @@ type Values struct {
 	Environment      string
 	ProfilingEnabled bool
 	Datastores       Datastores
+	Pricing          PricingConfig
+	Promotions       PromotionsConfig
+	Currencies       CurrenciesConfig
+	PriceLists       PriceListsConfig
+	Locales          LocalesConfig
+	Events           EventsConfig
+	Webhooks         WebhooksConfig
+	Stream           StreamConfig
+	Audit            AuditConfig
+	ProductCache     ProductCacheConfig
+	SearchCache      SearchCacheConfig
+	AtomicUploads    AtomicUploadsConfig
+	Tenancy          TenancyConfig
+	Health           HealthConfig
+	Metrics          MetricsConfig
+	Tracing          TracingConfig
+	Auth             AuthConfig
+	RateLimit        RateLimitConfig
+	Limits           LimitsConfig
 }
 
 type LogConfig struct {
-	Level string
+	Level  string
+	Access AccessLogConfig `mapstructure:"access"`
 }
 
+// AccessLogConfig Level is debug or info, info when unset; failed requests
+// are logged at error. SampleRate is the share of successful requests
+// logged, 1 when unset. With Bodies, request and response bodies up to
+// MaxBodyBytes are logged, the values of RedactFields masked.
+type AccessLogConfig struct {
+	Level        string   `mapstructure:"level"`
+	SampleRate   float64  `mapstructure:"sampleRate"`
+	Bodies       bool     `mapstructure:"bodies"`
+	MaxBodyBytes int      `mapstructure:"maxBodyBytes"`
+	RedactFields []string `mapstructure:"redactFields"`
+}
+
+// ServerConfig TrustedProxies are the addresses or CIDR ranges of the proxies
+// whose X-Forwarded-For and X-Real-IP headers name the client; other peers
+// are identified by their own address
 type ServerConfig struct {
-	Host string
-	Port int
+	Host           string
+	Port           int
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+}
+
+type PricingConfig struct {
+	SchedulerInterval int `mapstructure:"schedulerInterval"`
+}
+
+type PromotionsConfig struct {
+	CacheTTL int `mapstructure:"cacheTTL"`
+}
+
+type PriceListsConfig struct {
+	CacheTTL int `mapstructure:"cacheTTL"`
+}
+
+type LocalesConfig struct {
+	Default string `mapstructure:"default"`
+}
+
+// EventsConfig durations are in seconds
+type EventsConfig struct {
+	PollInterval int               `mapstructure:"pollInterval"`
+	BatchSize    int               `mapstructure:"batchSize"`
+	LeaseTTL     int               `mapstructure:"leaseTTL"`
+	GapTimeout   int               `mapstructure:"gapTimeout"`
+	SweepAfter   int               `mapstructure:"sweepAfter"`
+	FileSink     FileSinkConfig    `mapstructure:"fileSink"`
+	WebhookSink  WebhookSinkConfig `mapstructure:"webhookSink"`
+}
+
+type FileSinkConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+type WebhookSinkConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	Timeout int    `mapstructure:"timeout"`
+}
+
+// WebhooksConfig durations are in seconds
+type WebhooksConfig struct {
+	PollInterval int `mapstructure:"pollInterval"`
+	BatchSize    int `mapstructure:"batchSize"`
+	Timeout      int `mapstructure:"timeout"`
+	MaxAttempts  int `mapstructure:"maxAttempts"`
+	MinBackoff   int `mapstructure:"minBackoff"`
+	MaxBackoff   int `mapstructure:"maxBackoff"`
+	CacheTTL     int `mapstructure:"cacheTTL"`
+	ReplayLimit  int `mapstructure:"replayLimit"`
+	// AllowPrivateTargets lets subscriptions target private, loopback and
+	// link-local addresses, for local development
+	AllowPrivateTargets bool `mapstructure:"allowPrivateTargets"`
+}
+
+// StreamConfig KeepAlive is in seconds
+type StreamConfig struct {
+	ReplayBuffer int `mapstructure:"replayBuffer"`
+	ClientBuffer int `mapstructure:"clientBuffer"`
+	KeepAlive    int `mapstructure:"keepAlive"`
+}
+
+type AuditConfig struct {
+	RetentionDays int `mapstructure:"retentionDays"`
+}
+
+type CurrenciesConfig struct {
+	Base          string            `mapstructure:"base"`
+	RatesCacheTTL int               `mapstructure:"ratesCacheTTL"`
+	Rates         map[string]string `mapstructure:"rates"`
 }
 
 type Datastores struct {
 	TestDB MongoDB `mapstructure:"testDB"`
+	// ProductStore selects the product repository backend: "mongo", the
+	// default, or "memory" for local development and tests. Only products
+	// are kept in memory; everything else still needs testDB, so the service
+	// cannot run without Mongo.
+	ProductStore string `mapstructure:"productStore"`
+	// Mongo lists further named datastores alongside testDB
+	Mongo []MongoDB `mapstructure:"mongo"`
+	// Collections places collections in a datastore listed under mongo;
+	// the others stay with the catalog in testDB
+	Collections []CollectionConfig `mapstructure:"collections"`
+	Operations  OperationsConfig   `mapstructure:"operations"`
+}
+
+// CollectionConfig places the collection Name in the datastore Store
+type CollectionConfig struct {
+	Name  string `mapstructure:"name"`
+	Store string `mapstructure:"store"`
+}
+
+// OperationsConfig bounds each Mongo operation, in milliseconds. Attempts
+// failing because the datastore is unavailable are retried up to MaxRetries
+// times; a negative MaxRetries disables retries.
+type OperationsConfig struct {
+	ReadTimeout  int `mapstructure:"readTimeout"`
+	WriteTimeout int `mapstructure:"writeTimeout"`
+	MaxRetries   int `mapstructure:"maxRetries"`
+	MinBackoff   int `mapstructure:"minBackoff"`
+	MaxBackoff   int `mapstructure:"maxBackoff"`
 }
 
 type MongoDB struct {
-	Hosts      string       `mapstructure:"hosts"`
-	Port       int          `mapstructure:"port"`
-	User       string       `mapstructure:"user"`
-	Password   string       `mapstructure:"password"`
-	Database   string       `mapstructure:"database"`
-	AuthSource string       `mapstructure:"authSource"`
-	ReplicaSet string       `mapstructure:"replicaSet"`
-	AppName    string       `mapstructure:"appName"`
-	Options    MongoOptions `mapstructure:"options"`
+	// Name identifies a datastore listed under mongo
+	Name string `mapstructure:"name"`
+	// URI is a full connection string, or a mongodb+srv:// SRV record, used
+	// instead of the one assembled from the fields below
+	URI        string `mapstructure:"uri"`
+	Hosts      string `mapstructure:"hosts"`
+	Port       int    `mapstructure:"port"`
+	User       string `mapstructure:"user"`
+	Password   string `mapstructure:"password"`
+	Database   string `mapstructure:"database"`
+	AuthSource string `mapstructure:"authSource"`
+	ReplicaSet string `mapstructure:"replicaSet"`
+	AppName    string `mapstructure:"appName"`
+	// ReadPreference applies to reads that may lag behind writes, default
+	// secondaryPreferred; writes and the reads that must see them use the
+	// primary
+	ReadPreference string       `mapstructure:"readPreference"`
+	Options        MongoOptions `mapstructure:"options"`
 }
 
 type MongoOptions struct {
@@ -39,3 +182,160 @@ type MongoOptions struct {
 	IdleTimeout       int `mapstructure:"idleTimeout"`
 	ConnectionTimeout int `mapstructure:"connectionTimeout"`
 }
+
+// ProductCacheConfig TTL is in seconds
+type ProductCacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Size    int  `mapstructure:"size"`
+	TTL     int  `mapstructure:"ttl"`
+}
+
+// SearchCacheConfig TTL is in seconds
+type SearchCacheConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	MaxEntries int  `mapstructure:"maxEntries"`
+	TTL        int  `mapstructure:"ttl"`
+}
+
+// AtomicUploadsConfig bounds uploads applied in one transaction. MaxBytes is
+// the total BSON size of the products and Timeout is in seconds.
+type AtomicUploadsConfig struct {
+	MaxProducts int `mapstructure:"maxProducts"`
+	MaxBytes    int `mapstructure:"maxBytes"`
+	Timeout     int `mapstructure:"timeout"`
+}
+
+// HealthConfig durations are in seconds. Check results are cached for
+// CacheTTL, and on shutdown the instance reports not ready for DrainDelay
+// before it stops accepting connections.
+type HealthConfig struct {
+	CacheTTL   int `mapstructure:"cacheTTL"`
+	Timeout    int `mapstructure:"timeout"`
+	DrainDelay int `mapstructure:"drainDelay"`
+}
+
+// MetricsConfig CatalogInterval is how often the catalog gauges are
+// refreshed, in seconds
+type MetricsConfig struct {
+	CatalogInterval int `mapstructure:"catalogInterval"`
+}
+
+// TracingConfig Exporter is otlp, sending spans over OTLP/HTTP to Endpoint,
+// or stdout. SampleRatio is the share of traces started here that are
+// recorded, 1 when unset; traces started by a caller follow its decision.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	ServiceName string  `mapstructure:"serviceName"`
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+// AuthConfig Default is the requirement of routes not listed in Routes:
+// public, authenticated, apiKey or jwt. API keys are cached for KeyCacheTTL
+// seconds; instances serving cached keys look for revocations every five
+// seconds.
+type AuthConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Default     string            `mapstructure:"default"`
+	KeyCacheTTL int               `mapstructure:"keyCacheTTL"`
+	Routes      []RouteAuthConfig `mapstructure:"routes"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+}
+
+// RouteAuthConfig Route is a method and route template, as in
+// "GET /products/:productId"
+type RouteAuthConfig struct {
+	Route   string `mapstructure:"route"`
+	Require string `mapstructure:"require"`
+}
+
+// JWTConfig bearer tokens are verified against the keys of JWKSFile and
+// Keys. Issuer and Audience are checked when set; the tenant and roles of
+// the caller are read from TenantClaim and RolesClaim.
+type JWTConfig struct {
+	JWKSFile    string         `mapstructure:"jwksFile"`
+	Keys        []JWTKeyConfig `mapstructure:"keys"`
+	Issuer      string         `mapstructure:"issuer"`
+	Audience    string         `mapstructure:"audience"`
+	TenantClaim string         `mapstructure:"tenantClaim"`
+	RolesClaim  string         `mapstructure:"rolesClaim"`
+}
+
+// JWTKeyConfig Algorithm is HS256, with a shared Secret, or RS256 or ES256,
+// with a PEM encoded PublicKey
+type JWTKeyConfig struct {
+	ID        string `mapstructure:"id"`
+	Algorithm string `mapstructure:"algorithm"`
+	Secret    string `mapstructure:"secret"`
+	PublicKey string `mapstructure:"publicKey"`
+}
+
+// RateLimitConfig each client may send a route Rate requests per second,
+// and up to Burst at once. Routes not listed in Routes take Default; a Rate
+// of zero leaves a route unlimited. Unauthenticated limits the requests
+// each address may fail to authenticate.
+type RateLimitConfig struct {
+	Enabled         bool                   `mapstructure:"enabled"`
+	Default         RateConfig             `mapstructure:"default"`
+	Routes          []RouteRateLimitConfig `mapstructure:"routes"`
+	Unauthenticated RateConfig             `mapstructure:"unauthenticated"`
+}
+
+// RateConfig Burst of zero is the requests of one second
+type RateConfig struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// RouteRateLimitConfig Route is a method and route template, as in
+// "POST /products/bulk"
+type RouteRateLimitConfig struct {
+	Route string  `mapstructure:"route"`
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// LimitsConfig request bodies are limited to MaxBodyBytes, or the limit of
+// their route in Routes. Zero takes the default.
+type LimitsConfig struct {
+	MaxBodyBytes int                    `mapstructure:"maxBodyBytes"`
+	Routes       []RouteBodyLimitConfig `mapstructure:"routes"`
+	Products     ProductLimitsConfig    `mapstructure:"products"`
+}
+
+// RouteBodyLimitConfig Route is a method and route template, as in
+// "POST /products/bulk"
+type RouteBodyLimitConfig struct {
+	Route        string `mapstructure:"route"`
+	MaxBodyBytes int    `mapstructure:"maxBodyBytes"`
+}
+
+// ProductLimitsConfig bounds bulk uploads: MaxPerRequest products, each with
+// up to MaxImages images, descriptions of up to MaxDescriptionLength
+// characters and other strings of up to MaxStringLength. Searches sorted by
+// price in a currency other than the base one are sorted after pricing, so
+// they may match at most MaxPriceSorted products. Zero takes the default.
+type ProductLimitsConfig struct {
+	MaxPerRequest        int `mapstructure:"maxPerRequest"`
+	MaxStringLength      int `mapstructure:"maxStringLength"`
+	MaxDescriptionLength int `mapstructure:"maxDescriptionLength"`
+	MaxImages            int `mapstructure:"maxImages"`
+	MaxPriceSorted       int `mapstructure:"maxPriceSorted"`
+}
+
+// TenancyConfig lists the tenants served by the deployment. Requests that
+// name no tenant are served as Default.
+type TenancyConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	Default string         `mapstructure:"default"`
+	Tenants []TenantConfig `mapstructure:"tenants"`
+}
+
+// TenantConfig MaxProducts of zero leaves the tenant's catalog unbounded
+type TenantConfig struct {
+	ID          string   `mapstructure:"id"`
+	Name        string   `mapstructure:"name"`
+	APIKeys     []string `mapstructure:"apiKeys"`
+	MaxProducts int      `mapstructure:"maxProducts"`
+}
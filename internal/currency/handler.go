@@ -0,0 +1,59 @@
+package currency
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) GetRatesHandler(ctx *gin.Context) {
+	rates, err := h.service.GetRates(ctx.Request.Context())
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RatesResponse{
+		Success: true,
+		Base:    rates.Base,
+		Rates:   rates.Currencies(),
+	})
+}
+
+func (h *Handler) UpdateRatesHandler(ctx *gin.Context) {
+	var req UpdateRatesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	rates, err := h.service.UpdateRates(ctx.Request.Context(), req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RatesResponse{
+		Success: true,
+		Base:    rates.Base,
+		Rates:   rates.Currencies(),
+	})
+}
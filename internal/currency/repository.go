@@ -0,0 +1,75 @@
+package currency
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ratesDocumentID = "rates"
+
+type Repository interface {
+	GetRates(ctx context.Context) (*RatesDocument, error)
+	SaveRates(ctx context.Context, doc RatesDocument) error
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidCurrencyRates"),
+	}
+}
+
+func (r *repositoryImpl) GetRates(ctx context.Context) (*RatesDocument, error) {
+	var doc RatesDocument
+	err := r.db.Read(ctx, "currency.GetRates", func(ctx context.Context) error {
+		return r.collection.FindOne(ctx, bson.M{"_id": ratesDocumentID}).Decode(&doc)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching currency rates",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &doc, nil
+}
+
+func (r *repositoryImpl) SaveRates(ctx context.Context, doc RatesDocument) error {
+	doc.ID = ratesDocumentID
+	err := r.db.Write(ctx, "currency.SaveRates", func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": ratesDocumentID}, doc, options.Replace().SetUpsert(true))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error saving currency rates",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
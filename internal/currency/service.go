@@ -0,0 +1,118 @@
+package currency
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const defaultRatesCacheTTL = 60 * time.Second
+
+type Service interface {
+	GetRates(ctx context.Context) (money.Rates, error)
+	UpdateRates(ctx context.Context, req UpdateRatesRequest) (money.Rates, error)
+}
+
+type serviceImpl struct {
+	repository  Repository
+	base        string
+	configRates map[string]string
+	ratesTTL    time.Duration
+
+	mu        sync.Mutex
+	rates     *money.Rates
+	expiresAt time.Time
+}
+
+func NewService(cfg config.Config, repo Repository) Service {
+	currencies := cfg.Get().Currencies
+	ratesTTL := time.Duration(currencies.RatesCacheTTL) * time.Second
+	if ratesTTL <= 0 {
+		ratesTTL = defaultRatesCacheTTL
+	}
+
+	return &serviceImpl{
+		repository:  repo,
+		base:        cfg.Get().BaseCurrency(),
+		configRates: currencies.Rates,
+		ratesTTL:    ratesTTL,
+	}
+}
+
+// GetRates returns the config rates overlaid with the ones set through the
+// admin endpoint, cached for ratesTTL
+func (s *serviceImpl) GetRates(ctx context.Context) (money.Rates, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.rates != nil && now.Before(s.expiresAt) {
+		return *s.rates, nil
+	}
+
+	doc, err := s.repository.GetRates(ctx)
+	if err != nil {
+		return money.Rates{}, err
+	}
+
+	var stored map[string]string
+	if doc != nil {
+		stored = doc.Rates
+	}
+	rates, err := s.buildRates(stored)
+	if err != nil {
+		logging.Error(ctx, logger.Format{Message: "Invalid currency rates", Data: map[string]string{"error": err.Error()}})
+		return money.Rates{}, types.NewInternalServerError()
+	}
+
+	s.rates = &rates
+	s.expiresAt = now.Add(s.ratesTTL)
+	return rates, nil
+}
+
+// UpdateRates replaces the rates set through the admin endpoint
+func (s *serviceImpl) UpdateRates(ctx context.Context, req UpdateRatesRequest) (money.Rates, error) {
+	stored := make(map[string]string, len(req.Rates))
+	for currency, rate := range req.Rates {
+		currency = strings.ToUpper(currency)
+		if currency == s.base {
+			return money.Rates{}, types.NewValidationError("cannot set a rate for the base currency " + s.base)
+		}
+		stored[currency] = rate
+	}
+
+	rates, err := s.buildRates(stored)
+	if err != nil {
+		return money.Rates{}, types.NewValidationError(err.Error())
+	}
+
+	if err := s.repository.SaveRates(ctx, RatesDocument{Rates: stored, UpdatedAt: time.Now().UTC()}); err != nil {
+		return money.Rates{}, err
+	}
+
+	s.mu.Lock()
+	s.rates = &rates
+	s.expiresAt = time.Now().Add(s.ratesTTL)
+	s.mu.Unlock()
+
+	logging.Info(ctx, logger.Format{Message: "Currency rates updated", Data: rates.Currencies()})
+	return rates, nil
+}
+
+func (s *serviceImpl) buildRates(stored map[string]string) (money.Rates, error) {
+	merged := make(map[string]string, len(s.configRates)+len(stored))
+	for currency, rate := range s.configRates {
+		merged[strings.ToUpper(currency)] = rate
+	}
+	for currency, rate := range stored {
+		merged[strings.ToUpper(currency)] = rate
+	}
+	return money.NewRates(s.base, merged)
+}
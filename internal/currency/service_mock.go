@@ -0,0 +1,22 @@
+package currency
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) GetRates(ctx context.Context) (money.Rates, error) {
+	ret := s.Mock.Called(ctx)
+	return ret.Get(0).(money.Rates), ret.Error(1)
+}
+
+func (s *MockService) UpdateRates(ctx context.Context, req UpdateRatesRequest) (money.Rates, error) {
+	ret := s.Mock.Called(ctx, req)
+	return ret.Get(0).(money.Rates), ret.Error(1)
+}
@@ -0,0 +1,21 @@
+package currency
+
+import "time"
+
+// RatesDocument holds conversion rates set through the admin endpoint. They
+// override the rates from config currency by currency.
+type RatesDocument struct {
+	ID        string            `bson:"_id"`
+	Rates     map[string]string `bson:"rates"`
+	UpdatedAt time.Time         `bson:"updatedAt"`
+}
+
+type UpdateRatesRequest struct {
+	Rates map[string]string `json:"rates" binding:"required"`
+}
+
+type RatesResponse struct {
+	Success bool              `json:"success"`
+	Base    string            `json:"base"`
+	Rates   map[string]string `json:"rates"`
+}
@@ -0,0 +1,9 @@
+package currency
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+)
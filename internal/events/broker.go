@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+// Broker fans events out to in-process subscribers such as caches. It never
+// blocks the dispatcher: a subscriber whose buffer is full is dropped and its
+// Done channel closed, so it can resubscribe and rebuild its state.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C      <-chan Event
+	Done   <-chan struct{}
+	events chan Event
+	done   chan struct{}
+	broker *Broker
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[*Subscription]struct{}{}}
+}
+
+func (b *Broker) Subscribe(buffer int) *Subscription {
+	events := make(chan Event, buffer)
+	done := make(chan struct{})
+	subscription := &Subscription{
+		C:      events,
+		Done:   done,
+		events: events,
+		done:   done,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Close stops delivery to the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (b *Broker) Name() string {
+	return "broker"
+}
+
+func (b *Broker) Exclusive() bool {
+	return false
+}
+
+func (b *Broker) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			logger.Error(logger.Format{Message: "Dropping slow event subscriber"})
+			b.remove(subscription)
+		}
+	}
+	return nil
+}
+
+// remove must be called with mu held
+func (b *Broker) remove(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.done)
+}
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultLeaseTTL     = 30 * time.Second
+	defaultGapTimeout   = 10 * time.Second
+	defaultSweepAfter   = 60 * time.Second
+	minRetryBackoff     = time.Second
+	maxRetryBackoff     = time.Minute
+	sweeperLease        = "sweeper"
+)
+
+// Dispatcher delivers the outbox to every sink and re-records the writes
+// whose event was lost
+type Dispatcher struct {
+	repository   Repository
+	sinks        Sinks
+	owner        string
+	pollInterval time.Duration
+	batchSize    int
+	leaseTTL     time.Duration
+	gapTimeout   time.Duration
+	sweepAfter   time.Duration
+}
+
+func NewDispatcher(cfg config.Config, repository Repository, sinks Sinks) *Dispatcher {
+	eventsConfig := cfg.Get().Events
+	dispatcher := &Dispatcher{
+		repository:   repository,
+		sinks:        sinks,
+		owner:        primitive.NewObjectID().Hex(),
+		pollInterval: seconds(eventsConfig.PollInterval, defaultPollInterval),
+		batchSize:    eventsConfig.BatchSize,
+		leaseTTL:     seconds(eventsConfig.LeaseTTL, defaultLeaseTTL),
+		gapTimeout:   seconds(eventsConfig.GapTimeout, defaultGapTimeout),
+		sweepAfter:   seconds(eventsConfig.SweepAfter, defaultSweepAfter),
+	}
+	if dispatcher.batchSize <= 0 {
+		dispatcher.batchSize = defaultBatchSize
+	}
+	return dispatcher
+}
+
+func seconds(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Run blocks until ctx is cancelled, delivering to each sink independently
+// so a failing sink does not hold back the others
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger.Info(logger.Format{Message: "Event dispatcher started", Data: map[string]string{"owner": d.owner}})
+
+	var wg sync.WaitGroup
+	for _, sink := range d.sinks {
+		wg.Add(1)
+		go func(c *consumer) {
+			defer wg.Done()
+			d.loop(ctx, c.poll)
+		}(d.newConsumer(ctx, sink))
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.loop(ctx, d.sweep)
+	}()
+
+	wg.Wait()
+	logger.Info(logger.Format{Message: "Event dispatcher stopped"})
+}
+
+// loop calls step until ctx is cancelled, waiting as long as step asks
+func (d *Dispatcher) loop(ctx context.Context, step func(ctx context.Context) time.Duration) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(step(ctx))
+		}
+	}
+}
+
+// sweep records a resync or deletion event for writes left pending longer
+// than sweepAfter, e.g. because the writer failed before recording
+func (d *Dispatcher) sweep(ctx context.Context) time.Duration {
+	if _, acquired, err := d.repository.AcquireLease(ctx, sweeperLease, d.owner, d.leaseTTL); err != nil || !acquired {
+		return d.pollInterval
+	}
+
+	snapshots, err := d.repository.GetUnsettledProducts(ctx, time.Now().UTC().Add(-d.sweepAfter), d.batchSize)
+	if err != nil || len(snapshots) == 0 {
+		return d.pollInterval
+	}
+
+	events := make([]Event, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		eventType := TypeProductUpdated
+		if snapshot.DeletedAt != nil {
+			eventType = TypeProductDeleted
+		}
+		events = append(events, NewEvent(eventType, snapshot, nil))
+	}
+	if err := d.repository.Record(ctx, events); err != nil {
+		return d.pollInterval
+	}
+
+	logger.Info(logger.Format{Message: "Recorded events for unsettled products", Data: map[string]string{"count": strconv.Itoa(len(events))}})
+	return 0
+}
+
+// consumer delivers events to one sink from its checkpoint, the sequence
+// number of the last event delivered
+type consumer struct {
+	*Dispatcher
+	sink       Sink
+	lease      string
+	checkpoint int64
+	gapSince   time.Time
+	backoff    time.Duration
+}
+
+func (d *Dispatcher) newConsumer(ctx context.Context, sink Sink) *consumer {
+	c := &consumer{
+		Dispatcher: d,
+		sink:       sink,
+		lease:      "sink:" + sink.Name(),
+	}
+	if !sink.Exclusive() {
+		// Local sinks start from now; if the sequence cannot be read they
+		// start from the beginning of the outbox rather than miss events
+		c.checkpoint, _ = d.repository.LastSeq(ctx)
+	}
+	return c
+}
+
+// poll delivers the next batch of events and returns how long to wait before
+// the next one
+func (c *consumer) poll(ctx context.Context) time.Duration {
+	if c.sink.Exclusive() {
+		checkpoint, acquired, err := c.repository.AcquireLease(ctx, c.lease, c.owner, c.leaseTTL)
+		if err != nil || !acquired {
+			return c.pollInterval
+		}
+		// Another instance may have delivered further while this one did not
+		// hold the lease
+		if checkpoint > c.checkpoint {
+			c.checkpoint = checkpoint
+		}
+	}
+
+	events, err := c.repository.ReadAfter(ctx, c.checkpoint, c.batchSize)
+	if err != nil {
+		return c.pollInterval
+	}
+
+	for _, event := range events {
+		if event.Seq != c.checkpoint+1 && !c.skipGap(event.Seq) {
+			return c.pollInterval
+		}
+
+		if err := c.sink.Publish(ctx, event); err != nil {
+			logger.Error(logger.Format{
+				Message: "Error publishing product event",
+				Data: map[string]string{
+					"error": err.Error(),
+					"sink":  c.sink.Name(),
+					"seq":   strconv.FormatInt(event.Seq, 10),
+				},
+			})
+			return c.retry()
+		}
+
+		c.checkpoint = event.Seq
+		c.gapSince = time.Time{}
+		c.backoff = 0
+		if c.sink.Exclusive() {
+			if held, err := c.repository.SaveCheckpoint(ctx, c.lease, c.owner, c.checkpoint); err != nil || !held {
+				return c.pollInterval
+			}
+		}
+	}
+
+	if len(events) == c.batchSize {
+		return 0
+	}
+	return c.pollInterval
+}
+
+// skipGap reports whether to move past the sequence numbers missing before
+// seq. Numbers are allocated before their event is inserted, so a gap is
+// usually a write still in flight; one that outlasts gapTimeout belongs to a
+// writer that failed or that leaves its product for the sweeper to pick up.
+func (c *consumer) skipGap(seq int64) bool {
+	now := time.Now()
+	if c.gapSince.IsZero() {
+		c.gapSince = now
+	}
+	if now.Sub(c.gapSince) < c.gapTimeout {
+		return false
+	}
+
+	logger.Error(logger.Format{
+		Message: "Skipping missing product events",
+		Data: map[string]string{
+			"sink": c.sink.Name(),
+			"from": strconv.FormatInt(c.checkpoint+1, 10),
+			"to":   strconv.FormatInt(seq-1, 10),
+		},
+	})
+	c.gapSince = time.Time{}
+	return true
+}
+
+func (c *consumer) retry() time.Duration {
+	if c.backoff == 0 {
+		c.backoff = minRetryBackoff
+	} else if c.backoff *= 2; c.backoff > maxRetryBackoff {
+		c.backoff = maxRetryBackoff
+	}
+	return c.backoff
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingSink struct {
+	failures  int
+	published []int64
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Exclusive() bool {
+	return true
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event Event) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, event.Seq)
+	return nil
+}
+
+type DispatcherTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	sink       *recordingSink
+	consumer   *consumer
+}
+
+func (ds *DispatcherTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		ds.T().Fatalf("Failed to initialize config: %v", err)
+	}
+	logger.Init(cfg.Get().Log.Level)
+	ds.repository = new(MockRepository)
+	ds.sink = &recordingSink{}
+	dispatcher := &Dispatcher{
+		repository:   ds.repository,
+		owner:        "instance-1",
+		pollInterval: time.Second,
+		batchSize:    10,
+		leaseTTL:     time.Minute,
+		gapTimeout:   time.Hour,
+	}
+	ds.consumer = dispatcher.newConsumer(context.Background(), ds.sink)
+	ds.repository.On("AcquireLease", mock.Anything, "sink:recording", "instance-1", time.Minute).Return(int64(0), true, nil)
+	ds.repository.On("SaveCheckpoint", mock.Anything, "sink:recording", "instance-1", mock.Anything).Return(true, nil)
+}
+
+func TestDispatcherSuite(t *testing.T) {
+	suite.Run(t, new(DispatcherTestSuite))
+}
+
+func (ds *DispatcherTestSuite) TestShouldResumeFromFailedEvent() {
+	ds.sink.failures = 1
+	ds.repository.On("ReadAfter", mock.Anything, int64(0), 10).Return([]Event{{Seq: 1}, {Seq: 2}}, nil).Once()
+	ds.repository.On("ReadAfter", mock.Anything, int64(1), 10).Return([]Event{{Seq: 2}, {Seq: 3}}, nil).Once()
+
+	// The first event is not delivered, so nothing after it is either
+	wait := ds.consumer.poll(context.Background())
+	assert.Equal(ds.T(), minRetryBackoff, wait)
+	assert.Empty(ds.T(), ds.sink.published)
+
+	ds.sink.failures = 0
+	ds.repository.On("ReadAfter", mock.Anything, int64(0), 10).Return([]Event{{Seq: 1}}, nil).Once()
+	ds.consumer.poll(context.Background())
+	ds.consumer.poll(context.Background())
+
+	assert.Equal(ds.T(), []int64{1, 2, 3}, ds.sink.published)
+	ds.repository.AssertCalled(ds.T(), "SaveCheckpoint", mock.Anything, "sink:recording", "instance-1", int64(3))
+}
+
+func (ds *DispatcherTestSuite) TestShouldWaitForMissingEventBeforeSkippingIt() {
+	ds.repository.On("ReadAfter", mock.Anything, int64(0), 10).Return([]Event{{Seq: 1}, {Seq: 3}}, nil).Once()
+	ds.repository.On("ReadAfter", mock.Anything, int64(1), 10).Return([]Event{{Seq: 3}}, nil)
+
+	ds.consumer.poll(context.Background())
+	assert.Equal(ds.T(), []int64{1}, ds.sink.published)
+
+	// Event 2 never arrives within the gap timeout
+	ds.consumer.gapSince = time.Now().Add(-2 * time.Hour)
+	ds.consumer.poll(context.Background())
+	assert.Equal(ds.T(), []int64{1, 3}, ds.sink.published)
+}
+
+func (ds *DispatcherTestSuite) TestShouldNotDeliverWithoutLease() {
+	repository := new(MockRepository)
+	repository.On("AcquireLease", mock.Anything, "sink:recording", "instance-1", time.Minute).Return(int64(0), false, nil)
+	ds.consumer.repository = repository
+
+	ds.consumer.poll(context.Background())
+
+	repository.AssertNotCalled(ds.T(), "ReadAfter", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ds *DispatcherTestSuite) TestShouldDropSlowSubscriber() {
+	broker := NewBroker()
+	slow := broker.Subscribe(1)
+	fast := broker.Subscribe(2)
+
+	_ = broker.Publish(context.Background(), Event{Seq: 1})
+	_ = broker.Publish(context.Background(), Event{Seq: 2})
+
+	assert.Equal(ds.T(), int64(1), (<-fast.C).Seq)
+	assert.Equal(ds.T(), int64(2), (<-fast.C).Seq)
+	_, open := <-slow.Done
+	assert.False(ds.T(), open)
+}
@@ -0,0 +1,442 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	sequenceID = "productEvents"
+	// maxAuditedUpdateAttempts bounds the retries of an audited update that
+	// lost the race with concurrent writes to the product
+	maxAuditedUpdateAttempts = 3
+)
+
+type Repository interface {
+	// Record appends events to the outbox and settles the product writes
+	// they describe
+	Record(ctx context.Context, events []Event) error
+	// Settle clears the pending marker of writes that changed nothing and so
+	// have no event
+	Settle(ctx context.Context, snapshots []Snapshot) error
+	// UpdateProduct applies update to the live product of the tenant of ctx
+	// matching filter and records the change, reporting whether a product
+	// matched
+	UpdateProduct(ctx context.Context, filter, update bson.M, changedFields []string) (bool, error)
+	ReadAfter(ctx context.Context, seq int64, limit int) ([]Event, error)
+	LastSeq(ctx context.Context) (int64, error)
+	// AcquireLease takes or renews the named lease for owner and returns the
+	// checkpoint stored with it
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (int64, bool, error)
+	SaveCheckpoint(ctx context.Context, name, owner string, seq int64) (bool, error)
+	// GetUnsettledProducts returns products whose pending marker was set
+	// before the given time, i.e. writes whose event was never recorded
+	GetUnsettledProducts(ctx context.Context, before time.Time, limit int) ([]Snapshot, error)
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+	sequences  *mongo.Collection
+	leases     *mongo.Collection
+	products   *mongo.Collection
+	// gapTimeout is how long consumers wait for a missing event before
+	// skipping it
+	gapTimeout time.Duration
+	now        func() time.Time
+}
+
+func NewRepository(cfg config.Config, db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidProductEvents"),
+		sequences:  db.Collection("rapidSequences"),
+		leases:     db.Collection("rapidEventLeases"),
+		products:   db.Collection("rapidProducts"),
+		gapTimeout: seconds(cfg.Get().Events.GapTimeout, defaultGapTimeout),
+		now:        time.Now,
+	}
+}
+
+// Track adds the version bump and pending marker every product write carries
+// to update, so that the write is either settled by its event or found by the
+// sweeper
+func Track(update bson.M, now time.Time) bson.M {
+	tracked := bson.M{}
+	for operator, fields := range update {
+		tracked[operator] = fields
+	}
+
+	set := bson.M{PendingField: now}
+	if fields, ok := update["$set"].(bson.M); ok {
+		for field, value := range fields {
+			set[field] = value
+		}
+	}
+	tracked["$set"] = set
+	tracked["$inc"] = bson.M{VersionField: int64(1)}
+	return tracked
+}
+
+// Live matches products that are not awaiting removal
+func Live(filter bson.M) bson.M {
+	live := bson.M{DeletedField: bson.M{"$exists": false}}
+	for field, value := range filter {
+		live[field] = value
+	}
+	return live
+}
+
+func (r *repositoryImpl) Record(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	allocatedAt := r.now()
+	last, err := r.nextSeq(ctx, int64(len(events)))
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error allocating event sequence",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	documents := make([]interface{}, 0, len(events))
+	snapshots := make([]Snapshot, 0, len(events))
+	for i, event := range events {
+		event.Seq = last - int64(len(events)-1-i)
+		documents = append(documents, event)
+		snapshots = append(snapshots, event.Product)
+	}
+	err = r.db.Write(ctx, "events.Record", func(ctx context.Context) error {
+		_, err := r.collection.InsertMany(ctx, documents)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error inserting product events",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	// Consumers skip a sequence number missing for gapTimeout, so events
+	// inserted that late may never be delivered. Their writes are left
+	// pending for the sweeper to record again.
+	if elapsed := r.now().Sub(allocatedAt); elapsed >= r.gapTimeout {
+		logging.Error(ctx, logger.Format{
+			Message: "Recorded product events too late to settle",
+			Data: map[string]string{
+				"from":    strconv.FormatInt(last-int64(len(events)-1), 10),
+				"to":      strconv.FormatInt(last, 10),
+				"elapsed": elapsed.String(),
+			},
+		})
+		return nil
+	}
+
+	return r.Settle(ctx, snapshots)
+}
+
+// Settle clears the pending marker of each write, and removes products whose
+// deletion was recorded. Writes made since carry a newer version and are left
+// for their own event.
+func (r *repositoryImpl) Settle(ctx context.Context, snapshots []Snapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		filter := bson.M{"_id": snapshot.ID, VersionField: snapshot.Version}
+		if snapshot.DeletedAt != nil {
+			models = append(models, mongo.NewDeleteOneModel().SetFilter(filter))
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$unset": bson.M{PendingField: ""}}))
+	}
+
+	err := r.db.Write(ctx, "events.Settle", func(ctx context.Context) error {
+		_, err := r.products.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error settling product writes",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) UpdateProduct(ctx context.Context, filter, update bson.M, changedFields []string) (bool, error) {
+	filter = tenant.Filter(ctx, filter)
+	if !audit.Active(ctx) {
+		updated, err := r.updateProduct(ctx, filter, update)
+		if err != nil || updated == nil {
+			return false, err
+		}
+		r.recordUpdate(ctx, updated, changedFields)
+		return true, nil
+	}
+
+	// An audited update reads the product first and only applies if it is
+	// still at that version, so the recorded before values are exact
+	for attempt := 1; ; attempt++ {
+		var before bson.M
+		err := r.db.Read(ctx, "events.UpdateProduct", func(ctx context.Context) error {
+			return r.products.FindOne(ctx, Live(filter)).Decode(&before)
+		})
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return false, nil
+			}
+			logging.Error(ctx, logger.Format{
+				Message: "Error fetching product before update",
+				Data: map[string]string{
+					"error": err.Error(),
+				},
+			})
+			return false, utils.DatastoreError(err)
+		}
+
+		pinned := bson.M{"_id": before["_id"], VersionField: before[VersionField]}
+		if _, versioned := before[VersionField]; !versioned {
+			pinned[VersionField] = bson.M{"$exists": false}
+		}
+		for field, value := range filter {
+			if _, ok := pinned[field]; !ok {
+				pinned[field] = value
+			}
+		}
+
+		updated, err := r.updateProduct(ctx, pinned, update)
+		if err != nil {
+			return false, err
+		}
+		if updated == nil {
+			if attempt < maxAuditedUpdateAttempts {
+				continue
+			}
+			logging.Error(ctx, logger.Format{
+				Message: "Product kept changing during audited update",
+				Data: map[string]string{
+					"attempts": strconv.Itoa(attempt),
+				},
+			})
+			return false, types.NewInternalServerError()
+		}
+
+		var after bson.M
+		if err := bson.Unmarshal(updated, &after); err == nil {
+			productID, _ := before["_id"].(primitive.ObjectID)
+			audit.Record(ctx, audit.Diff(productID, changedFields, before, after)...)
+		}
+		r.recordUpdate(ctx, updated, changedFields)
+		return true, nil
+	}
+}
+
+// updateProduct applies update to the live product matching filter and
+// returns the product after it, or nil when none matched
+func (r *repositoryImpl) updateProduct(ctx context.Context, filter, update bson.M) (bson.Raw, error) {
+	findOneAndUpdateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated bson.Raw
+	err := r.db.Write(ctx, "events.UpdateProduct", func(ctx context.Context) error {
+		var err error
+		updated, err = r.products.FindOneAndUpdate(ctx, Live(filter), Track(update, time.Now().UTC()), findOneAndUpdateOptions).DecodeBytes()
+		return err
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error updating product",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+	return updated, nil
+}
+
+// recordUpdate records the event for an update that left the product at
+// updated. The write succeeded; if recording fails the sweeper records it
+// later.
+func (r *repositoryImpl) recordUpdate(ctx context.Context, updated bson.Raw, changedFields []string) {
+	var snapshot Snapshot
+	if err := bson.Unmarshal(updated, &snapshot); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to decode updated product", Data: map[string]string{"error": err.Error()}})
+		return
+	}
+	if err := r.Record(ctx, []Event{NewEvent(TypeProductUpdated, snapshot, changedFields)}); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to record product event", Data: map[string]string{"error": err.Error(), "productID": snapshot.ID.Hex()}})
+	}
+}
+
+func (r *repositoryImpl) nextSeq(ctx context.Context, n int64) (int64, error) {
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+	findOneAndUpdateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.db.Write(ctx, "events.nextSeq", func(ctx context.Context) error {
+		return r.sequences.FindOneAndUpdate(ctx, bson.M{"_id": sequenceID}, bson.M{"$inc": bson.M{"value": n}}, findOneAndUpdateOptions).Decode(&sequence)
+	})
+	return sequence.Value, err
+}
+
+func (r *repositoryImpl) ReadAfter(ctx context.Context, seq int64, limit int) ([]Event, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(int64(limit))
+	events := []Event{}
+	err := r.db.Read(ctx, "events.ReadAfter", func(ctx context.Context) error {
+		cursor, err := r.collection.Find(ctx, bson.M{"seq": bson.M{"$gt": seq}}, findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &events)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error reading product events",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return events, nil
+}
+
+func (r *repositoryImpl) LastSeq(ctx context.Context) (int64, error) {
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+	err := r.db.Read(ctx, "events.LastSeq", func(ctx context.Context) error {
+		return r.sequences.FindOne(ctx, bson.M{"_id": sequenceID}).Decode(&sequence)
+	})
+	if err != nil && err != mongo.ErrNoDocuments {
+		logging.Error(ctx, logger.Format{
+			Message: "Error reading event sequence",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return 0, utils.DatastoreError(err)
+	}
+
+	return sequence.Value, nil
+}
+
+func (r *repositoryImpl) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (int64, bool, error) {
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"leaseUntil": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "leaseUntil": now.Add(ttl)}}
+
+	var lease struct {
+		Checkpoint int64 `bson:"checkpoint"`
+	}
+	findOneAndUpdateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.db.Write(ctx, "events.AcquireLease", func(ctx context.Context) error {
+		return r.leases.FindOneAndUpdate(ctx, filter, update, findOneAndUpdateOptions).Decode(&lease)
+	})
+	if err != nil {
+		// Another owner holds a live lease, so the upsert collided with it
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, false, nil
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error acquiring event lease",
+			Data: map[string]string{
+				"error": err.Error(),
+				"lease": name,
+			},
+		})
+		return 0, false, utils.DatastoreError(err)
+	}
+
+	return lease.Checkpoint, true, nil
+}
+
+func (r *repositoryImpl) SaveCheckpoint(ctx context.Context, name, owner string, seq int64) (bool, error) {
+	var result *mongo.UpdateResult
+	err := r.db.Write(ctx, "events.SaveCheckpoint", func(ctx context.Context) error {
+		var err error
+		result, err = r.leases.UpdateOne(ctx, bson.M{"_id": name, "owner": owner}, bson.M{"$set": bson.M{"checkpoint": seq}})
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error saving event checkpoint",
+			Data: map[string]string{
+				"error": err.Error(),
+				"lease": name,
+			},
+		})
+		return false, utils.DatastoreError(err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+func (r *repositoryImpl) GetUnsettledProducts(ctx context.Context, before time.Time, limit int) ([]Snapshot, error) {
+	findOptions := options.Find().SetLimit(int64(limit))
+	snapshots := []Snapshot{}
+	err := r.db.Read(ctx, "events.GetUnsettledProducts", func(ctx context.Context) error {
+		cursor, err := r.products.Find(ctx, bson.M{PendingField: bson.M{"$lt": before}}, findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &snapshots)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching unsettled products",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return snapshots, nil
+}
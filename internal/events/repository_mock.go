@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (r *MockRepository) Record(ctx context.Context, events []Event) error {
+	ret := r.Mock.Called(ctx, events)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) Settle(ctx context.Context, snapshots []Snapshot) error {
+	ret := r.Mock.Called(ctx, snapshots)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) UpdateProduct(ctx context.Context, filter, update bson.M, changedFields []string) (bool, error) {
+	ret := r.Mock.Called(ctx, filter, update, changedFields)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (r *MockRepository) ReadAfter(ctx context.Context, seq int64, limit int) ([]Event, error) {
+	ret := r.Mock.Called(ctx, seq, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Event), ret.Error(1)
+}
+
+func (r *MockRepository) LastSeq(ctx context.Context) (int64, error) {
+	ret := r.Mock.Called(ctx)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func (r *MockRepository) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (int64, bool, error) {
+	ret := r.Mock.Called(ctx, name, owner, ttl)
+	return ret.Get(0).(int64), ret.Bool(1), ret.Error(2)
+}
+
+func (r *MockRepository) SaveCheckpoint(ctx context.Context, name, owner string, seq int64) (bool, error) {
+	ret := r.Mock.Called(ctx, name, owner, seq)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (r *MockRepository) GetUnsettledProducts(ctx context.Context, before time.Time, limit int) ([]Snapshot, error) {
+	ret := r.Mock.Called(ctx, before, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Snapshot), ret.Error(1)
+}
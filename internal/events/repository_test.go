@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// repositoryMongoURI names the environment variable pointing the repository
+// tests at a Mongo deployment; without it they are skipped
+const repositoryMongoURI = "RAPID_CONFORMANCE_MONGO_URI"
+
+type EventsRepositoryTestSuite struct {
+	suite.Suite
+	client     *mongo.Client
+	db         *utils.DBInstance
+	repository *repositoryImpl
+	now        time.Time
+}
+
+func TestEventsRepositorySuite(t *testing.T) {
+	uri := os.Getenv(repositoryMongoURI)
+	if uri == "" {
+		t.Skipf("%s is not set", repositoryMongoURI)
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Failed to connect to mongo: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	suite.Run(t, &EventsRepositoryTestSuite{client: client})
+}
+
+func (er *EventsRepositoryTestSuite) SetupTest() {
+	logger.Init("debug")
+	er.db = &utils.DBInstance{TestDB: er.client.Database("rapidEvents_" + primitive.NewObjectID().Hex())}
+	er.repository = NewRepository(&config.Values{Events: config.EventsConfig{GapTimeout: 10}}, er.db).(*repositoryImpl)
+	er.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	er.repository.now = func() time.Time { return er.now }
+}
+
+func (er *EventsRepositoryTestSuite) TearDownTest() {
+	er.db.TestDB.Drop(context.Background())
+}
+
+// pendingProduct stores a product whose write awaits its event
+func (er *EventsRepositoryTestSuite) pendingProduct() Snapshot {
+	snapshot := Snapshot{ID: primitive.NewObjectID(), TenantID: "retail", Name: "Titan Edge", Version: 1}
+	_, err := er.db.TestDB.Collection("rapidProducts").InsertOne(context.Background(), bson.M{
+		"_id":        snapshot.ID,
+		"tenantId":   snapshot.TenantID,
+		"name":       snapshot.Name,
+		VersionField: snapshot.Version,
+		PendingField: er.now,
+	})
+	er.Require().NoError(err)
+	return snapshot
+}
+
+func (er *EventsRepositoryTestSuite) TestShouldSettleEventRecordedInTime() {
+	snapshot := er.pendingProduct()
+
+	er.Require().NoError(er.repository.Record(context.Background(), []Event{NewEvent(TypeProductUpdated, snapshot, nil)}))
+
+	unsettled, err := er.repository.GetUnsettledProducts(context.Background(), er.now.Add(time.Hour), 10)
+	er.Require().NoError(err)
+	assert.Empty(er.T(), unsettled)
+}
+
+func (er *EventsRepositoryTestSuite) TestShouldLeaveWriteToSweeperWhenEventIsInsertedAfterConsumersSkipIt() {
+	snapshot := er.pendingProduct()
+	// The insert lands once consumers have waited out the gap and moved
+	// past its sequence number
+	er.repository.now = func() time.Time {
+		now := er.now
+		er.now = er.now.Add(er.repository.gapTimeout)
+		return now
+	}
+
+	er.Require().NoError(er.repository.Record(context.Background(), []Event{NewEvent(TypeProductUpdated, snapshot, nil)}))
+
+	unsettled, err := er.repository.GetUnsettledProducts(context.Background(), er.now.Add(time.Hour), 10)
+	er.Require().NoError(err)
+	if assert.Len(er.T(), unsettled, 1) {
+		assert.Equal(er.T(), snapshot.ID, unsettled[0].ID)
+	}
+}
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// Sink receives product events in sequence order. Publish is retried until it
+// succeeds, so a sink sees an event at least once.
+type Sink interface {
+	Name() string
+	// Exclusive sinks are delivered to by one instance at a time, from a
+	// checkpoint shared by all instances. Other sinks are local to the
+	// instance and receive the events recorded after it started.
+	Exclusive() bool
+	Publish(ctx context.Context, event Event) error
+}
+
+type Sinks []Sink
+
+// SubscriptionSink delivers events to the webhook subscriptions managed
+// through the API
+type SubscriptionSink interface {
+	Sink
+}
+
+// NewSinks returns the in-process broker, the subscription sink and the sinks
+// enabled in config
+func NewSinks(cfg config.Config, broker *Broker, subscriptions SubscriptionSink, httpClient utils.HTTPClient) Sinks {
+	sinks := Sinks{broker, subscriptions}
+
+	eventsConfig := cfg.Get().Events
+	if eventsConfig.FileSink.Enabled {
+		sinks = append(sinks, NewFileSink(eventsConfig.FileSink.Path))
+	}
+	if eventsConfig.WebhookSink.Enabled {
+		timeout := time.Duration(eventsConfig.WebhookSink.Timeout) * time.Second
+		sinks = append(sinks, NewWebhookSink(httpClient, eventsConfig.WebhookSink.URL, timeout))
+	}
+	return sinks
+}
+
+// WebhookSink posts each event as JSON; any status other than 2xx fails the
+// delivery
+type WebhookSink struct {
+	httpClient utils.HTTPClient
+	client     *http.Client
+	url        string
+	timeout    time.Duration
+}
+
+func NewWebhookSink(httpClient utils.HTTPClient, url string, timeout time.Duration) *WebhookSink {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSink{
+		httpClient: httpClient,
+		client:     &http.Client{},
+		url:        url,
+		timeout:    timeout,
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Exclusive() bool {
+	return true
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	response, err := s.httpClient.Post(utils.HTTPPayload{
+		Context: ctx,
+		Client:  s.client,
+		URL:     s.url,
+		Body:    event,
+		Timeout: s.timeout,
+	})
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// FileSink appends each event to a file as one line of JSON
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Exclusive() bool {
+	return true
+}
+
+func (s *FileSink) Publish(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		// Reopen on the next attempt in case the file was rotated away
+		_ = s.file.Close()
+		s.file = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TypeProductCreated = "product.created"
+	TypeProductUpdated = "product.updated"
+	TypeProductDeleted = "product.deleted"
+)
+
+// Fields product writes set alongside their change. VersionField counts the
+// writes to a product and PendingField marks a write whose event is not yet
+// in the outbox. DeletedField marks a product that is removed once its
+// deletion event is recorded.
+const (
+	VersionField = "version"
+	PendingField = "outboxPendingAt"
+	DeletedField = "deletedAt"
+)
+
+// Event is a change to one product. Events carry a global sequence number and
+// sinks consume them in sequence order, which keeps the events of each product
+// in version order. Delivery is at least once, so consumers should ignore an
+// event whose version is not newer than one they have applied. An update
+// event without ChangedFields is a resync: any field may have changed.
+type Event struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Seq           int64              `json:"seq" bson:"seq"`
+	TenantID      string             `json:"tenantId" bson:"tenantId"`
+	Type          string             `json:"type" bson:"type"`
+	ProductID     primitive.ObjectID `json:"productId" bson:"productId"`
+	Version       int64              `json:"version" bson:"version"`
+	ChangedFields []string           `json:"changedFields,omitempty" bson:"changedFields,omitempty"`
+	Product       Snapshot           `json:"product" bson:"product"`
+	OccurredAt    time.Time          `json:"occurredAt" bson:"occurredAt"`
+}
+
+// Snapshot is the state of the product after the change, or before it for a
+// deletion. Its bson tags follow the product document so it can be decoded
+// from one directly.
+type Snapshot struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	TenantID  string             `json:"-" bson:"tenantId"`
+	Name      string             `json:"name" bson:"name"`
+	Category  string             `json:"category" bson:"category"`
+	Brand     string             `json:"brand" bson:"brand"`
+	Price     money.Money        `json:"price" bson:"price"`
+	Inventory int                `json:"inventory" bson:"availableQty"`
+	Version   int64              `json:"-" bson:"version"`
+	DeletedAt *time.Time         `json:"-" bson:"deletedAt,omitempty"`
+}
+
+// NewEvent builds the event for a write that left product at snapshot
+func NewEvent(eventType string, snapshot Snapshot, changedFields []string) Event {
+	return Event{
+		Type:          eventType,
+		TenantID:      snapshot.TenantID,
+		ProductID:     snapshot.ID,
+		Version:       snapshot.Version,
+		ChangedFields: changedFields,
+		Product:       snapshot,
+		OccurredAt:    time.Now().UTC(),
+	}
+}
@@ -0,0 +1,10 @@
+package events
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewRepository,
+	NewBroker,
+	NewSinks,
+	NewDispatcher,
+)
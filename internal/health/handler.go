@@ -7,26 +7,38 @@ import (
 )
 
 type Handler struct {
+	registry *Registry
 }
 
-type Response struct {
-	Client string `json:"client,omitempty"`
-	Status string `json:"status"`
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{
+		registry: registry,
+	}
 }
 
-const (
-	UP   string = "UP"
-	DOWN string = "DOWN"
-)
-
-func NewHandler() *Handler {
-	return &Handler{}
+func (h *Handler) CheckSanity(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, Response{Status: UP})
 }
 
-func (h *Handler) CheckSanity(ctx *gin.Context) {
+// CheckLive reports the process able to serve requests, whatever the state
+// of its dependencies, so that it is only restarted when it is stuck
+func (h *Handler) CheckLive(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, Response{Status: UP})
 }
 
+// CheckReady reports whether the instance should receive traffic: every
+// dependency is UP and it is not shutting down
+func (h *Handler) CheckReady(ctx *gin.Context) {
+	response := h.registry.Readiness()
+	status := http.StatusOK
+	if response.Status != UP {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, response)
+}
+
+// CheckHealth reports the same as CheckReady, for monitors polling the
+// original endpoint
 func (h *Handler) CheckHealth(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, Response{Status: UP})
+	h.CheckReady(ctx)
 }
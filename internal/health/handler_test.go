@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthHandlerTestSuite struct {
+	suite.Suite
+	registry *Registry
+	router   *gin.Engine
+	now      time.Time
+}
+
+func (hh *HealthHandlerTestSuite) SetupTest() {
+	logger.Init("debug")
+	hh.now = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	hh.registry = NewRegistry(&config.Values{Health: config.HealthConfig{CacheTTL: 5, Timeout: 1}})
+	hh.registry.now = func() time.Time { return hh.now }
+
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(hh.registry)
+	hh.router = gin.New()
+	hh.router.GET("/live", handler.CheckLive)
+	hh.router.GET("/ready", handler.CheckReady)
+	hh.router.GET("/health", handler.CheckHealth)
+}
+
+func TestHealthHandlerSuite(t *testing.T) {
+	suite.Run(t, new(HealthHandlerTestSuite))
+}
+
+func (hh *HealthHandlerTestSuite) get(path string) (int, Response) {
+	recorder := httptest.NewRecorder()
+	hh.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response Response
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldReportReadyWhenEveryCheckPasses() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return nil })
+	running := &Running{}
+	running.Start()
+	hh.registry.Register("worker:priceScheduler", running.Check)
+
+	code, response := hh.get("/ready")
+
+	assert.Equal(hh.T(), http.StatusOK, code)
+	assert.Equal(hh.T(), UP, response.Status)
+	hh.Require().Len(response.Checks, 2)
+	assert.Equal(hh.T(), "mongo:testDB", response.Checks[0].Name)
+	assert.Equal(hh.T(), UP, response.Checks[0].Status)
+	assert.Equal(hh.T(), hh.now, response.Checks[0].CheckedAt)
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldReportFailingDependency() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return errors.New("server selection timeout") })
+	hh.registry.Register("worker:priceScheduler", (&Running{}).Check)
+
+	code, response := hh.get("/health")
+
+	assert.Equal(hh.T(), http.StatusServiceUnavailable, code)
+	assert.Equal(hh.T(), DOWN, response.Status)
+	hh.Require().Len(response.Checks, 2)
+	assert.Equal(hh.T(), "server selection timeout", response.Checks[0].Error)
+	assert.Equal(hh.T(), DOWN, response.Checks[1].Status, "a stopped worker is down")
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldStayLiveWhenDependenciesFail() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return errors.New("unreachable") })
+
+	code, response := hh.get("/live")
+
+	assert.Equal(hh.T(), http.StatusOK, code)
+	assert.Equal(hh.T(), UP, response.Status)
+	assert.Empty(hh.T(), response.Checks)
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldCacheResultsForCacheTTL() {
+	pings := 0
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error {
+		pings++
+		return nil
+	})
+
+	hh.get("/ready")
+	hh.now = hh.now.Add(4 * time.Second)
+	hh.get("/ready")
+	assert.Equal(hh.T(), 1, pings)
+
+	hh.now = hh.now.Add(time.Second)
+	hh.get("/ready")
+	assert.Equal(hh.T(), 2, pings)
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldBoundChecksByTimeout() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(hh.T(), ok)
+		return nil
+	})
+
+	hh.get("/ready")
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldReportNotReadyWhileDraining() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return nil })
+	hh.registry.Drain()
+
+	readyCode, ready := hh.get("/ready")
+	liveCode, _ := hh.get("/live")
+
+	assert.Equal(hh.T(), http.StatusServiceUnavailable, readyCode)
+	assert.Equal(hh.T(), DOWN, ready.Status)
+	assert.Equal(hh.T(), "server", ready.Checks[len(ready.Checks)-1].Name)
+	assert.Equal(hh.T(), http.StatusOK, liveCode, "a draining instance finishes its requests")
+}
+
+func (hh *HealthHandlerTestSuite) TestShouldReplaceCheckRegisteredUnderSameName() {
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return errors.New("unreachable") })
+	hh.registry.Register("mongo:testDB", func(ctx context.Context) error { return nil })
+
+	code, response := hh.get("/ready")
+
+	assert.Equal(hh.T(), http.StatusOK, code)
+	assert.Len(hh.T(), response.Checks, 1)
+}
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const (
+	defaultCacheTTL     = 2 * time.Second
+	defaultCheckTimeout = 2 * time.Second
+)
+
+var errDraining = errors.New("shutting down")
+
+type registeredCheck struct {
+	name  string
+	check Check
+}
+
+// Registry holds the checks components register for their dependencies.
+// Their results are cached for the cache TTL, so that frequent probes from
+// several sources do not each ping every dependency.
+type Registry struct {
+	cacheTTL time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	checks    []registeredCheck
+	results   []CheckResult
+	checkedAt time.Time
+
+	draining int32
+}
+
+func NewRegistry(cfg config.Config) *Registry {
+	healthConfig := cfg.Get().Health
+	return &Registry{
+		cacheTTL: seconds(healthConfig.CacheTTL, defaultCacheTTL),
+		timeout:  seconds(healthConfig.Timeout, defaultCheckTimeout),
+		now:      time.Now,
+	}
+}
+
+func seconds(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Register adds a check reported under name; a later registration under the
+// same name replaces it
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i].check = check
+			r.results = nil
+			return
+		}
+	}
+	r.checks = append(r.checks, registeredCheck{name: name, check: check})
+	r.results = nil
+}
+
+// Drain marks the instance as shutting down, so that it reports not ready
+// while in-flight requests complete
+func (r *Registry) Drain() {
+	atomic.StoreInt32(&r.draining, 1)
+}
+
+func (r *Registry) Draining() bool {
+	return atomic.LoadInt32(&r.draining) == 1
+}
+
+// Readiness is UP when every check passes and the instance is not draining
+func (r *Registry) Readiness() Response {
+	results := r.Results()
+	status := UP
+	for _, result := range results {
+		if result.Status != UP {
+			status = DOWN
+		}
+	}
+	if r.Draining() {
+		status = DOWN
+		results = append(results, CheckResult{Name: "server", Status: DOWN, Error: errDraining.Error(), CheckedAt: r.now()})
+	}
+	return Response{Status: status, Checks: results}
+}
+
+// Results returns the outcome of every check, running them again once the
+// cached results are older than the cache TTL. Callers arriving during a
+// run wait for it rather than starting their own. Checks are not bound to a
+// caller's request, so that one giving up does not fail them for the rest.
+func (r *Registry) Results() []CheckResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.results != nil && r.now().Sub(r.checkedAt) < r.cacheTTL {
+		return append([]CheckResult{}, r.results...)
+	}
+
+	results := make([]CheckResult, len(r.checks))
+	var checks sync.WaitGroup
+	for i, registered := range r.checks {
+		checks.Add(1)
+		go func(i int, registered registeredCheck) {
+			defer checks.Done()
+			results[i] = r.run(registered)
+		}(i, registered)
+	}
+	checks.Wait()
+
+	r.results = results
+	r.checkedAt = r.now()
+	return append([]CheckResult{}, results...)
+}
+
+func (r *Registry) run(registered registeredCheck) CheckResult {
+	checkCtx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	start := r.now()
+	err := registered.check(checkCtx)
+	result := CheckResult{
+		Name:      registered.name,
+		Status:    UP,
+		Latency:   r.now().Sub(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = DOWN
+		result.Error = err.Error()
+		logger.Error(logger.Format{
+			Message: "Health check failed",
+			Data: map[string]string{
+				"check": registered.name,
+				"error": err.Error(),
+			},
+		})
+	}
+	return result
+}
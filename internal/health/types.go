@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+type Response struct {
+	Client string `json:"client,omitempty"`
+	Status string `json:"status"`
+	// Checks reports each dependency the status was derived from
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	UP   string = "UP"
+	DOWN string = "DOWN"
+)
+
+// Check reports whether a dependency is usable, returning why not
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of a dependency's last check. Latency is in
+// milliseconds.
+type CheckResult struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Latency   int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var errStopped = errors.New("worker is not running")
+
+// Running tracks a background worker, whose check fails while it is not
+// running
+type Running struct {
+	running int32
+}
+
+func (r *Running) Start() {
+	atomic.StoreInt32(&r.running, 1)
+}
+
+func (r *Running) Stop() {
+	atomic.StoreInt32(&r.running, 0)
+}
+
+func (r *Running) Check(ctx context.Context) error {
+	if atomic.LoadInt32(&r.running) == 0 {
+		return errStopped
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultLocaleMu sync.RWMutex
+	defaultLocale   = "en"
+)
+
+// SetDefault sets the locale of the content held in a product's base name
+// and description fields
+func SetDefault(tag string) {
+	normalized, ok := Normalize(tag)
+	if !ok {
+		return
+	}
+	defaultLocaleMu.Lock()
+	defer defaultLocaleMu.Unlock()
+	defaultLocale = normalized
+}
+
+func Default() string {
+	defaultLocaleMu.RLock()
+	defer defaultLocaleMu.RUnlock()
+	return defaultLocale
+}
+
+// Normalize canonicalizes a BCP 47 style tag such as "hi_in" to "hi-IN": the
+// language in lower case, a script in title case and a region in upper case.
+// It reports false for anything that is not a well formed tag.
+func Normalize(tag string) (string, bool) {
+	tag = strings.TrimSpace(strings.Replace(tag, "_", "-", -1))
+	if tag == "" {
+		return "", false
+	}
+
+	subtags := strings.Split(tag, "-")
+	for i, subtag := range subtags {
+		if len(subtag) == 0 || len(subtag) > 8 || !isAlphanumeric(subtag) {
+			return "", false
+		}
+		switch {
+		case i == 0:
+			if len(subtag) < 2 || len(subtag) > 3 || !isAlpha(subtag) {
+				return "", false
+			}
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 4 && isAlpha(subtag):
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2 && isAlpha(subtag), len(subtag) == 3 && isDigits(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-"), true
+}
+
+// Language returns the primary language subtag of a normalized tag
+func Language(tag string) string {
+	if i := strings.Index(tag, "-"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// ParseAcceptLanguage returns the tags of an Accept-Language header, most
+// preferred first. Wildcards, malformed tags and tags with q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag, ok := Normalize(fields[0])
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			quality = q
+		}
+		if quality > 0 {
+			ranges = append(ranges, weighted{tag: tag, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	tags := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		tags = append(tags, r.tag)
+	}
+	return tags
+}
+
+// Chain returns the translation keys to try for the preferred tags, in
+// order. Each tag is followed by its bare language, so "hi-IN" falls back to
+// "hi". The chain stops at the first tag in the default locale's language,
+// since that content is in the base fields every product has.
+func Chain(preferred []string) []string {
+	defaultTag := Default()
+	var chain []string
+	seen := map[string]bool{defaultTag: true}
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			chain = append(chain, tag)
+		}
+	}
+
+	for _, tag := range preferred {
+		add(tag)
+		if Language(tag) == Language(defaultTag) {
+			break
+		}
+		add(Language(tag))
+	}
+	return chain
+}
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LocaleTestSuite struct {
+	suite.Suite
+}
+
+func (ls *LocaleTestSuite) SetupTest() {
+	SetDefault("en")
+}
+
+func TestLocaleSuite(t *testing.T) {
+	suite.Run(t, new(LocaleTestSuite))
+}
+
+func (ls *LocaleTestSuite) TestShouldNormalizeTags() {
+	tag, ok := Normalize("zh_hant_tw")
+
+	assert.True(ls.T(), ok)
+	assert.Equal(ls.T(), "zh-Hant-TW", tag)
+
+	_, ok = Normalize("hi.IN")
+	assert.False(ls.T(), ok)
+}
+
+func (ls *LocaleTestSuite) TestShouldOrderAcceptLanguageByQuality() {
+	tags := ParseAcceptLanguage("ta;q=0.5, hi-IN, *;q=0.1, en;q=0.8, fr;q=0")
+
+	assert.Equal(ls.T(), []string{"hi-IN", "en", "ta"}, tags)
+}
+
+func (ls *LocaleTestSuite) TestShouldFallBackToLanguageAndStopAtDefault() {
+	assert.Equal(ls.T(), []string{"hi-IN", "hi", "ta"}, Chain([]string{"hi-IN", "ta"}))
+	assert.Equal(ls.T(), []string{"mr", "en-GB"}, Chain([]string{"mr", "en-GB", "hi"}))
+	assert.Empty(ls.T(), Chain([]string{"en", "hi"}))
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+// Fields returns data with the request ID and trace of ctx added, so that
+// log lines can be correlated with the request and trace they were written
+// for. data itself is not changed.
+func Fields(ctx context.Context, data map[string]string) map[string]string {
+	fields := make(map[string]string, len(data)+3)
+	for key, value := range data {
+		fields[key] = value
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		fields["requestId"] = id
+	}
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+		fields["traceId"] = sc.TraceID.String()
+		fields["spanId"] = sc.SpanID.String()
+	}
+	return fields
+}
+
+func Info(ctx context.Context, format logger.Format) {
+	format.Data = Fields(ctx, format.Data)
+	logger.Info(format)
+}
+
+func Error(ctx context.Context, format logger.Format) {
+	format.Data = Fields(ctx, format.Data)
+	logger.Error(format)
+}
+
+func Debug(ctx context.Context, format logger.Format) {
+	format.Data = Fields(ctx, format.Data)
+	logger.Debug(format)
+}
@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldsShouldAddRequestIDAndTrace(t *testing.T) {
+	ctx, span := tracing.Start(requestid.WithID(context.Background(), "req-1"), "search", tracing.KindInternal)
+	data := map[string]string{"error": "timeout"}
+
+	fields := Fields(ctx, data)
+
+	assert.Equal(t, "req-1", fields["requestId"])
+	assert.Equal(t, span.Context().TraceID.String(), fields["traceId"])
+	assert.Equal(t, span.Context().SpanID.String(), fields["spanId"])
+	assert.Equal(t, "timeout", fields["error"])
+	assert.Equal(t, map[string]string{"error": "timeout"}, data, "the caller's data is not changed")
+}
+
+func TestFieldsShouldAddNothingOutsideRequest(t *testing.T) {
+	assert.Equal(t, map[string]string{"error": "timeout"}, Fields(context.Background(), map[string]string{"error": "timeout"}))
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// unmatchedRoute labels requests matching no route, so that probing
+// arbitrary paths cannot create unbounded series
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP requests served, by route and status.", "method", "route", "status")
+	httpRequestDuration = NewHistogramVec("http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route and status.", DurationBuckets, "method", "route", "status")
+)
+
+type Handler struct {
+	registry *Registry
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		registry: Default,
+	}
+}
+
+// Middleware records the count and latency of each request under its route
+// template, so that requests for different products share a series
+func (h *Handler) Middleware(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	status := strconv.Itoa(ctx.Writer.Status())
+	httpRequests.Inc(ctx.Request.Method, route, status)
+	httpRequestDuration.Observe(time.Since(start).Seconds(), ctx.Request.Method, route, status)
+}
+
+// Expose writes the metrics in the Prometheus text format
+func (h *Handler) Expose(ctx *gin.Context) {
+	ctx.Status(http.StatusOK)
+	ctx.Header("Content-Type", contentType)
+	if err := h.registry.Write(ctx.Writer); err != nil {
+		logger.Error(logger.Format{
+			Message: "Failed to write metrics",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are the histogram buckets for latencies, in seconds
+var DurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is a metric family written in the Prometheus text format
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metric families exposed on /metrics
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Default is the registry the New* constructors register with, so that
+// packages can declare their metrics alongside the code they measure
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[c.name()]; ok {
+		panic(fmt.Sprintf("metric %s is registered more than once", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Write writes every metric family in the Prometheus text exposition
+// format, ordered by name
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.Unlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// family is the state shared by the metric vectors: series keyed by their
+// label values
+type family struct {
+	metricName string
+	help       string
+	metricType string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// buckets and count are only set for histograms
+	buckets []uint64
+	count   uint64
+}
+
+func newFamily(name, help, metricType string, labelNames []string) *family {
+	return &family{
+		metricName: name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		series:     map[string]*series{},
+	}
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+// with returns the series of labelValues, creating it; callers hold f.mu
+func (f *family) with(labelValues []string, buckets int) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s takes %d label values, got %d", f.metricName, len(f.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...)}
+		if buckets > 0 {
+			s.buckets = make([]uint64, buckets)
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values, so that the output is
+// stable between scrapes; callers hold f.mu
+func (f *family) sorted() []*series {
+	sorted := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.Join(sorted[i].labelValues, "\xff") < strings.Join(sorted[j].labelValues, "\xff")
+	})
+	return sorted
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, f.metricType)
+}
+
+func (f *family) writeSample(w *bufio.Writer, name string, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	pairs := make([]string, 0, len(labelValues)+1)
+	for i, labelValue := range labelValues {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, f.labelNames[i], escapeLabel(labelValue)))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// CounterVec counts events, partitioned by labels
+type CounterVec struct {
+	*family
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{newFamily(name, help, "counter", labelNames)}
+	Default.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", c.metricName))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.with(labelValues, 0).value += value
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, s := range c.sorted() {
+		c.writeSample(w, c.metricName, s.labelValues, "", "", s.value)
+	}
+}
+
+// GaugeVec holds values that go up and down, partitioned by labels
+type GaugeVec struct {
+	*family
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{newFamily(name, help, "gauge", labelNames)}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(labelValues, 0).value = value
+}
+
+func (g *GaugeVec) Add(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(labelValues, 0).value += value
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(w)
+	for _, s := range g.sorted() {
+		g.writeSample(w, g.metricName, s.labelValues, "", "", s.value)
+	}
+}
+
+// HistogramVec counts observations into buckets by their upper bound,
+// partitioned by labels
+type HistogramVec struct {
+	*family
+	upperBounds []float64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	upperBounds := append([]float64{}, buckets...)
+	sort.Float64s(upperBounds)
+	h := &HistogramVec{family: newFamily(name, help, "histogram", labelNames), upperBounds: upperBounds}
+	Default.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.with(labelValues, len(h.upperBounds))
+	for i, upperBound := range h.upperBounds {
+		if value <= upperBound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, s := range h.sorted() {
+		for i, upperBound := range h.upperBounds {
+			h.writeSample(w, h.metricName+"_bucket", s.labelValues, "le", formatFloat(upperBound), float64(s.buckets[i]))
+		}
+		h.writeSample(w, h.metricName+"_bucket", s.labelValues, "le", "+Inf", float64(s.count))
+		h.writeSample(w, h.metricName+"_sum", s.labelValues, "", "", s.value)
+		h.writeSample(w, h.metricName+"_count", s.labelValues, "", "", float64(s.count))
+	}
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+	registry *Registry
+}
+
+func (mt *MetricsTestSuite) SetupTest() {
+	mt.registry = NewRegistry()
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
+
+func (mt *MetricsTestSuite) write() string {
+	var out bytes.Buffer
+	mt.Require().NoError(mt.registry.Write(&out))
+	return out.String()
+}
+
+func (mt *MetricsTestSuite) TestShouldWriteCountersBySortedLabels() {
+	counter := &CounterVec{newFamily("test_requests_total", "Requests served.", "counter", []string{"route"})}
+	mt.registry.register(counter)
+
+	counter.Inc("/products")
+	counter.Add(2, "/products")
+	counter.Inc("/health")
+
+	assert.Equal(mt.T(), strings.Join([]string{
+		"# HELP test_requests_total Requests served.",
+		"# TYPE test_requests_total counter",
+		`test_requests_total{route="/health"} 1`,
+		`test_requests_total{route="/products"} 3`,
+		"",
+	}, "\n"), mt.write())
+}
+
+func (mt *MetricsTestSuite) TestShouldWriteGaugesWithoutLabels() {
+	gauge := &GaugeVec{newFamily("test_connections", "Open connections.", "gauge", nil)}
+	mt.registry.register(gauge)
+
+	gauge.Set(5)
+	gauge.Add(-2)
+
+	assert.Contains(mt.T(), mt.write(), "\ntest_connections 3\n")
+}
+
+func (mt *MetricsTestSuite) TestShouldWriteCumulativeHistogramBuckets() {
+	histogram := &HistogramVec{family: newFamily("test_duration_seconds", "Durations.", "histogram", []string{"op"}), upperBounds: []float64{0.1, 1}}
+	mt.registry.register(histogram)
+
+	histogram.Observe(0.05, "find")
+	histogram.Observe(0.5, "find")
+	histogram.Observe(2, "find")
+
+	out := mt.write()
+	assert.Contains(mt.T(), out, `test_duration_seconds_bucket{op="find",le="0.1"} 1`)
+	assert.Contains(mt.T(), out, `test_duration_seconds_bucket{op="find",le="1"} 2`)
+	assert.Contains(mt.T(), out, `test_duration_seconds_bucket{op="find",le="+Inf"} 3`)
+	assert.Contains(mt.T(), out, `test_duration_seconds_sum{op="find"} 2.55`)
+	assert.Contains(mt.T(), out, `test_duration_seconds_count{op="find"} 3`)
+}
+
+func (mt *MetricsTestSuite) TestShouldEscapeLabelValuesAndHelp() {
+	counter := &CounterVec{newFamily("test_errors_total", "Errors\nby path.", "counter", []string{"path"})}
+	mt.registry.register(counter)
+
+	counter.Inc("a\"b\\c\nd")
+
+	out := mt.write()
+	assert.Contains(mt.T(), out, `# HELP test_errors_total Errors\nby path.`)
+	assert.Contains(mt.T(), out, `test_errors_total{path="a\"b\\c\nd"} 1`)
+}
+
+func (mt *MetricsTestSuite) TestShouldRefuseDuplicateNamesAndWrongLabelCounts() {
+	mt.registry.register(&GaugeVec{newFamily("test_gauge", "A gauge.", "gauge", []string{"tenant"})})
+
+	assert.Panics(mt.T(), func() {
+		mt.registry.register(&GaugeVec{newFamily("test_gauge", "A gauge.", "gauge", nil)})
+	})
+	assert.Panics(mt.T(), func() {
+		(&GaugeVec{newFamily("test_other", "A gauge.", "gauge", []string{"tenant"})}).Set(1)
+	})
+	assert.Panics(mt.T(), func() {
+		(&CounterVec{newFamily("test_counter", "A counter.", "counter", nil)}).Add(-1)
+	})
+}
+
+func (mt *MetricsTestSuite) TestShouldLabelRequestsByRouteTemplate() {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler()
+	router := gin.New()
+	router.Use(handler.Middleware)
+	router.GET("/products/:productID", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	router.GET("/metrics", handler.Expose)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/abc", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(mt.T(), http.StatusOK, recorder.Code)
+	assert.Equal(mt.T(), contentType, recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	assert.Contains(mt.T(), body, `http_requests_total{method="GET",route="/products/:productID",status="204"} 1`)
+	assert.Contains(mt.T(), body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.NotContains(mt.T(), body, "/products/abc")
+}
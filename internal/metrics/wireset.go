@@ -0,0 +1,7 @@
+package metrics
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+)
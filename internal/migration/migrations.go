@@ -0,0 +1,331 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations lists every migration of the catalog. Append new ones with the
+// next version; never renumber or edit one that has shipped.
+func Migrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "Create product indexes",
+			Up:          createIndexes("rapidProducts", productIndexes),
+			Down:        dropIndexes("rapidProducts", productIndexes),
+		},
+		{
+			Version:     2,
+			Description: "Backfill legacy prices as amounts with a currency",
+			Up: func(ctx context.Context, db *utils.DBInstance) error {
+				_, err := product.MigrateLegacyPrices(ctx, db, money.DefaultCurrency())
+				return err
+			},
+		},
+		{
+			Version:     3,
+			Description: "Create product event and webhook delivery indexes",
+			Up: all(
+				createIndexes("rapidProductEvents", eventIndexes),
+				createIndexes("rapidWebhookDeliveries", deliveryIndexes),
+			),
+			Down: all(
+				dropIndexes("rapidProductEvents", eventIndexes),
+				dropIndexes("rapidWebhookDeliveries", deliveryIndexes),
+			),
+		},
+		{
+			Version:     4,
+			Description: "Create price schedule indexes",
+			Up:          createIndexes("rapidProductPrices", priceIndexes),
+			Down:        dropIndexes("rapidProductPrices", priceIndexes),
+		},
+		{
+			Version:     5,
+			Description: "Assign existing records to the default tenant and key products by tenant",
+			Up: all(
+				backfillTenant(tenantCollections...),
+				dropIndexes("rapidProducts", productKeyIndexes),
+				createIndexes("rapidProducts", tenantProductIndexes),
+				createIndexes("rapidPriceLists", tenantPriceListIndexes),
+				createIndexes("rapidWebhookDeliveries", tenantDeliveryIndexes),
+			),
+			// Restoring the global product key fails if tenants have
+			// created products with the same name and category
+			Down: all(
+				dropIndexes("rapidWebhookDeliveries", tenantDeliveryIndexes),
+				dropIndexes("rapidPriceLists", tenantPriceListIndexes),
+				dropIndexes("rapidProducts", tenantProductIndexes),
+				createIndexes("rapidProducts", productKeyIndexes),
+			),
+		},
+		{
+			Version:     6,
+			Description: "Create API key indexes",
+			Up:          createIndexes("rapidApiKeys", apiKeyIndexes),
+			Down:        dropIndexes("rapidApiKeys", apiKeyIndexes),
+		},
+		{
+			Version:     7,
+			Description: "Create API key revocation index",
+			Up:          createIndexes("rapidApiKeys", apiKeyRevocationIndexes),
+			Down:        dropIndexes("rapidApiKeys", apiKeyRevocationIndexes),
+		},
+		{
+			Version:     8,
+			Description: "Create webhook delivery ordering index",
+			Up:          createIndexes("rapidWebhookDeliveries", deliveryOrderIndexes),
+			Down:        dropIndexes("rapidWebhookDeliveries", deliveryOrderIndexes),
+		},
+		{
+			Version:     9,
+			Description: "Create audit log indexes",
+			Up:          all(createIndexes("rapidAuditLog", auditIndexes), syncAuditRetention),
+			Down:        dropIndexes("rapidAuditLog", append(auditIndexes, auditRetentionIndex)),
+			Sync:        syncAuditRetention,
+		},
+	}
+}
+
+// The upsert key includes the deletion marker, so a deleted product awaiting
+// removal does not block re-creating one with the same name and category
+var productIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "name", Value: 1}, {Key: "category", Value: 1}, {Key: events.DeletedField, Value: 1}},
+		Options: options.Index().SetName("name_category_deleted_unique").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: "category", Value: 1}, {Key: "popularity", Value: -1}},
+		Options: options.Index().SetName("category_popularity"),
+	},
+	{
+		Keys:    bson.D{{Key: "brand", Value: 1}, {Key: "popularity", Value: -1}},
+		Options: options.Index().SetName("brand_popularity"),
+	},
+	{
+		Keys:    bson.D{{Key: "popularity", Value: -1}},
+		Options: options.Index().SetName("popularity"),
+	},
+	{
+		Keys:    bson.D{{Key: "price.amount", Value: 1}, {Key: "popularity", Value: -1}},
+		Options: options.Index().SetName("price_popularity"),
+	},
+	{
+		Keys:    bson.D{{Key: events.PendingField, Value: 1}},
+		Options: options.Index().SetName("outbox_pending").SetSparse(true),
+	},
+}
+
+// productKeyIndexes is the upsert key of products before tenancy
+var productKeyIndexes = productIndexes[:1]
+
+// tenantCollections hold records owned by a tenant
+var tenantCollections = []string{
+	"rapidProducts",
+	"rapidProductEvents",
+	"rapidProductPrices",
+	"rapidPromotions",
+	"rapidPriceLists",
+	"rapidWebhookSubscriptions",
+	"rapidWebhookDeliveries",
+	"rapidAuditLog",
+}
+
+var tenantProductIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "name", Value: 1}, {Key: "category", Value: 1}, {Key: events.DeletedField, Value: 1}},
+		Options: options.Index().SetName("tenant_name_category_deleted_unique").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "popularity", Value: -1}},
+		Options: options.Index().SetName("tenant_popularity"),
+	},
+}
+
+var tenantPriceListIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "code", Value: 1}},
+		Options: options.Index().SetName("tenant_code_unique").SetUnique(true),
+	},
+}
+
+var tenantDeliveryIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+		Options: options.Index().SetName("tenant_status_next_attempt"),
+	},
+}
+
+var eventIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "seq", Value: 1}},
+		Options: options.Index().SetName("seq_unique").SetUnique(true),
+	},
+}
+
+var deliveryIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "subscriptionId", Value: 1}, {Key: "eventSeq", Value: 1}},
+		Options: options.Index().SetName("subscription_event_unique").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+		Options: options.Index().SetName("status_next_attempt"),
+	},
+}
+
+var priceIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "productId", Value: 1}, {Key: "effectiveFrom", Value: -1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("product_effective_from"),
+	},
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "effectiveFrom", Value: 1}},
+		Options: options.Index().SetName("status_effective_from"),
+	},
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "effectiveTo", Value: 1}},
+		Options: options.Index().SetName("status_effective_to"),
+	},
+}
+
+// API keys are looked up by hash on every request that sends one
+var apiKeyIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetName("hash_unique").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "createdAt", Value: 1}},
+		Options: options.Index().SetName("tenant_created"),
+	},
+}
+
+// Instances poll for the latest revocation to drop the keys they cache
+var apiKeyRevocationIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "revokedAt", Value: -1}},
+		Options: options.Index().SetName("revoked_at").SetSparse(true),
+	},
+}
+
+// A delivery waits behind the pending deliveries of earlier events of its
+// product to the same subscription
+var deliveryOrderIndexes = []mongo.IndexModel{
+	{
+		Keys: bson.D{
+			{Key: "subscriptionId", Value: 1}, {Key: "productId", Value: 1},
+			{Key: "status", Value: 1}, {Key: "eventSeq", Value: 1},
+		},
+		Options: options.Index().SetName("subscription_product_status_seq"),
+	},
+}
+
+// The audit log was indexed on first write before these were migrations, so
+// they keep the default names it created them with
+var auditIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "at", Value: -1}},
+		Options: options.Index().SetName("tenantId_1_at_-1"),
+	},
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "actor", Value: 1}, {Key: "at", Value: -1}},
+		Options: options.Index().SetName("tenantId_1_actor_1_at_-1"),
+	},
+	{
+		Keys:    bson.D{{Key: tenant.Field, Value: 1}, {Key: "productIds", Value: 1}, {Key: "at", Value: -1}},
+		Options: options.Index().SetName("tenantId_1_productIds_1_at_-1"),
+	},
+}
+
+// Entries expire once they are older than the audit retention
+var auditRetentionIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "at", Value: 1}},
+	Options: options.Index().SetName("at_1"),
+}
+
+// syncAuditRetention creates the audit log's TTL index, or updates its expiry
+// with collMod when audit.retentionDays has changed since
+func syncAuditRetention(ctx context.Context, db *utils.DBInstance) error {
+	seconds := int32(audit.Retention(config.GetConfig()).Seconds())
+	collection := db.Collection("rapidAuditLog")
+
+	model := mongo.IndexModel{
+		Keys:    auditRetentionIndex.Keys,
+		Options: options.Index().SetName(*auditRetentionIndex.Options.Name).SetExpireAfterSeconds(seconds),
+	}
+	// Creating the index again is a no-op unless it exists with another expiry
+	_, err := collection.Indexes().CreateOne(ctx, model)
+	if commandErr, ok := err.(mongo.CommandError); !ok || commandErr.Name != "IndexOptionsConflict" {
+		return err
+	}
+	return collection.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: *auditRetentionIndex.Options.Name},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}).Err()
+}
+
+type step func(ctx context.Context, db *utils.DBInstance) error
+
+func createIndexes(collection string, models []mongo.IndexModel) step {
+	return func(ctx context.Context, db *utils.DBInstance) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, models)
+		return err
+	}
+}
+
+// backfillTenant assigns the records of collections that predate tenancy to
+// the default tenant
+func backfillTenant(collections ...string) step {
+	return func(ctx context.Context, db *utils.DBInstance) error {
+		filter := bson.M{tenant.Field: bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{tenant.Field: tenant.Default()}}
+		for _, collection := range collections {
+			if _, err := db.Collection(collection).UpdateMany(ctx, filter, update); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// dropIndexes drops the named indexes of models, ignoring ones already gone
+func dropIndexes(collection string, models []mongo.IndexModel) step {
+	return func(ctx context.Context, db *utils.DBInstance) error {
+		for _, model := range models {
+			_, err := db.Collection(collection).Indexes().DropOne(ctx, *model.Options.Name)
+			if commandErr, ok := err.(mongo.CommandError); ok && commandErr.Name == "IndexNotFound" {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func all(steps ...step) step {
+	return func(ctx context.Context, db *utils.DBInstance) error {
+		for _, step := range steps {
+			if err := step(ctx, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
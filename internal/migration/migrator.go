@@ -0,0 +1,171 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lockTTL bounds how long a crashed run can hold the migration lock
+const lockTTL = 10 * time.Minute
+
+// Migrator applies and reverts migrations against the catalog database,
+// recording each applied version
+type Migrator struct {
+	db         *utils.DBInstance
+	repository Repository
+	migrations []Migration
+	owner      string
+}
+
+func NewMigrator(db *utils.DBInstance, repository Repository) *Migrator {
+	return newMigrator(db, repository, Migrations())
+}
+
+func newMigrator(db *utils.DBInstance, repository Repository, migrations []Migration) *Migrator {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		repository: repository,
+		migrations: sorted,
+		owner:      hostname + "-" + primitive.NewObjectID().Hex(),
+	}
+}
+
+// Status lists every known migration with whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations up to and including target, or all of
+// them when target is 0, then syncs every applied migration, and returns the
+// versions it applied
+func (m *Migrator) Up(ctx context.Context, target int) ([]int, error) {
+	return m.locked(ctx, func(applied map[int]Record) ([]int, error) {
+		versions := []int{}
+		for _, migration := range m.migrations {
+			if target > 0 && migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			m.log("Applying migration", migration)
+			if err := migration.Up(ctx, m.db); err != nil {
+				return versions, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+			}
+			record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}
+			if err := m.repository.MarkApplied(ctx, record); err != nil {
+				return versions, fmt.Errorf("migration %d applied but not recorded: %w", migration.Version, err)
+			}
+			applied[migration.Version] = record
+			versions = append(versions, migration.Version)
+		}
+		return versions, m.sync(ctx, applied)
+	})
+}
+
+func (m *Migrator) sync(ctx context.Context, applied map[int]Record) error {
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok || migration.Sync == nil {
+			continue
+		}
+		if err := migration.Sync(ctx, m.db); err != nil {
+			return fmt.Errorf("syncing migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+	}
+	return nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the versions it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	return m.locked(ctx, func(applied map[int]Record) ([]int, error) {
+		versions := []int{}
+		for i := len(m.migrations) - 1; i >= 0 && len(versions) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == nil {
+				return versions, fmt.Errorf("migration %d (%s) cannot be reverted", migration.Version, migration.Description)
+			}
+
+			m.log("Reverting migration", migration)
+			if err := migration.Down(ctx, m.db); err != nil {
+				return versions, fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+			}
+			if err := m.repository.MarkReverted(ctx, migration.Version); err != nil {
+				return versions, fmt.Errorf("migration %d reverted but still recorded: %w", migration.Version, err)
+			}
+			versions = append(versions, migration.Version)
+		}
+		return versions, nil
+	})
+}
+
+func (m *Migrator) locked(ctx context.Context, run func(applied map[int]Record) ([]int, error)) ([]int, error) {
+	if err := m.repository.Lock(ctx, m.owner, lockTTL); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := m.repository.Unlock(ctx, m.owner); err != nil {
+			logger.Error(logger.Format{Message: "Failed to release migration lock", Data: map[string]string{"error": err.Error()}})
+		}
+	}()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return run(applied)
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	records, err := m.repository.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *Migrator) log(message string, migration Migration) {
+	logger.Info(logger.Format{
+		Message: message,
+		Data: map[string]string{
+			"version":     strconv.Itoa(migration.Version),
+			"description": migration.Description,
+		},
+	})
+}
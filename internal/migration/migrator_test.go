@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigratorTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	calls      []string
+	migrations []Migration
+}
+
+func (ms *MigratorTestSuite) SetupTest() {
+	logger.Init("debug")
+	ms.repository = new(MockRepository)
+	ms.repository.On("Lock", mock.Anything, mock.Anything, lockTTL).Return(nil)
+	ms.repository.On("Unlock", mock.Anything, mock.Anything).Return(nil)
+	ms.repository.On("MarkApplied", mock.Anything, mock.Anything).Return(nil)
+	ms.repository.On("MarkReverted", mock.Anything, mock.Anything).Return(nil)
+
+	ms.calls = nil
+	ms.migrations = []Migration{
+		ms.migration(3, true),
+		ms.migration(1, true),
+		ms.migration(2, false),
+	}
+}
+
+func TestMigratorSuite(t *testing.T) {
+	suite.Run(t, new(MigratorTestSuite))
+}
+
+func (ms *MigratorTestSuite) migration(version int, reversible bool) Migration {
+	name := string(rune('0' + version))
+	migration := Migration{
+		Version:     version,
+		Description: "migration " + name,
+		Up: func(ctx context.Context, db *utils.DBInstance) error {
+			ms.calls = append(ms.calls, "up "+name)
+			return nil
+		},
+	}
+	if reversible {
+		migration.Down = func(ctx context.Context, db *utils.DBInstance) error {
+			ms.calls = append(ms.calls, "down "+name)
+			return nil
+		}
+	}
+	return migration
+}
+
+func (ms *MigratorTestSuite) applied(versions ...int) {
+	records := []Record{}
+	for _, version := range versions {
+		records = append(records, Record{Version: version, AppliedAt: time.Now()})
+	}
+	ms.repository.On("Applied", mock.Anything).Return(records, nil)
+}
+
+func (ms *MigratorTestSuite) TestShouldApplyPendingMigrationsInOrder() {
+	ms.applied(1)
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	applied, err := migrator.Up(context.Background(), 0)
+
+	assert.NoError(ms.T(), err)
+	assert.Equal(ms.T(), []int{2, 3}, applied)
+	assert.Equal(ms.T(), []string{"up 2", "up 3"}, ms.calls)
+	ms.repository.AssertNumberOfCalls(ms.T(), "MarkApplied", 2)
+	ms.repository.AssertCalled(ms.T(), "Unlock", mock.Anything, migrator.owner)
+}
+
+func (ms *MigratorTestSuite) TestShouldStopAtTargetVersion() {
+	ms.applied()
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	applied, err := migrator.Up(context.Background(), 2)
+
+	assert.NoError(ms.T(), err)
+	assert.Equal(ms.T(), []int{1, 2}, applied)
+}
+
+func (ms *MigratorTestSuite) TestShouldStopAtFailedMigration() {
+	ms.applied()
+	ms.migrations[1].Up = func(ctx context.Context, db *utils.DBInstance) error { return errors.New("duplicate key") }
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	applied, err := migrator.Up(context.Background(), 0)
+
+	assert.Error(ms.T(), err)
+	assert.Empty(ms.T(), applied)
+	assert.Empty(ms.T(), ms.calls)
+	ms.repository.AssertNotCalled(ms.T(), "MarkApplied", mock.Anything, mock.Anything)
+}
+
+func (ms *MigratorTestSuite) TestShouldSyncAppliedMigrations() {
+	ms.applied(1)
+	for i := range ms.migrations {
+		name := string(rune('0' + ms.migrations[i].Version))
+		ms.migrations[i].Sync = func(ctx context.Context, db *utils.DBInstance) error {
+			ms.calls = append(ms.calls, "sync "+name)
+			return nil
+		}
+	}
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	applied, err := migrator.Up(context.Background(), 2)
+
+	assert.NoError(ms.T(), err)
+	assert.Equal(ms.T(), []int{2}, applied)
+	assert.Equal(ms.T(), []string{"up 2", "sync 1", "sync 2"}, ms.calls)
+}
+
+func (ms *MigratorTestSuite) TestShouldRevertNewestFirstAndRefuseIrreversible() {
+	ms.applied(1, 2, 3)
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	reverted, err := migrator.Down(context.Background(), 2)
+
+	assert.Error(ms.T(), err)
+	assert.Equal(ms.T(), []int{3}, reverted)
+	assert.Equal(ms.T(), []string{"down 3"}, ms.calls)
+	ms.repository.AssertCalled(ms.T(), "MarkReverted", mock.Anything, 3)
+}
+
+func (ms *MigratorTestSuite) TestShouldNotRunWhileLocked() {
+	repository := new(MockRepository)
+	repository.On("Lock", mock.Anything, mock.Anything, lockTTL).Return(ErrLocked)
+	migrator := newMigrator(nil, repository, ms.migrations)
+
+	_, err := migrator.Up(context.Background(), 0)
+
+	assert.Equal(ms.T(), ErrLocked, err)
+	assert.Empty(ms.T(), ms.calls)
+}
+
+func (ms *MigratorTestSuite) TestShouldReportStatusOfEveryMigration() {
+	ms.applied(1)
+	migrator := newMigrator(nil, ms.repository, ms.migrations)
+
+	statuses, err := migrator.Status(context.Background())
+
+	assert.NoError(ms.T(), err)
+	assert.Len(ms.T(), statuses, 3)
+	assert.True(ms.T(), statuses[0].Applied)
+	assert.False(ms.T(), statuses[1].Applied)
+	assert.Equal(ms.T(), 3, statuses[2].Version)
+}
+
+func (ms *MigratorTestSuite) TestShouldDeclareUniqueVersions() {
+	seen := map[int]bool{}
+	for _, migration := range Migrations() {
+		assert.False(ms.T(), seen[migration.Version], "version %d is declared twice", migration.Version)
+		seen[migration.Version] = true
+	}
+}
@@ -0,0 +1,97 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const lockID = "lock"
+
+// ErrLocked is returned when another process holds the migration lock
+var ErrLocked = errors.New("migrations are locked by another process")
+
+type Repository interface {
+	Applied(ctx context.Context) ([]Record, error)
+	MarkApplied(ctx context.Context, record Record) error
+	MarkReverted(ctx context.Context, version int) error
+	// Lock takes the migration lock for owner until ttl passes, so instances
+	// starting together do not run the same migration twice
+	Lock(ctx context.Context, owner string, ttl time.Duration) error
+	Unlock(ctx context.Context, owner string) error
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	migrations *mongo.Collection
+	locks      *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		migrations: db.Collection("rapidMigrations"),
+		locks:      db.Collection("rapidMigrationLocks"),
+	}
+}
+
+func (r *repositoryImpl) Applied(ctx context.Context) ([]Record, error) {
+	records := []Record{}
+	err := r.db.Read(ctx, "migration.Applied", func(ctx context.Context) error {
+		cursor, err := r.migrations.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *repositoryImpl) MarkApplied(ctx context.Context, record Record) error {
+	return r.db.Write(ctx, "migration.MarkApplied", func(ctx context.Context) error {
+		_, err := r.migrations.ReplaceOne(ctx, bson.M{"_id": record.Version}, record, options.Replace().SetUpsert(true))
+		return err
+	})
+}
+
+func (r *repositoryImpl) MarkReverted(ctx context.Context, version int) error {
+	return r.db.Write(ctx, "migration.MarkReverted", func(ctx context.Context) error {
+		_, err := r.migrations.DeleteOne(ctx, bson.M{"_id": version})
+		return err
+	})
+}
+
+func (r *repositoryImpl) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	now := time.Now().UTC()
+	filter := bson.M{"_id": lockID, "$or": []bson.M{{"owner": owner}, {"expiresAt": bson.M{"$lt": now}}}}
+	update := bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}}
+
+	err := r.db.Write(ctx, "migration.Lock", func(ctx context.Context) error {
+		_, err := r.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+func (r *repositoryImpl) Unlock(ctx context.Context, owner string) error {
+	return r.db.Write(ctx, "migration.Unlock", func(ctx context.Context) error {
+		_, err := r.locks.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+		return err
+	})
+}
@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (r *MockRepository) Applied(ctx context.Context) ([]Record, error) {
+	ret := r.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Record), ret.Error(1)
+}
+
+func (r *MockRepository) MarkApplied(ctx context.Context, record Record) error {
+	ret := r.Mock.Called(ctx, record)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) MarkReverted(ctx context.Context, version int) error {
+	ret := r.Mock.Called(ctx, version)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) Lock(ctx context.Context, owner string, ttl time.Duration) error {
+	ret := r.Mock.Called(ctx, owner, ttl)
+	return ret.Error(0)
+}
+
+func (r *MockRepository) Unlock(ctx context.Context, owner string) error {
+	ret := r.Mock.Called(ctx, owner)
+	return ret.Error(0)
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+)
+
+// Migration is one versioned change to the schema or data. Versions are
+// applied in ascending order and reverted in descending order.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *utils.DBInstance) error
+	// Down reverts Up; nil for migrations that cannot be reverted
+	Down func(ctx context.Context, db *utils.DBInstance) error
+	// Sync, when set, brings what Up created in line with the configuration.
+	// It runs on every Up once the migration is applied, so configuration
+	// changes reach the datastore without a new migration.
+	Sync func(ctx context.Context, db *utils.DBInstance) error
+}
+
+// Record is the stored fact that a migration was applied
+type Record struct {
+	Version     int       `json:"version" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	AppliedAt   time.Time `json:"appliedAt" bson:"appliedAt"`
+}
+
+// Status describes a known migration and whether it is applied
+type Status struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
@@ -0,0 +1,221 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Money is an amount in the minor units of an ISO 4217 currency, so 12999.50
+// INR is Amount 1299950 and Currency "INR". Arithmetic never goes through
+// floating point.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// exponents lists the currencies whose minor unit is not 1/100
+var exponents = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+var (
+	defaultCurrencyMu sync.RWMutex
+	defaultCurrency   = "INR"
+)
+
+// SetDefaultCurrency sets the currency assumed for bare numeric prices, both
+// in JSON requests and in documents written before prices carried a currency
+func SetDefaultCurrency(currency string) {
+	if currency == "" {
+		return
+	}
+	defaultCurrencyMu.Lock()
+	defer defaultCurrencyMu.Unlock()
+	defaultCurrency = strings.ToUpper(currency)
+}
+
+func DefaultCurrency() string {
+	defaultCurrencyMu.RLock()
+	defer defaultCurrencyMu.RUnlock()
+	return defaultCurrency
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit
+func Exponent(currency string) int {
+	if exponent, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// IsValidCurrency reports whether code looks like an ISO 4217 alphabetic code
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMajor parses a decimal string in major units, e.g. "12999.50", into
+// Money. Digits beyond the currency's minor unit are rounded half away from
+// zero.
+func ParseMajor(value string, currency string) (Money, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	currency = strings.ToUpper(currency)
+	amount, err := toMinor(rat, Exponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// FromMajor converts a float in major units to Money. It exists for inputs
+// that are floats by nature, such as request filters and legacy documents.
+func FromMajor(value float64, currency string) Money {
+	m, _ := ParseMajor(strconv.FormatFloat(value, 'f', -1, 64), currency)
+	return m
+}
+
+// Major returns the amount in major units. Use it for display and logging
+// only; it is not exact.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(Exponent(m.Currency))
+}
+
+// Decimal formats the amount in major units with the currency's precision
+func (m Money) Decimal() string {
+	exponent := Exponent(m.Currency)
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(exponent)).FloatString(exponent)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Sub subtracts o, which must be in the same currency
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}
+}
+
+// Percent returns pct percent of m, rounded to the minor unit
+func (m Money) Percent(pct float64) Money {
+	rate, _ := new(big.Rat).SetString(strconv.FormatFloat(pct, 'f', -1, 64))
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	value.Quo(value, big.NewRat(100, 1))
+	amount, _ := toMinor(value, 0)
+	return Money{Amount: amount, Currency: m.Currency}
+}
+
+// UnmarshalJSON accepts either a Money object or, for clients written before
+// prices carried a currency, a bare number in major units of the default
+// currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		if bytes.Equal(data, []byte("null")) {
+			return nil
+		}
+		parsed, err := ParseMajor(string(data), DefaultCurrency())
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	type plain Money
+	var value plain
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*m = Money(value)
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency()
+	}
+	m.Currency = strings.ToUpper(m.Currency)
+	return nil
+}
+
+// UnmarshalBSONValue reads both Money documents and the plain doubles stored
+// before prices carried a currency
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bsoncore.Value{Type: t, Data: data}
+	switch t {
+	case bsontype.Double:
+		*m = FromMajor(value.Double(), DefaultCurrency())
+		return nil
+	case bsontype.Int32:
+		*m = FromMajor(float64(value.Int32()), DefaultCurrency())
+		return nil
+	case bsontype.Int64:
+		*m = FromMajor(float64(value.Int64()), DefaultCurrency())
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+		return nil
+	case bsontype.EmbeddedDocument:
+		type plain Money
+		var doc plain
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		*m = Money(doc)
+		return nil
+	}
+	return fmt.Errorf("cannot decode %s into money", t)
+}
+
+func toMinor(value *big.Rat, exponent int) (int64, error) {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(exponent)))
+	rounded := roundHalfAwayFromZero(scaled)
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("amount %s out of range", value.FloatString(exponent))
+	}
+	return rounded.Int64(), nil
+}
+
+func roundHalfAwayFromZero(value *big.Rat) *big.Int {
+	num := new(big.Int).Abs(value.Num())
+	denom := value.Denom()
+
+	quotient, remainder := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if new(big.Int).Mul(remainder, big.NewInt(2)).Cmp(denom) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
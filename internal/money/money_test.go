@@ -0,0 +1,112 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type MoneyTestSuite struct {
+	suite.Suite
+}
+
+func TestMoneySuite(t *testing.T) {
+	suite.Run(t, new(MoneyTestSuite))
+}
+
+func (ms *MoneyTestSuite) TestShouldParseMajorUnitsWithoutFloatRounding() {
+	m, err := ParseMajor("0.29", "INR")
+
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(29, "INR"), m)
+	assert.Equal(ms.T(), New(1005, "USD"), FromMajor(10.045, "USD"))
+}
+
+func (ms *MoneyTestSuite) TestShouldRespectCurrencyExponent() {
+	jpy, _ := ParseMajor("1299.5", "JPY")
+	kwd, _ := ParseMajor("1.2345", "KWD")
+
+	assert.Equal(ms.T(), int64(1300), jpy.Amount)
+	assert.Equal(ms.T(), int64(1235), kwd.Amount)
+	assert.Equal(ms.T(), "1.235 KWD", kwd.String())
+}
+
+func (ms *MoneyTestSuite) TestShouldAcceptLegacyNumericJSON() {
+	var product struct {
+		Price Money `json:"price"`
+	}
+
+	err := json.Unmarshal([]byte(`{"price": 12999.99}`), &product)
+
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(1299999, DefaultCurrency()), product.Price)
+}
+
+func (ms *MoneyTestSuite) TestShouldAcceptMoneyObjectJSON() {
+	var product struct {
+		Price Money `json:"price"`
+	}
+
+	err := json.Unmarshal([]byte(`{"price": {"amount": 4999, "currency": "usd"}}`), &product)
+
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(4999, "USD"), product.Price)
+}
+
+func (ms *MoneyTestSuite) TestShouldDecodeLegacyDoubleFromBSON() {
+	raw, _ := bson.Marshal(bson.M{"price": 12999.5})
+	var product struct {
+		Price Money `bson:"price"`
+	}
+
+	err := bson.Unmarshal(raw, &product)
+
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(1299950, DefaultCurrency()), product.Price)
+}
+
+func (ms *MoneyTestSuite) TestShouldRoundTripMoneyDocumentThroughBSON() {
+	raw, _ := bson.Marshal(bson.M{"price": New(1299950, "INR")})
+	var product struct {
+		Price Money `bson:"price"`
+	}
+
+	err := bson.Unmarshal(raw, &product)
+
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(1299950, "INR"), product.Price)
+}
+
+func (ms *MoneyTestSuite) TestShouldTakePercentageRoundedToMinorUnit() {
+	assert.Equal(ms.T(), New(333, "INR"), New(1000, "INR").Percent(33.3))
+	assert.Equal(ms.T(), New(125, "INR"), New(1000, "INR").Percent(12.5))
+}
+
+func (ms *MoneyTestSuite) TestShouldConvertBetweenCurrencies() {
+	rates, err := NewRates("INR", map[string]string{"USD": "0.012", "JPY": "1.8"})
+	assert.Nil(ms.T(), err)
+
+	usd, err := rates.Convert(New(1299900, "INR"), "USD")
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(15599, "USD"), usd)
+
+	inr, err := rates.Convert(New(15599, "USD"), "INR")
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(1299917, "INR"), inr)
+
+	jpy, err := rates.Convert(New(1000, "USD"), "JPY")
+	assert.Nil(ms.T(), err)
+	assert.Equal(ms.T(), New(1500, "JPY"), jpy)
+}
+
+func (ms *MoneyTestSuite) TestShouldRejectUnknownCurrency() {
+	rates, _ := NewRates("INR", map[string]string{"USD": "0.012"})
+
+	_, err := rates.Convert(New(100, "INR"), "EUR")
+
+	assert.NotNil(ms.T(), err)
+	assert.False(ms.T(), rates.Supports("EUR"))
+}
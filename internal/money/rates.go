@@ -0,0 +1,82 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Rates converts between the catalog's base currency and the currencies it
+// sells in. Each rate is the number of units of a currency one unit of the
+// base currency buys, kept as an exact decimal.
+type Rates struct {
+	Base  string
+	rates map[string]*big.Rat
+}
+
+// NewRates parses decimal rate strings keyed by currency code
+func NewRates(base string, rates map[string]string) (Rates, error) {
+	base = strings.ToUpper(base)
+	if !IsValidCurrency(base) {
+		return Rates{}, fmt.Errorf("invalid base currency %q", base)
+	}
+
+	parsed := make(map[string]*big.Rat, len(rates))
+	for currency, value := range rates {
+		currency = strings.ToUpper(currency)
+		if !IsValidCurrency(currency) {
+			return Rates{}, fmt.Errorf("invalid currency %q", currency)
+		}
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok || rate.Sign() <= 0 {
+			return Rates{}, fmt.Errorf("invalid rate %q for %s", value, currency)
+		}
+		parsed[currency] = rate
+	}
+	parsed[base] = big.NewRat(1, 1)
+
+	return Rates{Base: base, rates: parsed}, nil
+}
+
+// Supports reports whether prices can be converted into currency
+func (r Rates) Supports(currency string) bool {
+	_, ok := r.rates[strings.ToUpper(currency)]
+	return ok
+}
+
+// Currencies returns the rate of every supported currency as a decimal string
+func (r Rates) Currencies() map[string]string {
+	currencies := make(map[string]string, len(r.rates))
+	for currency, rate := range r.rates {
+		currencies[currency] = rate.FloatString(8)
+	}
+	return currencies
+}
+
+// Convert expresses m in currency to, rounding to the target minor unit
+func (r Rates) Convert(m Money, to string) (Money, error) {
+	to = strings.ToUpper(to)
+	if m.Currency == to {
+		return m, nil
+	}
+
+	fromRate, ok := r.rates[m.Currency]
+	if !ok {
+		return Money{}, fmt.Errorf("no conversion rate for %s", m.Currency)
+	}
+	toRate, ok := r.rates[to]
+	if !ok {
+		return Money{}, fmt.Errorf("no conversion rate for %s", to)
+	}
+
+	// amount / 10^fromExp / fromRate * toRate * 10^toExp
+	value := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(Exponent(m.Currency)))
+	value.Quo(value, fromRate)
+	value.Mul(value, toRate)
+
+	amount, err := toMinor(value, Exponent(to))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: to}, nil
+}
@@ -0,0 +1,82 @@
+package price
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) GetPriceHistoryHandler(ctx *gin.Context) {
+	productIDParam := ctx.Param("productId")
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Request received for price history", Data: map[string]string{"productId": productIDParam}})
+
+	productID, err := primitive.ObjectIDFromHex(productIDParam)
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
+		return
+	}
+
+	entries, err := h.service.GetPriceHistory(ctx.Request.Context(), productID)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PriceHistoryResponse{
+		Success:   true,
+		ProductID: productID,
+		Count:     len(entries),
+		Prices:    entries,
+	})
+}
+
+func (h *Handler) SchedulePriceHandler(ctx *gin.Context) {
+	productIDParam := ctx.Param("productId")
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Request received for schedule price", Data: map[string]string{"productId": productIDParam}})
+
+	productID, err := primitive.ObjectIDFromHex(productIDParam)
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
+		return
+	}
+
+	var req SchedulePriceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	entry, err := h.service.SchedulePrice(ctx.Request.Context(), productID, req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, SchedulePriceResponse{
+		Success: true,
+		Message: fmt.Sprintf("Price scheduled from %s", entry.EffectiveFrom.Format(time.RFC3339)),
+		Price:   entry,
+	})
+}
@@ -0,0 +1,202 @@
+package price
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	InsertEntries(ctx context.Context, entries []Entry) error
+	GetEntriesByProduct(ctx context.Context, productID primitive.ObjectID) ([]Entry, error)
+	GetDueEntries(ctx context.Context, now time.Time) ([]Entry, error)
+	GetExpiredEntries(ctx context.Context, now time.Time) ([]Entry, error)
+	GetActiveEntries(ctx context.Context, productIDs []primitive.ObjectID) ([]Entry, error)
+	UpdateStatus(ctx context.Context, entryIDs []primitive.ObjectID, from []string, to string) error
+	GetBasePrice(ctx context.Context, productID primitive.ObjectID) (money.Money, error)
+	SetProductPrice(ctx context.Context, productID primitive.ObjectID, price money.Money) error
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+	// reads serves price history, which may lag behind writes; the
+	// scheduler's queries read from collection
+	reads    *mongo.Collection
+	products *mongo.Collection
+	events   events.Repository
+}
+
+func NewRepository(db *utils.DBInstance, eventsRepository events.Repository) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidProductPrices"),
+		reads:      db.ReadCollection("rapidProductPrices"),
+		products:   db.Collection("rapidProducts"),
+		events:     eventsRepository,
+	}
+}
+
+func (r *repositoryImpl) InsertEntries(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		entry.TenantID = tenant.ID(ctx)
+		documents = append(documents, entry)
+	}
+
+	err := r.db.Write(ctx, "price.InsertEntries", func(ctx context.Context) error {
+		_, err := r.collection.InsertMany(ctx, documents)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error inserting price entries",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetEntriesByProduct(ctx context.Context, productID primitive.ObjectID) ([]Entry, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "createdAt", Value: -1}})
+	return r.find(ctx, "price.GetEntriesByProduct", r.reads, bson.M{"productId": productID}, findOptions)
+}
+
+func (r *repositoryImpl) GetDueEntries(ctx context.Context, now time.Time) ([]Entry, error) {
+	filter := bson.M{
+		"source":        SourceSchedule,
+		"status":        StatusScheduled,
+		"effectiveFrom": bson.M{"$lte": now},
+	}
+	return r.find(ctx, "price.GetDueEntries", r.collection, filter, options.Find())
+}
+
+func (r *repositoryImpl) GetExpiredEntries(ctx context.Context, now time.Time) ([]Entry, error) {
+	filter := bson.M{
+		"source":      SourceSchedule,
+		"status":      bson.M{"$in": []string{StatusScheduled, StatusActive}},
+		"effectiveTo": bson.M{"$lte": now},
+	}
+	return r.find(ctx, "price.GetExpiredEntries", r.collection, filter, options.Find())
+}
+
+func (r *repositoryImpl) GetActiveEntries(ctx context.Context, productIDs []primitive.ObjectID) ([]Entry, error) {
+	filter := bson.M{
+		"source":    SourceSchedule,
+		"status":    StatusActive,
+		"productId": bson.M{"$in": productIDs},
+	}
+	return r.find(ctx, "price.GetActiveEntries", r.collection, filter, options.Find())
+}
+
+func (r *repositoryImpl) UpdateStatus(ctx context.Context, entryIDs []primitive.ObjectID, from []string, to string) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	filter := tenant.Filter(ctx, bson.M{
+		"_id":    bson.M{"$in": entryIDs},
+		"status": bson.M{"$in": from},
+	})
+	err := r.db.Write(ctx, "price.UpdateStatus", func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"status": to}})
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error updating price entry status",
+			Data: map[string]string{
+				"error":  err.Error(),
+				"status": to,
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetBasePrice(ctx context.Context, productID primitive.ObjectID) (money.Money, error) {
+	var product struct {
+		Price     money.Money `bson:"price"`
+		BasePrice money.Money `bson:"basePrice"`
+	}
+
+	findOneOptions := options.FindOne().SetProjection(bson.M{"price": 1, "basePrice": 1})
+	err := r.db.Read(ctx, "price.GetBasePrice", func(ctx context.Context) error {
+		return r.products.FindOne(ctx, tenant.Filter(ctx, events.Live(bson.M{"_id": productID})), findOneOptions).Decode(&product)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return money.Money{}, types.NewNotFoundError("Product not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching base price",
+			Data: map[string]string{
+				"error":     err.Error(),
+				"productID": productID.Hex(),
+			},
+		})
+		return money.Money{}, utils.DatastoreError(err)
+	}
+
+	// Products uploaded before price history existed only carry a price
+	if product.BasePrice.IsZero() {
+		return product.Price, nil
+	}
+	return product.BasePrice, nil
+}
+
+// SetProductPrice is a no-op when the product already has the price, so that
+// no change event is recorded for it
+func (r *repositoryImpl) SetProductPrice(ctx context.Context, productID primitive.ObjectID, price money.Money) error {
+	filter := bson.M{"_id": productID, "price": bson.M{"$ne": price}}
+	_, err := r.events.UpdateProduct(ctx, filter, bson.M{"$set": bson.M{"price": price}}, []string{"price"})
+	return err
+}
+
+func (r *repositoryImpl) find(ctx context.Context, operation string, collection *mongo.Collection, filter bson.M, findOptions *options.FindOptions) ([]Entry, error) {
+	entries := []Entry{}
+	err := r.db.Read(ctx, operation, func(ctx context.Context) error {
+		cursor, err := collection.Find(ctx, tenant.Filter(ctx, filter), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &entries)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching price entries",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,93 @@
+package price
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultSchedulerInterval = 60 * time.Second
+	// SchedulerActor is the audit log actor of the prices the scheduler
+	// applies and reverts
+	SchedulerActor = "system:price-scheduler"
+)
+
+// Scheduler periodically applies and reverts scheduled price changes in the
+// catalog of every tenant
+type Scheduler struct {
+	service  Service
+	audit    audit.Service
+	tenants  *tenant.Registry
+	interval time.Duration
+}
+
+func NewScheduler(cfg config.Config, s Service, auditService audit.Service, tenants *tenant.Registry) *Scheduler {
+	interval := time.Duration(cfg.Get().Pricing.SchedulerInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+
+	return &Scheduler{
+		service:  s,
+		audit:    auditService,
+		tenants:  tenants,
+		interval: interval,
+	}
+}
+
+// Run blocks until ctx is cancelled, applying due prices on every tick
+func (s *Scheduler) Run(ctx context.Context) {
+	logger.Info(logger.Format{Message: "Price scheduler started", Data: map[string]string{"interval": s.interval.String()}})
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(logger.Format{Message: "Price scheduler stopped"})
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	for _, t := range s.tenants.All() {
+		s.tickTenant(tenant.WithTenant(ctx, t))
+	}
+}
+
+func (s *Scheduler) tickTenant(ctx context.Context) {
+	ctx, scope := audit.WithScope(ctx, SchedulerActor, primitive.NewObjectID().Hex(), "", "price-scheduler")
+	result, err := s.service.ApplyDuePrices(ctx, time.Now().UTC())
+	if scope.HasChanges() {
+		if auditErr := s.audit.Record(ctx, scope.Entry(0)); auditErr != nil {
+			logger.Error(logger.Format{Message: "Failed to record scheduled price changes", Data: map[string]string{"error": auditErr.Error()}})
+		}
+	}
+	if err != nil {
+		logger.Error(logger.Format{Message: "Failed to apply scheduled prices", Data: map[string]string{"error": err.Error(), "tenantID": tenant.ID(ctx)}})
+		return
+	}
+
+	if result.Applied > 0 || result.Expired > 0 {
+		logger.Info(logger.Format{
+			Message: "Applied scheduled prices",
+			Data: map[string]string{
+				"tenantID": tenant.ID(ctx),
+				"applied":  strconv.Itoa(result.Applied),
+				"expired":  strconv.Itoa(result.Expired),
+			},
+		})
+	}
+}
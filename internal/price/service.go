@@ -0,0 +1,217 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Service interface {
+	RecordFeedPrices(ctx context.Context, changes []Change, uploaded []primitive.ObjectID) error
+	SchedulePrice(ctx context.Context, productID primitive.ObjectID, req SchedulePriceRequest) (Entry, error)
+	GetPriceHistory(ctx context.Context, productID primitive.ObjectID) ([]Entry, error)
+	ApplyDuePrices(ctx context.Context, now time.Time) (ApplyResult, error)
+}
+
+type serviceImpl struct {
+	repository Repository
+}
+
+func NewService(repo Repository) Service {
+	return &serviceImpl{
+		repository: repo,
+	}
+}
+
+// RecordFeedPrices appends a history entry for every base price changed by a
+// bulk upload. The upload writes the base price of every uploaded product,
+// changed or not, onto the product, so a scheduled price that is currently
+// running is written back onto each of them to keep precedence.
+func (s *serviceImpl) RecordFeedPrices(ctx context.Context, changes []Change, uploaded []primitive.ObjectID) error {
+	if len(changes) > 0 {
+		if err := s.recordChanges(ctx, changes); err != nil {
+			return err
+		}
+	}
+	if len(uploaded) == 0 {
+		return nil
+	}
+
+	active, err := s.repository.GetActiveEntries(ctx, uploaded)
+	if err != nil {
+		return err
+	}
+	for productID, entry := range latestByProduct(active) {
+		if err := s.repository.SetProductPrice(ctx, productID, entry.Price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *serviceImpl) recordChanges(ctx context.Context, changes []Change) error {
+	now := time.Now().UTC()
+	entries := make([]Entry, 0, len(changes))
+	for _, change := range changes {
+		entries = append(entries, Entry{
+			ProductID:     change.ProductID,
+			Price:         change.Price,
+			EffectiveFrom: now,
+			Source:        SourceFeed,
+			Status:        StatusApplied,
+			CreatedAt:     now,
+		})
+	}
+	return s.repository.InsertEntries(ctx, entries)
+}
+
+func (s *serviceImpl) SchedulePrice(ctx context.Context, productID primitive.ObjectID, req SchedulePriceRequest) (Entry, error) {
+	now := time.Now().UTC()
+	if req.Price.Amount <= 0 {
+		return Entry{}, types.NewValidationError("price must be greater than 0")
+	}
+	if req.EffectiveTo != nil {
+		if !req.EffectiveTo.After(req.EffectiveFrom) {
+			return Entry{}, types.NewValidationError("effectiveTo must be after effectiveFrom")
+		}
+		if !req.EffectiveTo.After(now) {
+			return Entry{}, types.NewValidationError("effectiveTo must be in the future")
+		}
+	}
+
+	basePrice, err := s.repository.GetBasePrice(ctx, productID)
+	if err != nil {
+		return Entry{}, err
+	}
+	if req.Price.Currency != basePrice.Currency {
+		return Entry{}, types.NewValidationError(fmt.Sprintf("price must be in %s, the currency of the product's base price", basePrice.Currency))
+	}
+
+	entry := Entry{
+		ID:            primitive.NewObjectID(),
+		ProductID:     productID,
+		Price:         req.Price,
+		EffectiveFrom: req.EffectiveFrom.UTC(),
+		Source:        SourceSchedule,
+		Status:        StatusScheduled,
+		CreatedAt:     now,
+	}
+	if req.EffectiveTo != nil {
+		effectiveTo := req.EffectiveTo.UTC()
+		entry.EffectiveTo = &effectiveTo
+	}
+
+	if err := s.repository.InsertEntries(ctx, []Entry{entry}); err != nil {
+		return Entry{}, err
+	}
+
+	logging.Info(ctx, logger.Format{
+		Message: "Scheduled price change",
+		Data: map[string]string{
+			"productID":     productID.Hex(),
+			"price":         entry.Price.String(),
+			"effectiveFrom": entry.EffectiveFrom.Format(time.RFC3339),
+		},
+	})
+	return entry, nil
+}
+
+func (s *serviceImpl) GetPriceHistory(ctx context.Context, productID primitive.ObjectID) ([]Entry, error) {
+	if _, err := s.repository.GetBasePrice(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return s.repository.GetEntriesByProduct(ctx, productID)
+}
+
+// ApplyDuePrices expires scheduled prices whose window has closed, activates
+// the ones whose window has opened, and then recomputes the price of every
+// product touched. A product with several running schedules takes the one
+// that became effective last; with none it falls back to its base price.
+func (s *serviceImpl) ApplyDuePrices(ctx context.Context, now time.Time) (ApplyResult, error) {
+	affected := map[primitive.ObjectID]struct{}{}
+
+	expired, err := s.repository.GetExpiredEntries(ctx, now)
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	if err := s.repository.UpdateStatus(ctx, entryIDs(expired), []string{StatusScheduled, StatusActive}, StatusExpired); err != nil {
+		return ApplyResult{}, err
+	}
+	for _, entry := range expired {
+		if entry.Status == StatusActive {
+			affected[entry.ProductID] = struct{}{}
+		}
+	}
+
+	due, err := s.repository.GetDueEntries(ctx, now)
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	if err := s.repository.UpdateStatus(ctx, entryIDs(due), []string{StatusScheduled}, StatusActive); err != nil {
+		return ApplyResult{}, err
+	}
+	for _, entry := range due {
+		affected[entry.ProductID] = struct{}{}
+	}
+
+	if len(affected) == 0 {
+		return ApplyResult{}, nil
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(affected))
+	for productID := range affected {
+		productIDs = append(productIDs, productID)
+	}
+
+	active, err := s.repository.GetActiveEntries(ctx, productIDs)
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	running := latestByProduct(active)
+
+	for _, productID := range productIDs {
+		price, err := s.effectivePrice(ctx, productID, running)
+		if err != nil {
+			return ApplyResult{}, err
+		}
+		if err := s.repository.SetProductPrice(ctx, productID, price); err != nil {
+			return ApplyResult{}, err
+		}
+	}
+
+	return ApplyResult{Applied: len(due), Expired: len(expired)}, nil
+}
+
+func (s *serviceImpl) effectivePrice(ctx context.Context, productID primitive.ObjectID, running map[primitive.ObjectID]Entry) (money.Money, error) {
+	if entry, ok := running[productID]; ok {
+		return entry.Price, nil
+	}
+	return s.repository.GetBasePrice(ctx, productID)
+}
+
+func latestByProduct(entries []Entry) map[primitive.ObjectID]Entry {
+	latest := make(map[primitive.ObjectID]Entry, len(entries))
+	for _, entry := range entries {
+		current, ok := latest[entry.ProductID]
+		if !ok || entry.EffectiveFrom.After(current.EffectiveFrom) ||
+			(entry.EffectiveFrom.Equal(current.EffectiveFrom) && entry.CreatedAt.After(current.CreatedAt)) {
+			latest[entry.ProductID] = entry
+		}
+	}
+	return latest
+}
+
+func entryIDs(entries []Entry) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
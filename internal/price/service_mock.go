@@ -0,0 +1,93 @@
+package price
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) RecordFeedPrices(ctx context.Context, changes []Change, uploaded []primitive.ObjectID) error {
+	ret := s.Mock.Called(ctx, changes, uploaded)
+	return ret.Error(0)
+}
+
+func (s *MockService) SchedulePrice(ctx context.Context, productID primitive.ObjectID, req SchedulePriceRequest) (Entry, error) {
+	ret := s.Mock.Called(ctx, productID, req)
+	return ret.Get(0).(Entry), ret.Error(1)
+}
+
+func (s *MockService) GetPriceHistory(ctx context.Context, productID primitive.ObjectID) ([]Entry, error) {
+	ret := s.Mock.Called(ctx, productID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+func (s *MockService) ApplyDuePrices(ctx context.Context, now time.Time) (ApplyResult, error) {
+	ret := s.Mock.Called(ctx, now)
+	return ret.Get(0).(ApplyResult), ret.Error(1)
+}
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) InsertEntries(ctx context.Context, entries []Entry) error {
+	ret := m.Mock.Called(ctx, entries)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) GetEntriesByProduct(ctx context.Context, productID primitive.ObjectID) ([]Entry, error) {
+	ret := m.Mock.Called(ctx, productID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+func (m *MockRepository) GetDueEntries(ctx context.Context, now time.Time) ([]Entry, error) {
+	ret := m.Mock.Called(ctx, now)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+func (m *MockRepository) GetExpiredEntries(ctx context.Context, now time.Time) ([]Entry, error) {
+	ret := m.Mock.Called(ctx, now)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+func (m *MockRepository) GetActiveEntries(ctx context.Context, productIDs []primitive.ObjectID) ([]Entry, error) {
+	ret := m.Mock.Called(ctx, productIDs)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Entry), ret.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(ctx context.Context, entryIDs []primitive.ObjectID, from []string, to string) error {
+	ret := m.Mock.Called(ctx, entryIDs, from, to)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) GetBasePrice(ctx context.Context, productID primitive.ObjectID) (money.Money, error) {
+	ret := m.Mock.Called(ctx, productID)
+	return ret.Get(0).(money.Money), ret.Error(1)
+}
+
+func (m *MockRepository) SetProductPrice(ctx context.Context, productID primitive.ObjectID, price money.Money) error {
+	ret := m.Mock.Called(ctx, productID, price)
+	return ret.Error(0)
+}
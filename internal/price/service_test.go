@@ -0,0 +1,149 @@
+package price
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PriceServiceTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	service    Service
+	now        time.Time
+}
+
+func (ps *PriceServiceTestSuite) SetupTest() {
+	logger.Init("debug")
+	ps.repository = new(MockRepository)
+	ps.service = NewService(ps.repository)
+	ps.now = time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPriceServiceSuite(t *testing.T) {
+	suite.Run(t, new(PriceServiceTestSuite))
+}
+
+func (ps *PriceServiceTestSuite) TestShouldApplyDueScheduledPrice() {
+	productID := primitive.NewObjectID()
+	due := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(999900, "INR"), EffectiveFrom: ps.now, Source: SourceSchedule, Status: StatusScheduled}
+
+	ps.repository.On("GetExpiredEntries", mock.Anything, ps.now).Return([]Entry{}, nil)
+	ps.repository.On("UpdateStatus", mock.Anything, []primitive.ObjectID{}, mock.Anything, StatusExpired).Return(nil)
+	ps.repository.On("GetDueEntries", mock.Anything, ps.now).Return([]Entry{due}, nil)
+	ps.repository.On("UpdateStatus", mock.Anything, []primitive.ObjectID{due.ID}, []string{StatusScheduled}, StatusActive).Return(nil)
+	active := due
+	active.Status = StatusActive
+	ps.repository.On("GetActiveEntries", mock.Anything, []primitive.ObjectID{productID}).Return([]Entry{active}, nil)
+	ps.repository.On("SetProductPrice", mock.Anything, productID, money.New(999900, "INR")).Return(nil)
+
+	result, err := ps.service.ApplyDuePrices(context.Background(), ps.now)
+
+	assert.Nil(ps.T(), err)
+	assert.Equal(ps.T(), ApplyResult{Applied: 1, Expired: 0}, result)
+	ps.repository.AssertExpectations(ps.T())
+}
+
+func (ps *PriceServiceTestSuite) TestShouldRevertToBasePriceWhenScheduleExpires() {
+	productID := primitive.NewObjectID()
+	effectiveTo := ps.now
+	expired := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(999900, "INR"), EffectiveFrom: ps.now.Add(-24 * time.Hour), EffectiveTo: &effectiveTo, Source: SourceSchedule, Status: StatusActive}
+
+	ps.repository.On("GetExpiredEntries", mock.Anything, ps.now).Return([]Entry{expired}, nil)
+	ps.repository.On("UpdateStatus", mock.Anything, []primitive.ObjectID{expired.ID}, []string{StatusScheduled, StatusActive}, StatusExpired).Return(nil)
+	ps.repository.On("GetDueEntries", mock.Anything, ps.now).Return([]Entry{}, nil)
+	ps.repository.On("UpdateStatus", mock.Anything, []primitive.ObjectID{}, mock.Anything, StatusActive).Return(nil)
+	ps.repository.On("GetActiveEntries", mock.Anything, []primitive.ObjectID{productID}).Return([]Entry{}, nil)
+	ps.repository.On("GetBasePrice", mock.Anything, productID).Return(money.New(1299900, "INR"), nil)
+	ps.repository.On("SetProductPrice", mock.Anything, productID, money.New(1299900, "INR")).Return(nil)
+
+	result, err := ps.service.ApplyDuePrices(context.Background(), ps.now)
+
+	assert.Nil(ps.T(), err)
+	assert.Equal(ps.T(), ApplyResult{Applied: 0, Expired: 1}, result)
+	ps.repository.AssertExpectations(ps.T())
+}
+
+func (ps *PriceServiceTestSuite) TestShouldPreferLatestRunningSchedule() {
+	productID := primitive.NewObjectID()
+	older := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(1199900, "INR"), EffectiveFrom: ps.now.Add(-48 * time.Hour), Source: SourceSchedule, Status: StatusActive}
+	newer := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(899900, "INR"), EffectiveFrom: ps.now, Source: SourceSchedule, Status: StatusScheduled}
+
+	ps.repository.On("GetExpiredEntries", mock.Anything, ps.now).Return([]Entry{}, nil)
+	ps.repository.On("UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ps.repository.On("GetDueEntries", mock.Anything, ps.now).Return([]Entry{newer}, nil)
+	ps.repository.On("GetActiveEntries", mock.Anything, []primitive.ObjectID{productID}).Return([]Entry{older, newer}, nil)
+	ps.repository.On("SetProductPrice", mock.Anything, productID, money.New(899900, "INR")).Return(nil)
+
+	_, err := ps.service.ApplyDuePrices(context.Background(), ps.now)
+
+	assert.Nil(ps.T(), err)
+	ps.repository.AssertExpectations(ps.T())
+}
+
+func (ps *PriceServiceTestSuite) TestShouldRejectScheduleEndingBeforeItStarts() {
+	from := time.Now().Add(48 * time.Hour)
+	to := from.Add(-time.Hour)
+
+	_, err := ps.service.SchedulePrice(context.Background(), primitive.NewObjectID(), SchedulePriceRequest{Price: money.New(999900, "INR"), EffectiveFrom: from, EffectiveTo: &to})
+
+	assert.Equal(ps.T(), types.NewValidationError("effectiveTo must be after effectiveFrom"), err)
+	ps.repository.AssertNotCalled(ps.T(), "InsertEntries", mock.Anything, mock.Anything)
+}
+
+func (ps *PriceServiceTestSuite) TestShouldReturnNotFoundWhenSchedulingUnknownProduct() {
+	productID := primitive.NewObjectID()
+	ps.repository.On("GetBasePrice", mock.Anything, productID).Return(money.Money{}, types.NewNotFoundError("Product not found"))
+
+	_, err := ps.service.SchedulePrice(context.Background(), productID, SchedulePriceRequest{Price: money.New(999900, "INR"), EffectiveFrom: time.Now().Add(time.Hour)})
+
+	assert.Equal(ps.T(), types.NewNotFoundError("Product not found"), err)
+	ps.repository.AssertNotCalled(ps.T(), "InsertEntries", mock.Anything, mock.Anything)
+}
+
+func (ps *PriceServiceTestSuite) TestShouldKeepRunningSchedulePriceAfterFeedUpload() {
+	productID := primitive.NewObjectID()
+	running := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(899900, "INR"), EffectiveFrom: ps.now, Source: SourceSchedule, Status: StatusActive}
+
+	ps.repository.On("InsertEntries", mock.Anything, mock.MatchedBy(func(entries []Entry) bool {
+		return len(entries) == 1 && entries[0].Source == SourceFeed && entries[0].Price == money.New(1299900, "INR")
+	})).Return(nil)
+	ps.repository.On("GetActiveEntries", mock.Anything, []primitive.ObjectID{productID}).Return([]Entry{running}, nil)
+	ps.repository.On("SetProductPrice", mock.Anything, productID, money.New(899900, "INR")).Return(nil)
+
+	err := ps.service.RecordFeedPrices(context.Background(), []Change{{ProductID: productID, Price: money.New(1299900, "INR")}}, []primitive.ObjectID{productID})
+
+	assert.Nil(ps.T(), err)
+	ps.repository.AssertExpectations(ps.T())
+}
+
+func (ps *PriceServiceTestSuite) TestShouldKeepRunningSchedulePriceWhenSameFeedIsUploadedAgain() {
+	productID := primitive.NewObjectID()
+	running := Entry{ID: primitive.NewObjectID(), ProductID: productID, Price: money.New(899900, "INR"), EffectiveFrom: ps.now, Source: SourceSchedule, Status: StatusActive}
+	ps.repository.On("GetActiveEntries", mock.Anything, []primitive.ObjectID{productID}).Return([]Entry{running}, nil)
+	ps.repository.On("SetProductPrice", mock.Anything, productID, money.New(899900, "INR")).Return(nil)
+
+	err := ps.service.RecordFeedPrices(context.Background(), []Change{}, []primitive.ObjectID{productID})
+
+	assert.Nil(ps.T(), err)
+	ps.repository.AssertExpectations(ps.T())
+	ps.repository.AssertNotCalled(ps.T(), "InsertEntries", mock.Anything, mock.Anything)
+}
+
+func (ps *PriceServiceTestSuite) TestShouldRejectScheduleInAnotherCurrency() {
+	productID := primitive.NewObjectID()
+	ps.repository.On("GetBasePrice", mock.Anything, productID).Return(money.New(1299900, "INR"), nil)
+
+	_, err := ps.service.SchedulePrice(context.Background(), productID, SchedulePriceRequest{Price: money.New(9999, "USD"), EffectiveFrom: time.Now().Add(time.Hour)})
+
+	assert.Equal(ps.T(), types.NewValidationError("price must be in INR, the currency of the product's base price"), err)
+	ps.repository.AssertNotCalled(ps.T(), "InsertEntries", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,65 @@
+package price
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SourceFeed     = "feed"
+	SourceSchedule = "schedule"
+)
+
+const (
+	StatusApplied   = "applied"
+	StatusScheduled = "scheduled"
+	StatusActive    = "active"
+	StatusExpired   = "expired"
+)
+
+// Entry is a single point in a product's price history. Feed entries are
+// recorded whenever a bulk upload changes the base price; schedule entries
+// are future-dated prices applied and reverted by the Scheduler.
+type Entry struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID      string             `json:"-" bson:"tenantId"`
+	ProductID     primitive.ObjectID `json:"productId" bson:"productId"`
+	Price         money.Money        `json:"price" bson:"price"`
+	EffectiveFrom time.Time          `json:"effectiveFrom" bson:"effectiveFrom"`
+	EffectiveTo   *time.Time         `json:"effectiveTo,omitempty" bson:"effectiveTo,omitempty"`
+	Source        string             `json:"source" bson:"source"`
+	Status        string             `json:"status" bson:"status"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Change is a base price written by a bulk upload
+type Change struct {
+	ProductID primitive.ObjectID
+	Price     money.Money
+}
+
+type SchedulePriceRequest struct {
+	Price         money.Money `json:"price" binding:"required"`
+	EffectiveFrom time.Time   `json:"effectiveFrom" binding:"required"`
+	EffectiveTo   *time.Time  `json:"effectiveTo"`
+}
+
+type SchedulePriceResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Price   Entry  `json:"price"`
+}
+
+type PriceHistoryResponse struct {
+	Success   bool               `json:"success"`
+	ProductID primitive.ObjectID `json:"productId"`
+	Count     int                `json:"count"`
+	Prices    []Entry            `json:"prices"`
+}
+
+type ApplyResult struct {
+	Applied int
+	Expired int
+}
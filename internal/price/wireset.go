@@ -0,0 +1,10 @@
+package price
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+	NewScheduler,
+)
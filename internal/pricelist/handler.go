@@ -0,0 +1,160 @@
+package pricelist
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) CreatePriceListHandler(ctx *gin.Context) {
+	var req PriceListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	priceList, err := h.service.CreatePriceList(ctx.Request.Context(), req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, PriceListResponse{
+		Success:   true,
+		Message:   "Price list created",
+		PriceList: *priceList,
+	})
+}
+
+func (h *Handler) UpdatePriceListHandler(ctx *gin.Context) {
+	var req PriceListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	priceList, err := h.service.UpdatePriceList(ctx.Request.Context(), NormalizeCode(ctx.Param("code")), req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PriceListResponse{
+		Success:   true,
+		Message:   "Price list updated",
+		PriceList: *priceList,
+	})
+}
+
+func (h *Handler) DeletePriceListHandler(ctx *gin.Context) {
+	if err := h.service.DeletePriceList(ctx.Request.Context(), NormalizeCode(ctx.Param("code"))); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetPriceListHandler(ctx *gin.Context) {
+	priceList, err := h.service.GetPriceList(ctx.Request.Context(), NormalizeCode(ctx.Param("code")))
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, priceList)
+}
+
+func (h *Handler) ListPriceListsHandler(ctx *gin.Context) {
+	priceLists, err := h.service.ListPriceLists(ctx.Request.Context())
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListPriceListsResponse{
+		Success:    true,
+		Count:      len(priceLists),
+		PriceLists: priceLists,
+	})
+}
+
+func (h *Handler) GetPricesHandler(ctx *gin.Context) {
+	code := NormalizeCode(ctx.Param("code"))
+	prices, err := h.service.GetPrices(ctx.Request.Context(), code)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PricesResponse{
+		Success: true,
+		Code:    code,
+		Count:   len(prices),
+		Prices:  prices,
+	})
+}
+
+func (h *Handler) SetPricesHandler(ctx *gin.Context) {
+	var req SetPricesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	code := NormalizeCode(ctx.Param("code"))
+	if err := h.service.SetPrices(ctx.Request.Context(), code, req.Prices); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PricesResponse{
+		Success: true,
+		Code:    code,
+		Count:   len(req.Prices),
+		Prices:  req.Prices,
+	})
+}
+
+func (h *Handler) RemovePriceHandler(ctx *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(ctx.Param("productId"))
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
+		return
+	}
+
+	if err := h.service.RemovePrice(ctx.Request.Context(), NormalizeCode(ctx.Param("code")), productID); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
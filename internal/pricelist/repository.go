@@ -0,0 +1,292 @@
+package pricelist
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error)
+	UpdatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error)
+	DeletePriceList(ctx context.Context, code string) error
+	GetPriceListByCode(ctx context.Context, code string) (*PriceList, error)
+	ListPriceLists(ctx context.Context) ([]PriceList, error)
+	GetPrices(ctx context.Context, code string) ([]Override, error)
+	SetPrices(ctx context.Context, code string, overrides []Override) error
+	RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error
+	GetExistingProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+	products   *mongo.Collection
+	events     events.Repository
+}
+
+func NewRepository(db *utils.DBInstance, eventsRepository events.Repository) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidPriceLists"),
+		products:   db.Collection("rapidProducts"),
+		events:     eventsRepository,
+	}
+}
+
+func (r *repositoryImpl) CreatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error) {
+	priceList.TenantID = tenant.ID(ctx)
+	var result *mongo.InsertOneResult
+	err := r.db.Write(ctx, "pricelist.CreatePriceList", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.InsertOne(ctx, priceList)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error creating price list",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  priceList.Code,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	priceList.ID = result.InsertedID.(primitive.ObjectID)
+	return &priceList, nil
+}
+
+func (r *repositoryImpl) UpdatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error) {
+	priceList.TenantID = tenant.ID(ctx)
+	var result *mongo.UpdateResult
+	err := r.db.Write(ctx, "pricelist.UpdatePriceList", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.ReplaceOne(ctx, tenant.Filter(ctx, bson.M{"code": priceList.Code}), priceList)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error updating price list",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  priceList.Code,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, types.NewNotFoundError("Price list not found")
+	}
+
+	return &priceList, nil
+}
+
+// DeletePriceList removes the list and every override it holds
+func (r *repositoryImpl) DeletePriceList(ctx context.Context, code string) error {
+	var result *mongo.DeleteResult
+	err := r.db.Write(ctx, "pricelist.DeletePriceList", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteOne(ctx, tenant.Filter(ctx, bson.M{"code": code}))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error deleting price list",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  code,
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+	if result.DeletedCount == 0 {
+		return types.NewNotFoundError("Price list not found")
+	}
+
+	// Each product is updated on its own so that it gets its change event
+	field := overrideField(code)
+	var documents []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err = r.db.Read(ctx, "pricelist.DeletePriceList", func(ctx context.Context) error {
+		cursor, err := r.products.Find(ctx, tenant.Filter(ctx, events.Live(bson.M{field: bson.M{"$exists": true}})), options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &documents)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching price list overrides",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  code,
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	for _, document := range documents {
+		filter := bson.M{"_id": document.ID, field: bson.M{"$exists": true}}
+		if _, err := r.events.UpdateProduct(ctx, filter, bson.M{"$unset": bson.M{field: ""}}, []string{"priceLists"}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetPriceListByCode(ctx context.Context, code string) (*PriceList, error) {
+	var priceList PriceList
+	err := r.db.Read(ctx, "pricelist.GetPriceListByCode", func(ctx context.Context) error {
+		return r.collection.FindOne(ctx, tenant.Filter(ctx, bson.M{"code": code})).Decode(&priceList)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, types.NewNotFoundError("Price list not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching price list",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  code,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &priceList, nil
+}
+
+func (r *repositoryImpl) ListPriceLists(ctx context.Context) ([]PriceList, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "code", Value: 1}})
+	priceLists := []PriceList{}
+	err := r.db.Read(ctx, "pricelist.ListPriceLists", func(ctx context.Context) error {
+		cursor, err := r.collection.Find(ctx, tenant.Filter(ctx, bson.M{}), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &priceLists)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching price lists",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return priceLists, nil
+}
+
+func (r *repositoryImpl) GetPrices(ctx context.Context, code string) ([]Override, error) {
+	field := overrideField(code)
+	findOptions := options.Find().SetProjection(bson.M{"price": "$" + field})
+	overrides := []Override{}
+	err := r.db.Read(ctx, "pricelist.GetPrices", func(ctx context.Context) error {
+		cursor, err := r.products.Find(ctx, tenant.Filter(ctx, events.Live(bson.M{field: bson.M{"$exists": true}})), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &overrides)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching price list overrides",
+			Data: map[string]string{
+				"error": err.Error(),
+				"code":  code,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return overrides, nil
+}
+
+func (r *repositoryImpl) SetPrices(ctx context.Context, code string, overrides []Override) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	// Overrides that already hold the price are skipped, and so record no
+	// change event
+	field := overrideField(code)
+	for _, override := range overrides {
+		filter := bson.M{"_id": override.ProductID, field: bson.M{"$ne": override.Price}}
+		if _, err := r.events.UpdateProduct(ctx, filter, bson.M{"$set": bson.M{field: override.Price}}, []string{"priceLists"}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error {
+	field := overrideField(code)
+	filter := bson.M{"_id": productID, field: bson.M{"$exists": true}}
+	matched, err := r.events.UpdateProduct(ctx, filter, bson.M{"$unset": bson.M{field: ""}}, []string{"priceLists"})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return types.NewNotFoundError("Product has no price in this price list")
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetExistingProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1})
+	var documents []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := r.db.Read(ctx, "pricelist.GetExistingProductIDs", func(ctx context.Context) error {
+		cursor, err := r.products.Find(ctx, tenant.Filter(ctx, events.Live(bson.M{"_id": bson.M{"$in": productIDs}})), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &documents)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching product IDs",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	existing := make([]primitive.ObjectID, 0, len(documents))
+	for _, document := range documents {
+		existing = append(existing, document.ID)
+	}
+	return existing, nil
+}
+
+// overrideField is the product field holding a list's override. Codes are
+// validated on creation, so they are safe to use in a field path.
+func overrideField(code string) string {
+	return "priceLists." + code
+}
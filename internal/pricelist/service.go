@@ -0,0 +1,269 @@
+package pricelist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+// codePattern keeps codes usable as a product field name
+var codePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type Service interface {
+	CreatePriceList(ctx context.Context, req PriceListRequest) (*PriceList, error)
+	UpdatePriceList(ctx context.Context, code string, req PriceListRequest) (*PriceList, error)
+	DeletePriceList(ctx context.Context, code string) error
+	GetPriceList(ctx context.Context, code string) (*PriceList, error)
+	ListPriceLists(ctx context.Context) ([]PriceList, error)
+	GetActivePriceLists(ctx context.Context) ([]PriceList, error)
+	GetPrices(ctx context.Context, code string) ([]Override, error)
+	SetPrices(ctx context.Context, code string, overrides []Override) error
+	RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error
+}
+
+type serviceImpl struct {
+	repository Repository
+	cacheTTL   time.Duration
+
+	mu sync.Mutex
+	// active caches each tenant's active lists, keyed by tenant ID
+	active map[string]activePriceLists
+}
+
+type activePriceLists struct {
+	priceLists []PriceList
+	expiresAt  time.Time
+}
+
+func NewService(cfg config.Config, repo Repository) Service {
+	cacheTTL := time.Duration(cfg.Get().PriceLists.CacheTTL) * time.Second
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	return &serviceImpl{
+		repository: repo,
+		cacheTTL:   cacheTTL,
+		active:     map[string]activePriceLists{},
+	}
+}
+
+func (s *serviceImpl) CreatePriceList(ctx context.Context, req PriceListRequest) (*PriceList, error) {
+	code := NormalizeCode(req.Code)
+	if !codePattern.MatchString(code) {
+		return nil, types.NewValidationError("code must be lowercase letters, digits, '-' or '_'")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, types.NewValidationError("name cannot be empty")
+	}
+
+	existing, err := s.repository.GetPriceListByCode(ctx, code)
+	if err != nil && types.ToStatusError(err).HTTPCode != http.StatusNotFound {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, types.NewValidationError(fmt.Sprintf("price list %s already exists", code))
+	}
+
+	now := time.Now().UTC()
+	priceList := fromRequest(req)
+	priceList.Code = code
+	priceList.CreatedAt = now
+	priceList.UpdatedAt = now
+
+	created, err := s.repository.CreatePriceList(ctx, priceList)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Price list created", Data: map[string]string{"code": created.Code}})
+	return created, nil
+}
+
+// UpdatePriceList changes the name, description and state of a list; its
+// code identifies the overrides and cannot change
+func (s *serviceImpl) UpdatePriceList(ctx context.Context, code string, req PriceListRequest) (*PriceList, error) {
+	if NormalizeCode(req.Code) != code {
+		return nil, types.NewValidationError("code cannot be changed")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, types.NewValidationError("name cannot be empty")
+	}
+
+	existing, err := s.repository.GetPriceListByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	priceList := fromRequest(req)
+	priceList.ID = existing.ID
+	priceList.Code = code
+	priceList.CreatedAt = existing.CreatedAt
+	priceList.UpdatedAt = time.Now().UTC()
+
+	updated, err := s.repository.UpdatePriceList(ctx, priceList)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Price list updated", Data: map[string]string{"code": code}})
+	return updated, nil
+}
+
+func (s *serviceImpl) DeletePriceList(ctx context.Context, code string) error {
+	if err := s.repository.DeletePriceList(ctx, code); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Price list deleted", Data: map[string]string{"code": code}})
+	return nil
+}
+
+func (s *serviceImpl) GetPriceList(ctx context.Context, code string) (*PriceList, error) {
+	return s.repository.GetPriceListByCode(ctx, code)
+}
+
+func (s *serviceImpl) ListPriceLists(ctx context.Context) ([]PriceList, error) {
+	return s.repository.ListPriceLists(ctx)
+}
+
+// GetActivePriceLists returns the lists callers may price against. It is read
+// on every product search and lookup that names a list, so it is cached for
+// cacheTTL per tenant and refreshed after any change made through this
+// service.
+func (s *serviceImpl) GetActivePriceLists(ctx context.Context) ([]PriceList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	tenantID := tenant.ID(ctx)
+	if cached, ok := s.active[tenantID]; ok && now.Before(cached.expiresAt) {
+		return cached.priceLists, nil
+	}
+
+	priceLists, err := s.repository.ListPriceLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]PriceList, 0, len(priceLists))
+	for _, priceList := range priceLists {
+		if priceList.Active {
+			active = append(active, priceList)
+		}
+	}
+
+	s.active[tenantID] = activePriceLists{priceLists: active, expiresAt: now.Add(s.cacheTTL)}
+	return active, nil
+}
+
+func (s *serviceImpl) GetPrices(ctx context.Context, code string) ([]Override, error) {
+	if _, err := s.repository.GetPriceListByCode(ctx, code); err != nil {
+		return nil, err
+	}
+
+	return s.repository.GetPrices(ctx, code)
+}
+
+// SetPrices adds or replaces overrides in a list. Overrides are in the base
+// currency and are converted like the base price for other currencies.
+func (s *serviceImpl) SetPrices(ctx context.Context, code string, overrides []Override) error {
+	if len(overrides) == 0 {
+		return types.NewValidationError("prices cannot be empty")
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(overrides))
+	seen := map[primitive.ObjectID]bool{}
+	for i, override := range overrides {
+		if override.ProductID.IsZero() {
+			return types.NewValidationError(fmt.Sprintf("Price at index %d: productId is required", i))
+		}
+		if override.Price.Amount <= 0 {
+			return types.NewValidationError(fmt.Sprintf("Price at index %d: price must be greater than 0", i))
+		}
+		if override.Price.Currency != money.DefaultCurrency() {
+			return types.NewValidationError(fmt.Sprintf("Price at index %d: price must be in %s", i, money.DefaultCurrency()))
+		}
+		if seen[override.ProductID] {
+			return types.NewValidationError(fmt.Sprintf("Price at index %d: duplicate product %s", i, override.ProductID.Hex()))
+		}
+		seen[override.ProductID] = true
+		productIDs = append(productIDs, override.ProductID)
+	}
+
+	if _, err := s.repository.GetPriceListByCode(ctx, code); err != nil {
+		return err
+	}
+
+	existing, err := s.repository.GetExistingProductIDs(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	if len(existing) != len(productIDs) {
+		found := make(map[primitive.ObjectID]bool, len(existing))
+		for _, productID := range existing {
+			found[productID] = true
+		}
+		for _, productID := range productIDs {
+			if !found[productID] {
+				return types.NewNotFoundError(fmt.Sprintf("Product %s not found", productID.Hex()))
+			}
+		}
+	}
+
+	if err := s.repository.SetPrices(ctx, code, overrides); err != nil {
+		return err
+	}
+
+	logging.Info(ctx, logger.Format{Message: "Price list prices set", Data: map[string]string{"code": code, "count": fmt.Sprintf("%d", len(overrides))}})
+	return nil
+}
+
+func (s *serviceImpl) RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error {
+	if _, err := s.repository.GetPriceListByCode(ctx, code); err != nil {
+		return err
+	}
+
+	return s.repository.RemovePrice(ctx, code, productID)
+}
+
+func (s *serviceImpl) invalidate(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, tenant.ID(ctx))
+}
+
+// NormalizeCode maps a caller supplied price list code to its stored form
+func NormalizeCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+func fromRequest(req PriceListRequest) PriceList {
+	priceList := PriceList{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Active:      true,
+	}
+	if req.Active != nil {
+		priceList.Active = *req.Active
+	}
+	return priceList
+}
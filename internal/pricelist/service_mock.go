@@ -0,0 +1,142 @@
+package pricelist
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) CreatePriceList(ctx context.Context, req PriceListRequest) (*PriceList, error) {
+	ret := s.Mock.Called(ctx, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (s *MockService) UpdatePriceList(ctx context.Context, code string, req PriceListRequest) (*PriceList, error) {
+	ret := s.Mock.Called(ctx, code, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (s *MockService) DeletePriceList(ctx context.Context, code string) error {
+	ret := s.Mock.Called(ctx, code)
+	return ret.Error(0)
+}
+
+func (s *MockService) GetPriceList(ctx context.Context, code string) (*PriceList, error) {
+	ret := s.Mock.Called(ctx, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (s *MockService) ListPriceLists(ctx context.Context) ([]PriceList, error) {
+	ret := s.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]PriceList), ret.Error(1)
+}
+
+func (s *MockService) GetActivePriceLists(ctx context.Context) ([]PriceList, error) {
+	ret := s.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]PriceList), ret.Error(1)
+}
+
+func (s *MockService) GetPrices(ctx context.Context, code string) ([]Override, error) {
+	ret := s.Mock.Called(ctx, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Override), ret.Error(1)
+}
+
+func (s *MockService) SetPrices(ctx context.Context, code string, overrides []Override) error {
+	ret := s.Mock.Called(ctx, code, overrides)
+	return ret.Error(0)
+}
+
+func (s *MockService) RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error {
+	ret := s.Mock.Called(ctx, code, productID)
+	return ret.Error(0)
+}
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error) {
+	ret := m.Mock.Called(ctx, priceList)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (m *MockRepository) UpdatePriceList(ctx context.Context, priceList PriceList) (*PriceList, error) {
+	ret := m.Mock.Called(ctx, priceList)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (m *MockRepository) DeletePriceList(ctx context.Context, code string) error {
+	ret := m.Mock.Called(ctx, code)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) GetPriceListByCode(ctx context.Context, code string) (*PriceList, error) {
+	ret := m.Mock.Called(ctx, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*PriceList), ret.Error(1)
+}
+
+func (m *MockRepository) ListPriceLists(ctx context.Context) ([]PriceList, error) {
+	ret := m.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]PriceList), ret.Error(1)
+}
+
+func (m *MockRepository) GetPrices(ctx context.Context, code string) ([]Override, error) {
+	ret := m.Mock.Called(ctx, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Override), ret.Error(1)
+}
+
+func (m *MockRepository) SetPrices(ctx context.Context, code string, overrides []Override) error {
+	ret := m.Mock.Called(ctx, code, overrides)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) RemovePrice(ctx context.Context, code string, productID primitive.ObjectID) error {
+	ret := m.Mock.Called(ctx, code, productID)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) GetExistingProductIDs(ctx context.Context, productIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	ret := m.Mock.Called(ctx, productIDs)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]primitive.ObjectID), ret.Error(1)
+}
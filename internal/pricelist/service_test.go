@@ -0,0 +1,91 @@
+package pricelist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PriceListServiceTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	service    Service
+}
+
+func (ps *PriceListServiceTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		ps.T().Fatalf("Failed to initialize config: %v", err)
+	}
+	logger.Init(cfg.Get().Log.Level)
+	money.SetDefaultCurrency("INR")
+	ps.repository = new(MockRepository)
+	ps.service = NewService(cfg, ps.repository)
+}
+
+func TestPriceListServiceSuite(t *testing.T) {
+	suite.Run(t, new(PriceListServiceTestSuite))
+}
+
+func (ps *PriceListServiceTestSuite) TestShouldRejectCodeUnusableAsFieldName() {
+	_, err := ps.service.CreatePriceList(context.Background(), PriceListRequest{Code: "b2b.tier", Name: "B2B"})
+
+	assert.Equal(ps.T(), types.NewValidationError("code must be lowercase letters, digits, '-' or '_'"), err)
+	ps.repository.AssertNotCalled(ps.T(), "CreatePriceList", mock.Anything, mock.Anything)
+}
+
+func (ps *PriceListServiceTestSuite) TestShouldRejectDuplicateCode() {
+	ps.repository.On("GetPriceListByCode", mock.Anything, "wholesale").Return(&PriceList{Code: "wholesale"}, nil)
+
+	_, err := ps.service.CreatePriceList(context.Background(), PriceListRequest{Code: " Wholesale ", Name: "Wholesale"})
+
+	assert.Equal(ps.T(), types.NewValidationError("price list wholesale already exists"), err)
+	ps.repository.AssertNotCalled(ps.T(), "CreatePriceList", mock.Anything, mock.Anything)
+}
+
+func (ps *PriceListServiceTestSuite) TestShouldRejectPricesForUnknownProducts() {
+	known, unknown := primitive.NewObjectID(), primitive.NewObjectID()
+	overrides := []Override{
+		{ProductID: known, Price: money.New(999900, "INR")},
+		{ProductID: unknown, Price: money.New(899900, "INR")},
+	}
+	ps.repository.On("GetPriceListByCode", mock.Anything, "wholesale").Return(&PriceList{Code: "wholesale"}, nil)
+	ps.repository.On("GetExistingProductIDs", mock.Anything, []primitive.ObjectID{known, unknown}).Return([]primitive.ObjectID{known}, nil)
+
+	err := ps.service.SetPrices(context.Background(), "wholesale", overrides)
+
+	assert.Equal(ps.T(), types.NewNotFoundError("Product "+unknown.Hex()+" not found"), err)
+	ps.repository.AssertNotCalled(ps.T(), "SetPrices", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ps *PriceListServiceTestSuite) TestShouldRejectPricesOutsideBaseCurrency() {
+	overrides := []Override{{ProductID: primitive.NewObjectID(), Price: money.New(9900, "USD")}}
+
+	err := ps.service.SetPrices(context.Background(), "wholesale", overrides)
+
+	assert.Equal(ps.T(), types.NewValidationError("Price at index 0: price must be in INR"), err)
+	ps.repository.AssertNotCalled(ps.T(), "SetPrices", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (ps *PriceListServiceTestSuite) TestShouldOnlyServeActivePriceLists() {
+	ps.repository.On("ListPriceLists", mock.Anything).Return([]PriceList{
+		{Code: "app-only", Active: false},
+		{Code: "wholesale", Active: true},
+	}, nil).Once()
+
+	active, err := ps.service.GetActivePriceLists(context.Background())
+	cached, _ := ps.service.GetActivePriceLists(context.Background())
+
+	assert.Nil(ps.T(), err)
+	assert.Equal(ps.T(), []PriceList{{Code: "wholesale", Active: true}}, active)
+	assert.Equal(ps.T(), active, cached)
+	ps.repository.AssertNumberOfCalls(ps.T(), "ListPriceLists", 1)
+}
@@ -0,0 +1,62 @@
+package pricelist
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Header lets a caller pick a price list without changing the request body
+const Header = "X-Price-List"
+
+// PriceList is a named set of per-product price overrides for a customer
+// segment or sales channel, e.g. wholesale or app-only. Products without an
+// override in the list are sold at their base price. Overrides are stored on
+// the product documents under priceLists.<code>, in the base currency.
+type PriceList struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    string             `json:"-" bson:"tenantId"`
+	Code        string             `json:"code" bson:"code"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Active      bool               `json:"active" bson:"active"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type PriceListRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"`
+}
+
+// Override is the price of one product in a price list
+type Override struct {
+	ProductID primitive.ObjectID `json:"productId" bson:"_id"`
+	Price     money.Money        `json:"price" bson:"price"`
+}
+
+type SetPricesRequest struct {
+	Prices []Override `json:"prices" binding:"required"`
+}
+
+type PriceListResponse struct {
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	PriceList PriceList `json:"priceList"`
+}
+
+type ListPriceListsResponse struct {
+	Success    bool        `json:"success"`
+	Count      int         `json:"count"`
+	PriceLists []PriceList `json:"priceLists"`
+}
+
+type PricesResponse struct {
+	Success bool       `json:"success"`
+	Code    string     `json:"code"`
+	Count   int        `json:"count"`
+	Prices  []Override `json:"prices"`
+}
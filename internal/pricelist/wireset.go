@@ -0,0 +1,9 @@
+package pricelist
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+)
@@ -0,0 +1,125 @@
+package product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// checkUploadPermissions refuses an upload changing products the caller of
+// ctx may not change. Creating a product takes bulk-write; changing one
+// takes price-write for its prices, inventory-write for its inventory and
+// bulk-write for the rest, each on the product both as stored and as
+// uploaded, so that a caller scoped to a brand can neither take over nor
+// give away another brand's products. Requests without a caller, as when
+// authentication is disabled, are not restricted.
+func checkUploadPermissions(ctx context.Context, products []Product, existing []Product) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	stored := make(map[productKey]Product, len(existing))
+	for _, product := range existing {
+		stored[productKey{product.Name, product.Category}] = product
+	}
+	for _, product := range products {
+		previous, exists := stored[productKey{product.Name, product.Category}]
+		for _, permission := range requiredPermissions(product, previous, exists) {
+			if !identity.CanOn(permission, product.Brand, product.Category) {
+				return forbidden(permission, product)
+			}
+			if exists && !identity.CanOn(permission, previous.Brand, previous.Category) {
+				return forbidden(permission, previous)
+			}
+		}
+	}
+	return nil
+}
+
+// fieldPermissions are the permissions changing a stored field takes, where
+// it is not bulk-write
+var fieldPermissions = map[string]auth.Permission{
+	"price":          auth.PermissionPriceWrite,
+	"currencyPrices": auth.PermissionPriceWrite,
+	"availableQty":   auth.PermissionInventoryWrite,
+}
+
+// requiredPermissions returns the permissions writing product over previous
+// takes. The uploaded price is compared with the stored base price, as the
+// stored price is a scheduled one while a sale runs.
+func requiredPermissions(product, previous Product, exists bool) []auth.Permission {
+	if !exists {
+		return []auth.Permission{auth.PermissionBulkWrite}
+	}
+	if previous.BasePrice.Currency != "" {
+		previous.Price = previous.BasePrice
+	}
+
+	permissions := []auth.Permission{}
+	seen := map[auth.Permission]bool{}
+	for _, field := range changedFields(previous, product) {
+		permission, ok := fieldPermissions[field]
+		if !ok {
+			permission = auth.PermissionBulkWrite
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// readScopes returns the grants limiting the products the caller of ctx may
+// read, none when it may read the whole catalog
+func readScopes(ctx context.Context) ([]auth.Grant, error) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	scopes, limited := identity.Scopes(auth.PermissionRead)
+	if !limited {
+		return nil, nil
+	}
+	if len(scopes) == 0 {
+		return nil, types.NewForbiddenError(fmt.Sprintf("Requires the %s permission", auth.PermissionRead))
+	}
+	return scopes, nil
+}
+
+// checkReadPermission refuses reading a product out of the scope of the
+// caller of ctx as not found, so that scoped callers do not learn of the
+// products outside it
+func checkReadPermission(ctx context.Context, product *Product) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok || identity.CanOn(auth.PermissionRead, product.Brand, product.Category) {
+		return nil
+	}
+	return types.NewNotFoundError("Product not found")
+}
+
+// checkDeletePermission refuses deleting a product the caller of ctx may not
+// delete; the product is only read when the caller's grants are scoped
+func checkDeletePermission(ctx context.Context, repository Repository, productID primitive.ObjectID) error {
+	identity, ok := auth.FromContext(ctx)
+	if !ok || identity.Can(auth.PermissionDelete) {
+		return nil
+	}
+
+	product, err := repository.GetProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if !identity.CanOn(auth.PermissionDelete, product.Brand, product.Category) {
+		return forbidden(auth.PermissionDelete, *product)
+	}
+	return nil
+}
+
+func forbidden(permission auth.Permission, product Product) error {
+	return types.NewForbiddenError(fmt.Sprintf("Requires the %s permission on %s products in %s", permission, product.Brand, product.Category))
+}
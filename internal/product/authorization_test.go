@@ -0,0 +1,47 @@
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthorizationTestSuite struct {
+	suite.Suite
+	inventoryManager context.Context
+	stored           Product
+}
+
+func (at *AuthorizationTestSuite) SetupTest() {
+	at.inventoryManager = auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"inventory-manager"}})
+	at.stored = catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)
+	at.stored.BasePrice = at.stored.Price
+}
+
+func TestAuthorizationSuite(t *testing.T) {
+	suite.Run(t, new(AuthorizationTestSuite))
+}
+
+func (at *AuthorizationTestSuite) TestShouldLetInventoryUpdateThroughWhileSaleIsActive() {
+	at.stored.Price = money.New(999900, "INR")
+	restocked := catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)
+	restocked.Inventory = 25
+
+	err := checkUploadPermissions(at.inventoryManager, []Product{restocked}, []Product{at.stored})
+
+	assert.NoError(at.T(), err)
+}
+
+func (at *AuthorizationTestSuite) TestShouldRequirePriceWriteToChangeBasePriceWhileSaleIsActive() {
+	at.stored.Price = money.New(999900, "INR")
+	repriced := catalogProduct("Titan Edge", "watch", "titan", 999900, 4.5)
+
+	err := checkUploadPermissions(at.inventoryManager, []Product{repriced}, []Product{at.stored})
+
+	assert.Equal(at.T(), "forbidden", types.ToStatusError(err).Code)
+}
@@ -0,0 +1,390 @@
+package product
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheSize = 10000
+	defaultCacheTTL  = 60 * time.Second
+	// cacheEventBuffer is how many change events the cache may fall behind
+	// the broker before it is dropped and purged
+	cacheEventBuffer = 1024
+)
+
+// CacheStats counts the lookups served by the product cache
+type CacheStats struct {
+	Enabled   bool   `json:"enabled"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Capacity  int    `json:"capacity"`
+}
+
+// SearchCacheStats counts the searches served by the search cache
+type SearchCacheStats struct {
+	Enabled bool   `json:"enabled"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+type cacheEntry struct {
+	productID primitive.ObjectID
+	product   Product
+	expiresAt time.Time
+}
+
+// CachedRepository serves GetProductByID from a size-bounded LRU cache whose
+// entries expire after a TTL, and SearchProducts from a SearchCache with a
+// short TTL. Concurrent misses on the same key share one read. Entries are
+// invalidated by writes made through it and, while Run is following the
+// broker, by the change events of writes made anywhere else.
+type CachedRepository struct {
+	repository Repository
+	broker     *events.Broker
+	enabled    bool
+	size       int
+	ttl        time.Duration
+	now        func() time.Time
+
+	search        SearchCache
+	searchEnabled bool
+	searchTTL     time.Duration
+
+	mu      sync.Mutex
+	entries map[primitive.ObjectID]*list.Element
+	order   *list.List
+	// generation changes on every invalidation, so a read that started
+	// before one does not cache what it read
+	generation uint64
+	loads      singleflight.Group
+
+	hits         uint64
+	misses       uint64
+	evictions    uint64
+	searchHits   uint64
+	searchMisses uint64
+}
+
+func NewCachedRepository(cfg config.Config, db *utils.DBInstance, eventsRepository events.Repository, search SearchCache, broker *events.Broker) *CachedRepository {
+	return newCachedRepository(cfg, newStore(cfg, db, eventsRepository), search, broker)
+}
+
+func newCachedRepository(cfg config.Config, repository Repository, search SearchCache, broker *events.Broker) *CachedRepository {
+	cacheConfig := cfg.Get().ProductCache
+	size := cacheConfig.Size
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	ttl := time.Duration(cacheConfig.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	searchConfig := cfg.Get().SearchCache
+	searchTTL := time.Duration(searchConfig.TTL) * time.Second
+	if searchTTL <= 0 {
+		searchTTL = defaultSearchCacheTTL
+	}
+
+	return &CachedRepository{
+		repository: repository,
+		broker:     broker,
+		enabled:    cacheConfig.Enabled,
+		size:       size,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[primitive.ObjectID]*list.Element{},
+		order:      list.New(),
+
+		search:        search,
+		searchEnabled: searchConfig.Enabled,
+		searchTTL:     searchTTL,
+	}
+}
+
+func (c *CachedRepository) CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error) {
+	result, err := c.repository.CreateProducts(ctx, products, opts)
+	if err != nil {
+		// Part of the upload may have been written, unless it was atomic
+		c.purge(ctx)
+		return nil, err
+	}
+
+	for _, product := range result.Products {
+		c.invalidate(ctx, product.ID, product.Category, result.Previous[product.ID].Category)
+	}
+	return result, nil
+}
+
+// SearchProducts returns a copy of the cached results, so callers may price
+// and localize them in place. Searches matched in memory are not cached, as
+// their results depend on more than their parameters.
+func (c *CachedRepository) SearchProducts(ctx context.Context, params SearchParams) ([]Product, error) {
+	if !c.searchEnabled || params.Match != nil {
+		return c.repository.SearchProducts(ctx, params)
+	}
+
+	key := searchKey(tenant.ID(ctx), params)
+	cached, found, err := c.search.Get(ctx, key)
+	if err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to read search cache", Data: map[string]string{"error": err.Error()}})
+	}
+	tracing.SpanFromContext(ctx).SetAttribute("cache.hit", found)
+	if found {
+		atomic.AddUint64(&c.searchHits, 1)
+		cacheRequests.Inc("search", "hit")
+		return append([]Product{}, cached...), nil
+	}
+	atomic.AddUint64(&c.searchMisses, 1)
+	cacheRequests.Inc("search", "miss")
+
+	generation := c.currentGeneration()
+
+	loaded, err, _ := c.loads.Do("search:"+key+":"+strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		products, err := c.repository.SearchProducts(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		c.storeSearch(ctx, key, products, searchTags(params, products), generation)
+		return products, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Product{}, loaded.([]Product)...), nil
+}
+
+// storeSearch caches a search read at the given generation. The backend may
+// be remote, so it is called without holding mu; an invalidation that bumps
+// the generation before the write lands may have reached the backend first,
+// so the write is then dropped again rather than left stale.
+func (c *CachedRepository) storeSearch(ctx context.Context, key string, products []Product, tags []string, generation uint64) {
+	if c.currentGeneration() != generation {
+		return
+	}
+	if err := c.search.Set(ctx, key, products, tags, c.searchTTL); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to write search cache", Data: map[string]string{"error": err.Error()}})
+		return
+	}
+	if c.currentGeneration() != generation {
+		if err := c.search.Invalidate(ctx, tags...); err != nil {
+			logging.Error(ctx, logger.Format{Message: "Failed to invalidate search cache", Data: map[string]string{"error": err.Error()}})
+		}
+	}
+}
+
+func (c *CachedRepository) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *CachedRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	err := c.repository.DeleteProduct(ctx, productID)
+	c.invalidate(ctx, productID)
+	return err
+}
+
+// CountProducts is not cached; it is only read periodically for metrics
+func (c *CachedRepository) CountProducts(ctx context.Context) (CatalogStats, error) {
+	return c.repository.CountProducts(ctx)
+}
+
+// GetProductByID returns a copy of the cached product, so callers may price
+// and localize it in place
+func (c *CachedRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
+	if !c.enabled {
+		return c.repository.GetProductByID(ctx, productID)
+	}
+
+	tenantID := tenant.ID(ctx)
+	cached, ok := c.lookup(tenantID, productID)
+	tracing.SpanFromContext(ctx).SetAttribute("cache.hit", ok)
+	if ok {
+		return &cached, nil
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	key := tenantID + ":" + productID.Hex() + ":" + strconv.FormatUint(generation, 10)
+	loaded, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		product, err := c.repository.GetProductByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		c.store(productID, *product, generation)
+		return *product, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	product := loaded.(Product)
+	return &product, nil
+}
+
+// lookup only serves a product to its own tenant; to any other it is a miss,
+// which the repository answers as not found
+func (c *CachedRepository) lookup(tenantID string, productID primitive.ObjectID) (Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[productID]
+	if !ok || element.Value.(*cacheEntry).product.TenantID != tenantID {
+		atomic.AddUint64(&c.misses, 1)
+		cacheRequests.Inc("product", "miss")
+		return Product{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		atomic.AddUint64(&c.misses, 1)
+		cacheRequests.Inc("product", "miss")
+		return Product{}, false
+	}
+
+	c.order.MoveToFront(element)
+	atomic.AddUint64(&c.hits, 1)
+	cacheRequests.Inc("product", "hit")
+	return entry.product, true
+}
+
+func (c *CachedRepository) store(productID primitive.ObjectID, product Product, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if element, ok := c.entries[productID]; ok {
+		c.remove(element)
+	}
+	c.entries[productID] = c.order.PushFront(&cacheEntry{
+		productID: productID,
+		product:   product,
+		expiresAt: c.now().Add(c.ttl),
+	})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		atomic.AddUint64(&c.evictions, 1)
+		cacheEvictions.Inc()
+	}
+}
+
+// remove must be called with mu held
+func (c *CachedRepository) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).productID)
+}
+
+// invalidate drops the cached product and the searches a change to it in
+// the given categories may affect
+func (c *CachedRepository) invalidate(ctx context.Context, productID primitive.ObjectID, categories ...string) {
+	c.mu.Lock()
+	c.generation++
+	if element, ok := c.entries[productID]; ok {
+		c.remove(element)
+	}
+	c.mu.Unlock()
+
+	if c.searchEnabled {
+		if err := c.search.Invalidate(ctx, changeTags(productID, categories...)...); err != nil {
+			logging.Error(ctx, logger.Format{Message: "Failed to invalidate search cache", Data: map[string]string{"error": err.Error()}})
+		}
+	}
+}
+
+func (c *CachedRepository) purge(ctx context.Context) {
+	c.mu.Lock()
+	c.generation++
+	c.entries = map[primitive.ObjectID]*list.Element{}
+	c.order.Init()
+	c.mu.Unlock()
+
+	if c.searchEnabled {
+		if err := c.search.Purge(ctx); err != nil {
+			logging.Error(ctx, logger.Format{Message: "Failed to purge search cache", Data: map[string]string{"error": err.Error()}})
+		}
+	}
+}
+
+func (c *CachedRepository) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Enabled:   c.enabled,
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Entries:   entries,
+		Capacity:  c.size,
+	}
+}
+
+func (c *CachedRepository) SearchStats() SearchCacheStats {
+	return SearchCacheStats{
+		Enabled: c.searchEnabled,
+		Hits:    atomic.LoadUint64(&c.searchHits),
+		Misses:  atomic.LoadUint64(&c.searchMisses),
+		Entries: c.search.Len(),
+	}
+}
+
+// Run blocks until ctx is cancelled, invalidating the products named by the
+// broker's change events. If the broker drops the cache for falling behind,
+// the cache is purged and resubscribes. With both caches disabled it only
+// waits, so the worker still reports healthy.
+func (c *CachedRepository) Run(ctx context.Context) {
+	if !c.enabled && !c.searchEnabled {
+		<-ctx.Done()
+		return
+	}
+
+	for {
+		subscription := c.broker.Subscribe(cacheEventBuffer)
+		c.purge(ctx)
+		if !c.follow(ctx, subscription) {
+			subscription.Close()
+			return
+		}
+		logger.Error(logger.Format{Message: "Product cache fell behind change events, purging"})
+	}
+}
+
+// follow invalidates the products of the subscription's events until ctx is
+// cancelled, when it returns false, or the broker drops the subscription
+func (c *CachedRepository) follow(ctx context.Context, subscription *events.Subscription) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-subscription.Done:
+			return true
+		case event := <-subscription.C:
+			c.invalidate(ctx, event.ProductID, event.Product.Category)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package product
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/health"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProductCacheTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	broker     *events.Broker
+	cache      *CachedRepository
+	now        time.Time
+}
+
+func (pc *ProductCacheTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		pc.T().Fatalf("Failed to initialize config: %v", err)
+	}
+	logger.Init(cfg.Get().Log.Level)
+	pc.repository = new(MockRepository)
+	pc.broker = events.NewBroker()
+	pc.cache = newCachedRepository(cfg, pc.repository, NewMemorySearchCache(10), pc.broker)
+	pc.cache.enabled = true
+	pc.cache.size = 2
+	pc.cache.ttl = time.Minute
+	pc.now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pc.cache.now = func() time.Time { return pc.now }
+}
+
+func TestProductCacheSuite(t *testing.T) {
+	suite.Run(t, new(ProductCacheTestSuite))
+}
+
+func (pc *ProductCacheTestSuite) stored(name string) (primitive.ObjectID, *Product) {
+	product := &Product{ID: primitive.NewObjectID(), TenantID: tenant.Default(), Name: name}
+	pc.repository.On("GetProductByID", mock.Anything, product.ID).Return(product, nil)
+	return product.ID, product
+}
+
+func (pc *ProductCacheTestSuite) TestShouldServeRepeatedReadsFromCache() {
+	productID, _ := pc.stored("Titan Edge")
+
+	first, _ := pc.cache.GetProductByID(context.Background(), productID)
+	first.Name = "localized"
+	second, err := pc.cache.GetProductByID(context.Background(), productID)
+
+	assert.NoError(pc.T(), err)
+	assert.Equal(pc.T(), "Titan Edge", second.Name, "callers get their own copy")
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 1)
+	stats := pc.cache.Stats()
+	assert.Equal(pc.T(), uint64(1), stats.Hits)
+	assert.Equal(pc.T(), uint64(1), stats.Misses)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldNotServeCachedProductToAnotherTenant() {
+	product := &Product{ID: primitive.NewObjectID(), TenantID: tenant.Default(), Name: "Titan Edge"}
+	productID := product.ID
+	ofTenant := func(id string) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool { return tenant.ID(ctx) == id })
+	}
+	pc.repository.On("GetProductByID", ofTenant(tenant.Default()), productID).Return(product, nil)
+	pc.repository.On("GetProductByID", ofTenant("wholesale"), productID).Return(nil, types.NewNotFoundError("Product not found"))
+	otherTenant := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "wholesale"})
+
+	pc.cache.GetProductByID(context.Background(), productID)
+	product, err := pc.cache.GetProductByID(otherTenant, productID)
+
+	assert.Nil(pc.T(), product)
+	assert.Equal(pc.T(), types.NewNotFoundError("Product not found"), err)
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 2)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldExpireEntriesAfterTTL() {
+	productID, _ := pc.stored("Titan Edge")
+
+	pc.cache.GetProductByID(context.Background(), productID)
+	pc.now = pc.now.Add(time.Minute)
+	pc.cache.GetProductByID(context.Background(), productID)
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 2)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldEvictLeastRecentlyUsed() {
+	first, _ := pc.stored("first")
+	second, _ := pc.stored("second")
+	third, _ := pc.stored("third")
+
+	pc.cache.GetProductByID(context.Background(), first)
+	pc.cache.GetProductByID(context.Background(), second)
+	pc.cache.GetProductByID(context.Background(), first)
+	pc.cache.GetProductByID(context.Background(), third)
+	pc.cache.GetProductByID(context.Background(), first)
+	pc.cache.GetProductByID(context.Background(), second)
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 4)
+	assert.Equal(pc.T(), uint64(2), pc.cache.Stats().Evictions)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldInvalidateProductsWrittenByUpload() {
+	productID, _ := pc.stored("Titan Edge")
+	pc.repository.On("CreateProducts", mock.Anything, mock.Anything, CreateOptions{}).Return(&CreateProductsResult{
+		ProductIDs: []primitive.ObjectID{productID},
+		Products:   []Product{{ID: productID, Name: "Titan Edge", Category: "watch"}},
+	}, nil)
+
+	pc.cache.GetProductByID(context.Background(), productID)
+	pc.cache.CreateProducts(context.Background(), []Product{{Name: "Titan Edge"}}, CreateOptions{})
+	pc.cache.GetProductByID(context.Background(), productID)
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 2)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldInvalidateProductsNamedByChangeEvents() {
+	productID, _ := pc.stored("Titan Edge")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pc.cache.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.Eventually(pc.T(), func() bool {
+		pc.cache.GetProductByID(context.Background(), productID)
+		return pc.cache.Stats().Entries == 1
+	}, time.Second, 10*time.Millisecond, "cache is populated once Run has subscribed")
+	calls := len(pc.repository.Calls)
+
+	pc.broker.Publish(context.Background(), events.Event{ProductID: productID})
+
+	assert.Eventually(pc.T(), func() bool { return pc.cache.Stats().Entries == 0 }, time.Second, 10*time.Millisecond)
+	pc.cache.GetProductByID(context.Background(), productID)
+	assert.Len(pc.T(), pc.repository.Calls, calls+1)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldKeepWorkerHealthyWithBothCachesDisabled() {
+	pc.cache.enabled = false
+	pc.cache.searchEnabled = false
+	running := &health.Running{}
+	running.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer running.Stop()
+		pc.cache.Run(ctx)
+	}()
+
+	assert.Never(pc.T(), func() bool { return running.Check(context.Background()) != nil }, 100*time.Millisecond, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Error(pc.T(), running.Check(context.Background()))
+}
+
+func (pc *ProductCacheTestSuite) TestShouldCollapseConcurrentMisses() {
+	product := &Product{ID: primitive.NewObjectID(), Name: "Titan Edge"}
+	release := make(chan time.Time)
+	pc.repository.On("GetProductByID", mock.Anything, product.ID).WaitUntil(release).Return(product, nil)
+
+	var readers sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			pc.cache.GetProductByID(context.Background(), product.ID)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	readers.Wait()
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "GetProductByID", 1)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldShareSearchEntryAcrossEquivalentParams() {
+	pc.cache.searchEnabled = true
+	watch := Product{ID: primitive.NewObjectID(), Name: "Titan Edge", Category: "watch"}
+	pc.repository.On("SearchProducts", mock.Anything, mock.Anything).Return([]Product{watch}, nil)
+
+	pc.cache.SearchProducts(context.Background(), SearchParams{Categories: []string{"watch", "shoes"}, Brands: []string{"titan"}, Limit: 20})
+	products, err := pc.cache.SearchProducts(context.Background(), SearchParams{Categories: []string{"shoes", "watch", "shoes"}, Brands: []string{"titan"}, Limit: 20})
+
+	assert.NoError(pc.T(), err)
+	assert.Equal(pc.T(), []Product{watch}, products)
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 1)
+	assert.Equal(pc.T(), uint64(1), pc.cache.SearchStats().Hits)
+
+	pc.cache.SearchProducts(context.Background(), SearchParams{Categories: []string{"watch", "shoes"}, Brands: []string{"titan"}, Limit: 10})
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 2)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldInvalidateSearchesOfChangedCategory() {
+	pc.cache.searchEnabled = true
+	watch := Product{ID: primitive.NewObjectID(), Name: "Titan Edge", Category: "watch"}
+	pc.repository.On("SearchProducts", mock.Anything, mock.Anything).Return([]Product{watch}, nil)
+	watches := SearchParams{Categories: []string{"watch"}}
+	shoes := SearchParams{Categories: []string{"shoes"}}
+
+	pc.cache.SearchProducts(context.Background(), watches)
+	pc.cache.SearchProducts(context.Background(), shoes)
+	pc.cache.invalidate(context.Background(), primitive.NewObjectID(), "shoes")
+	pc.cache.SearchProducts(context.Background(), watches)
+	pc.cache.SearchProducts(context.Background(), shoes)
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 3)
+
+	// A product leaving the category invalidates the searches it appeared in
+	pc.cache.invalidate(context.Background(), watch.ID, "shoes")
+	pc.cache.SearchProducts(context.Background(), watches)
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 4)
+}
+
+// racingSearchCache runs onSet while a write to the backend is in flight
+type racingSearchCache struct {
+	*MemorySearchCache
+	onSet func()
+}
+
+func (r *racingSearchCache) Set(ctx context.Context, key string, products []Product, tags []string, ttl time.Duration) error {
+	if r.onSet != nil {
+		onSet := r.onSet
+		r.onSet = nil
+		onSet()
+	}
+	return r.MemorySearchCache.Set(ctx, key, products, tags, ttl)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldDropSearchWrittenWhileInvalidated() {
+	search := &racingSearchCache{MemorySearchCache: NewMemorySearchCache(10)}
+	pc.cache.search = search
+	pc.cache.searchEnabled = true
+	watch := Product{ID: primitive.NewObjectID(), Name: "Titan Edge", Category: "watch"}
+	pc.repository.On("SearchProducts", mock.Anything, mock.Anything).Return([]Product{watch}, nil)
+	params := SearchParams{Categories: []string{"watch"}}
+	// The invalidation reaches the backend before the write it overtook
+	search.onSet = func() { pc.cache.invalidate(context.Background(), watch.ID, "watch") }
+
+	pc.cache.SearchProducts(context.Background(), params)
+	pc.cache.SearchProducts(context.Background(), params)
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 2)
+}
+
+func (pc *ProductCacheTestSuite) TestShouldNotCacheSearchesMatchedInMemory() {
+	pc.cache.searchEnabled = true
+	pc.repository.On("SearchProducts", mock.Anything, mock.Anything).Return([]Product{}, nil)
+	params := SearchParams{Categories: []string{"watch"}, Match: func(Product) bool { return true }}
+
+	pc.cache.SearchProducts(context.Background(), params)
+	pc.cache.SearchProducts(context.Background(), params)
+
+	pc.repository.AssertNumberOfCalls(pc.T(), "SearchProducts", 2)
+}
@@ -0,0 +1,84 @@
+package product
+
+import (
+	"reflect"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fieldValue struct {
+	name  string
+	value interface{}
+}
+
+// storedFields lists the fields a product write can change, by their
+// document names
+func storedFields(product Product) []fieldValue {
+	return []fieldValue{
+		{"name", product.Name},
+		{"category", product.Category},
+		{"brand", product.Brand},
+		{"price", product.Price},
+		{"description", product.Description},
+		{"images", product.Images},
+		{"availableQty", product.Inventory},
+		{"popularity", product.Popularity},
+		{"currencyPrices", product.CurrencyPrices},
+		{"translations", product.Translations},
+	}
+}
+
+// changedFields lists the stored fields that differ between two versions of
+// a product, by their document names
+func changedFields(before, after Product) []string {
+	changed := []string{}
+	afterFields := storedFields(after)
+	for i, field := range storedFields(before) {
+		if !reflect.DeepEqual(field.value, afterFields[i].value) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// auditChanges lists the field-level changes between two versions of a
+// product; a nil version stands for a product that does not exist
+func auditChanges(before, after *Product) []audit.Change {
+	values := func(product *Product) map[string]interface{} {
+		if product == nil {
+			return nil
+		}
+		fields := map[string]interface{}{}
+		for _, field := range storedFields(*product) {
+			fields[field.name] = field.value
+		}
+		return fields
+	}
+
+	names := []string{}
+	for _, field := range storedFields(Product{}) {
+		names = append(names, field.name)
+	}
+	productID := primitive.NilObjectID
+	if after != nil {
+		productID = after.ID
+	} else if before != nil {
+		productID = before.ID
+	}
+	return audit.Diff(productID, names, values(before), values(after))
+}
+
+func snapshot(product Product) events.Snapshot {
+	return events.Snapshot{
+		ID:        product.ID,
+		TenantID:  product.TenantID,
+		Name:      product.Name,
+		Category:  product.Category,
+		Brand:     product.Brand,
+		Price:     product.Price,
+		Inventory: product.Inventory,
+		Version:   product.Version,
+	}
+}
@@ -1,11 +1,19 @@
 package product
 
 import (
-	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/locale"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
 	"github.com/roppenlabs/rapid-product-catalog/internal/types"
 	logger "github.com/roppenlabs/rapido-logger-go"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,116 +21,194 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultMaxProductsPerRequest = 5000
+	defaultMaxStringLength       = 2048
+	defaultMaxDescriptionLength  = 10000
+	defaultMaxImages             = 20
+)
+
 type Handler struct {
 	service Service
+	limits  config.ProductLimitsConfig
 }
 
-func NewHandler(s Service) *Handler {
+func NewHandler(cfg config.Config, s Service) *Handler {
+	limits := cfg.Get().Limits.Products
+	if limits.MaxPerRequest <= 0 {
+		limits.MaxPerRequest = defaultMaxProductsPerRequest
+	}
+	if limits.MaxStringLength <= 0 {
+		limits.MaxStringLength = defaultMaxStringLength
+	}
+	if limits.MaxDescriptionLength <= 0 {
+		limits.MaxDescriptionLength = defaultMaxDescriptionLength
+	}
+	if limits.MaxImages <= 0 {
+		limits.MaxImages = defaultMaxImages
+	}
 	return &Handler{
 		service: s,
+		limits:  limits,
 	}
 }
 
 func (h *Handler) CreateProductsHandler(ctx *gin.Context) {
 	var req BulkCreateProductsRequest
 
-	logger.Info(logger.Format{Message: "Request received for bulk create products", Data: map[string]string{"request": fmt.Sprintf("%+v", req)}})
-
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		logger.Error(logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
-		ctx.JSON(http.StatusBadRequest, buildErrorResponse(types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
 		return
 	}
+	logging.Info(ctx.Request.Context(), logger.Format{
+		Message: "Request received for bulk create products",
+		Data: map[string]string{
+			"products": strconv.Itoa(len(req.Products)),
+			"atomic":   strconv.FormatBool(req.Atomic),
+		},
+	})
 
 	if len(req.Products) == 0 {
-		logger.Error(logger.Format{Message: "Products array is empty"})
-		ctx.JSON(http.StatusBadRequest, buildErrorResponse(types.NewValidationError("Products array cannot be empty")))
+		logging.Error(ctx.Request.Context(), logger.Format{Message: "Products array is empty"})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Products array cannot be empty")))
 		return
 	}
 
-	if validationErr := validateProducts(req.Products); validationErr != nil {
-		logger.Error(logger.Format{Message: validationErr.Error()})
-		ctx.JSON(http.StatusBadRequest, buildErrorResponse(validationErr))
+	if len(req.Products) > h.limits.MaxPerRequest {
+		statusError := types.NewBatchTooLargeError(fmt.Sprintf("Bulk uploads are limited to %d products, got %d", h.limits.MaxPerRequest, len(req.Products)))
+		logging.Error(ctx.Request.Context(), logger.Format{Message: statusError.Message})
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
 		return
 	}
 
-	response, err := h.service.BulkCreateProducts(context.Background(), req.Products)
+	if validationErr := validateProducts(req.Products, h.limits); validationErr != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: validationErr.Error()})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, validationErr))
+		return
+	}
+
+	response, err := h.service.BulkCreateProducts(ctx.Request.Context(), req.Products, CreateOptions{Atomic: req.Atomic})
 
 	if err != nil {
 		statusError, ok := err.(*types.StatusError)
 		if !ok {
 			serverError := types.NewInternalServerError()
-			ctx.JSON(http.StatusInternalServerError, buildErrorResponse(serverError))
+			ctx.JSON(http.StatusInternalServerError, requestid.ErrorResponse(ctx, serverError))
 			return
 		}
-		ctx.JSON(statusError.HTTPCode, buildErrorResponse(statusError))
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
 		return
 	}
-	logger.Info(logger.Format{Message: "Response for bulk create products", Data: map[string]string{"response": fmt.Sprintf("%+v", response)}})
+	logging.Info(ctx.Request.Context(), logger.Format{
+		Message: "Response for bulk create products",
+		Data: map[string]string{
+			"created": strconv.Itoa(response.Created),
+			"updated": strconv.Itoa(response.Updated),
+		},
+	})
 	ctx.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) SearchProductsHandler(ctx *gin.Context) {
 	var req SearchProductsRequest
 
-	logger.Info(logger.Format{Message: "Request received for search products", Data: map[string]string{"request": fmt.Sprintf("%+v", req)}})
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Request received for search products"})
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		logger.Error(logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
-		ctx.JSON(http.StatusBadRequest, buildErrorResponse(types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
 		return
 	}
 
+	if req.PriceList == "" {
+		req.PriceList = ctx.GetHeader(pricelist.Header)
+	}
+
 	// Normalize request to internal format
 	searchParams := normalizeSearchRequest(req)
+	searchParams.Locales = locale.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
 
-	response, err := h.service.SearchProducts(context.Background(), searchParams)
+	response, err := h.service.SearchProducts(ctx.Request.Context(), searchParams)
 
 	if err != nil {
 		statusError, ok := err.(*types.StatusError)
 		if !ok {
 			serverError := types.NewInternalServerError()
-			ctx.JSON(http.StatusInternalServerError, buildErrorResponse(serverError))
+			ctx.JSON(http.StatusInternalServerError, requestid.ErrorResponse(ctx, serverError))
 			return
 		}
-		ctx.JSON(statusError.HTTPCode, buildErrorResponse(statusError))
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
 		return
 	}
 
-	logger.Info(logger.Format{Message: "Response for search products", Data: map[string]string{"response": fmt.Sprintf("%+v", response)}})
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Response for search products", Data: map[string]string{"count": strconv.Itoa(response.Count)}})
 	ctx.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) GetProductByIDHandler(ctx *gin.Context) {
 	productIDParam := ctx.Param("productId")
 
-	logger.Info(logger.Format{Message: "Request received for get product by ID", Data: map[string]string{"productId": productIDParam}})
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Request received for get product by ID", Data: map[string]string{"productId": productIDParam}})
 
 	productID, err := primitive.ObjectIDFromHex(productIDParam)
 	if err != nil {
-		logger.Error(logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
-		ctx.JSON(http.StatusBadRequest, buildErrorResponse(types.NewValidationError("Invalid product ID format")))
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
 		return
 	}
 
-	product, err := h.service.GetProductByID(context.Background(), productID)
+	priceList := ctx.Query("priceList")
+	if priceList == "" {
+		priceList = ctx.GetHeader(pricelist.Header)
+	}
+	view := ViewOptions{
+		Currency:  requestedCurrency(ctx.Query("currency")),
+		PriceList: pricelist.NormalizeCode(priceList),
+		Locales:   locale.ParseAcceptLanguage(ctx.GetHeader("Accept-Language")),
+	}
+	product, err := h.service.GetProductByID(ctx.Request.Context(), productID, view)
 	if err != nil {
 		statusError, ok := err.(*types.StatusError)
 		if !ok {
 			serverError := types.NewInternalServerError()
-			ctx.JSON(http.StatusInternalServerError, buildErrorResponse(serverError))
+			ctx.JSON(http.StatusInternalServerError, requestid.ErrorResponse(ctx, serverError))
 			return
 		}
-		ctx.JSON(statusError.HTTPCode, buildErrorResponse(statusError))
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
 		return
 	}
 
-	logger.Info(logger.Format{Message: "Response for get product by ID", Data: map[string]string{"response": fmt.Sprintf("%+v", product)}})
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Response for get product by ID", Data: map[string]string{"productId": productIDParam}})
 	ctx.JSON(http.StatusOK, product)
 }
 
-func validateProducts(products []Product) *types.StatusError {
+func (h *Handler) DeleteProductHandler(ctx *gin.Context) {
+	productIDParam := ctx.Param("productId")
+
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Request received for delete product", Data: map[string]string{"productId": productIDParam}})
+
+	productID, err := primitive.ObjectIDFromHex(productIDParam)
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid product ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid product ID format")))
+		return
+	}
+
+	if err := h.service.DeleteProduct(ctx.Request.Context(), productID); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func validateProducts(products []Product, limits config.ProductLimitsConfig) *types.StatusError {
 	for i, product := range products {
+		if err := checkLengths(product, i, limits); err != nil {
+			return err
+		}
 		if strings.TrimSpace(product.Name) == "" {
 			return types.NewValidationError(fmt.Sprintf("Product at index %d: name cannot be empty", i))
 		}
@@ -132,18 +218,116 @@ func validateProducts(products []Product) *types.StatusError {
 		if strings.TrimSpace(product.Brand) == "" {
 			return types.NewValidationError(fmt.Sprintf("Product at index %d: brand cannot be empty", i))
 		}
-		if product.Price <= 0 {
+		if product.Price.Amount <= 0 {
 			return types.NewValidationError(fmt.Sprintf("Product at index %d: price must be greater than 0", i))
 		}
+		if product.Price.Currency != money.DefaultCurrency() {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: price must be in %s, use currencyPrices for other currencies", i, money.DefaultCurrency()))
+		}
+		seen := map[string]bool{}
+		for _, currencyPrice := range product.CurrencyPrices {
+			if !money.IsValidCurrency(currencyPrice.Currency) || currencyPrice.Currency == money.DefaultCurrency() {
+				return types.NewValidationError(fmt.Sprintf("Product at index %d: invalid currency price currency %q", i, currencyPrice.Currency))
+			}
+			if currencyPrice.Amount <= 0 {
+				return types.NewValidationError(fmt.Sprintf("Product at index %d: %s price must be greater than 0", i, currencyPrice.Currency))
+			}
+			if seen[currencyPrice.Currency] {
+				return types.NewValidationError(fmt.Sprintf("Product at index %d: duplicate %s price", i, currencyPrice.Currency))
+			}
+			seen[currencyPrice.Currency] = true
+		}
+		if err := normalizeTranslations(&products[i], i); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// lengthCheck is a string of an uploaded product and the characters it may
+// hold
+type lengthCheck struct {
+	field string
+	value string
+	limit int
+}
+
+// checkLengths refuses a product whose strings or images exceed limits
+func checkLengths(product Product, index int, limits config.ProductLimitsConfig) *types.StatusError {
+	if len(product.Images) > limits.MaxImages {
+		return types.NewValidationError(fmt.Sprintf("Product at index %d: images are limited to %d, got %d", index, limits.MaxImages, len(product.Images)))
+	}
+
+	checks := []lengthCheck{
+		{"name", product.Name, limits.MaxStringLength},
+		{"category", product.Category, limits.MaxStringLength},
+		{"brand", product.Brand, limits.MaxStringLength},
+		{"description", product.Description, limits.MaxDescriptionLength},
+	}
+	for i, image := range product.Images {
+		checks = append(checks, lengthCheck{fmt.Sprintf("image %d", i), image, limits.MaxStringLength})
+	}
+	tags := make([]string, 0, len(product.Translations))
+	for tag := range product.Translations {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		checks = append(checks,
+			lengthCheck{tag + " name", product.Translations[tag].Name, limits.MaxStringLength},
+			lengthCheck{tag + " description", product.Translations[tag].Description, limits.MaxDescriptionLength},
+		)
+	}
+	for _, check := range checks {
+		if length := utf8.RuneCountInString(check.value); length > check.limit {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: %s is limited to %d characters, got %d", index, check.field, check.limit, length))
+		}
+	}
+	return nil
+}
+
+// normalizeTranslations validates the translations of product and rewrites
+// their keys to canonical locale tags
+func normalizeTranslations(product *Product, index int) *types.StatusError {
+	if len(product.Translations) == 0 {
+		return nil
+	}
+
+	translations := make(map[string]Translation, len(product.Translations))
+	for tag, translation := range product.Translations {
+		normalized, ok := locale.Normalize(tag)
+		if !ok {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: invalid translation locale %q", index, tag))
+		}
+		if normalized == locale.Default() {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: %s content belongs in name and description", index, normalized))
+		}
+		if _, exists := translations[normalized]; exists {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: duplicate %s translation", index, normalized))
+		}
+		translation.Name = strings.TrimSpace(translation.Name)
+		translation.Description = strings.TrimSpace(translation.Description)
+		if translation.Name == "" && translation.Description == "" {
+			return types.NewValidationError(fmt.Sprintf("Product at index %d: %s translation is empty", index, normalized))
+		}
+		translations[normalized] = translation
+	}
+
+	product.Translations = translations
+	return nil
+}
+
 func normalizeSearchRequest(req SearchProductsRequest) SearchParams {
 	params := SearchParams{
+		ViewOptions: ViewOptions{
+			Currency:  requestedCurrency(req.Currency),
+			PriceList: pricelist.NormalizeCode(req.PriceList),
+		},
 		Categories: []string{},
 		Brands:     []string{},
+		PriceBasis: PriceBasisList,
 		SearchText: req.Search,
+		Sort:       SortPopularity,
 		Limit:      15,
 	}
 
@@ -183,25 +367,53 @@ func normalizeSearchRequest(req SearchProductsRequest) SearchParams {
 		}
 	}
 
+	if req.Sort != "" {
+		params.Sort = req.Sort
+	}
+
 	// Extract price range
 	if req.PriceRange != nil {
 		if req.PriceRange.Min > 0 {
-			params.MinPrice = &req.PriceRange.Min
+			minPrice := money.FromMajor(req.PriceRange.Min, params.Currency)
+			params.MinPrice = &minPrice
 		}
 		if req.PriceRange.Max > 0 {
-			params.MaxPrice = &req.PriceRange.Max
+			maxPrice := money.FromMajor(req.PriceRange.Max, params.Currency)
+			params.MaxPrice = &maxPrice
+		}
+		if req.PriceRange.Basis != "" {
+			params.PriceBasis = req.PriceRange.Basis
 		}
 	}
 
 	return params
 }
 
-func buildErrorResponse(err *types.StatusError) types.ErrorResponse {
-	return types.ErrorResponse{
-		Error: types.Error{
-			Message: err.Message,
-			Code:    err.Code,
-			Status:  "error",
-		},
+// requestedCurrency normalizes a caller supplied currency code, defaulting to
+// the catalog's base currency
+func requestedCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return money.DefaultCurrency()
 	}
+	return currency
+}
+
+// CacheHandler reports the effectiveness of the product caches
+type CacheHandler struct {
+	products *CachedRepository
+}
+
+func NewCacheHandler(products *CachedRepository) *CacheHandler {
+	return &CacheHandler{
+		products: products,
+	}
+}
+
+func (h *CacheHandler) GetStatsHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, CacheStatsResponse{
+		Success:  true,
+		Products: h.products.Stats(),
+		Search:   h.products.SearchStats(),
+	})
 }
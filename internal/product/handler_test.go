@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
 	"github.com/roppenlabs/rapid-product-catalog/internal/types"
 	logger "github.com/roppenlabs/rapido-logger-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
 	"github.com/stretchr/testify/suite"
@@ -22,18 +26,26 @@ type ProductUploadHandlerTestSuite struct {
 	validate *validator.Validate
 	server   *testutils.TestServer
 	handler  *Handler
+	config   *config.Values
 }
 
 func (mph *ProductUploadHandlerTestSuite) SetupTest() {
 	mph.service = new(MockService)
 	mph.server = testutils.NewServer()
-	mph.handler = NewHandler(mph.service)
+	mph.config = &config.Values{Limits: config.LimitsConfig{Products: config.ProductLimitsConfig{
+		MaxPerRequest:        3,
+		MaxStringLength:      40,
+		MaxDescriptionLength: 80,
+		MaxImages:            2,
+	}}}
+	mph.handler = NewHandler(mph.config, mph.service)
 
 	// Register product routes directly
 	router := mph.server.Router()
 	router.POST("/products/bulk", mph.handler.CreateProductsHandler)
 	router.POST("/products/search", mph.handler.SearchProductsHandler)
 	router.GET("/products/:productId", mph.handler.GetProductByIDHandler)
+	router.DELETE("/products/:productId", mph.handler.DeleteProductHandler)
 
 	logger.Init("debug")
 }
@@ -44,7 +56,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnSuccessWithValidInput(
 			Name:        "Titan Edge 1",
 			Category:    "watch",
 			Brand:       "titan",
-			Price:       12999,
+			Price:       money.New(1299900, "INR"),
 			Description: "Titan Edge Slim Series",
 			Images:      []string{"https://cdn.example.com/titan1.png"},
 			Inventory:   20,
@@ -58,7 +70,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnSuccessWithValidInput(
 		Updated: 0,
 	}
 
-	mph.service.On("BulkCreateProducts", mock.Anything, products).Return(expectedResponse, nil)
+	mph.service.On("BulkCreateProducts", mock.Anything, products, CreateOptions{}).Return(expectedResponse, nil)
 
 	requestBodyBytes, _ := json.Marshal(requestBody)
 	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
@@ -88,7 +100,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnErrorWhenProductsArray
 
 	assert.Equal(mph.T(), http.StatusBadRequest, mph.server.Recorder().Code)
 	assert.Equal(mph.T(), expectedResponse.Error.Message, actualResponse.Error.Message)
-	mph.service.AssertNotCalled(mph.T(), "BulkCreateProducts", mock.Anything, mock.Anything)
+	mph.service.AssertNotCalled(mph.T(), "BulkCreateProducts", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (mph *ProductUploadHandlerTestSuite) TestShouldReturnErrorWhenExpectedErrorIsThrown() {
@@ -97,7 +109,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnErrorWhenExpectedError
 			Name:        "Titan Edge 1",
 			Category:    "watch",
 			Brand:       "titan",
-			Price:       12999,
+			Price:       money.New(1299900, "INR"),
 			Description: "Titan Edge Slim Series",
 			Images:      []string{"https://cdn.example.com/titan1.png"},
 			Inventory:   20,
@@ -113,7 +125,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnErrorWhenExpectedError
 		},
 	}
 
-	mph.service.On("BulkCreateProducts", mock.Anything, products).Return(CreateProductsResponse{}, types.NewValidationError("Validation failed"))
+	mph.service.On("BulkCreateProducts", mock.Anything, products, CreateOptions{}).Return(CreateProductsResponse{}, types.NewValidationError("Validation failed"))
 
 	requestBodyBytes, _ := json.Marshal(requestBody)
 	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
@@ -130,7 +142,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnServerErrorWhenRandomE
 			Name:        "Titan Edge 1",
 			Category:    "watch",
 			Brand:       "titan",
-			Price:       12999,
+			Price:       money.New(1299900, "INR"),
 			Description: "Titan Edge Slim Series",
 			Images:      []string{"https://cdn.example.com/titan1.png"},
 			Inventory:   20,
@@ -146,7 +158,7 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnServerErrorWhenRandomE
 		},
 	}
 
-	mph.service.On("BulkCreateProducts", mock.Anything, products).Return(CreateProductsResponse{}, errors.New("random error"))
+	mph.service.On("BulkCreateProducts", mock.Anything, products, CreateOptions{}).Return(CreateProductsResponse{}, errors.New("random error"))
 
 	requestBodyBytes, _ := json.Marshal(requestBody)
 	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
@@ -160,3 +172,161 @@ func (mph *ProductUploadHandlerTestSuite) TestShouldReturnServerErrorWhenRandomE
 func TestProductUploadHandlerTest(t *testing.T) {
 	suite.Run(t, new(ProductUploadHandlerTestSuite))
 }
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldNormalizeTranslationLocales() {
+	product := Product{
+		Name:         "Titan Edge 1",
+		Category:     "watch",
+		Brand:        "titan",
+		Price:        money.New(1299900, "INR"),
+		Description:  "Titan Edge Slim Series",
+		Images:       []string{"https://cdn.example.com/titan1.png"},
+		Inventory:    20,
+		Translations: map[string]Translation{"hi_in": {Name: " टाइटन एज 1 "}},
+	}
+	expected := product
+	expected.Translations = map[string]Translation{"hi-IN": {Name: "टाइटन एज 1"}}
+
+	mph.service.On("BulkCreateProducts", mock.Anything, []Product{expected}, CreateOptions{}).Return(CreateProductsResponse{Success: true}, nil)
+
+	requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product}})
+	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+
+	assert.Equal(mph.T(), http.StatusOK, mph.server.Recorder().Code)
+	mph.service.AssertExpectations(mph.T())
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldPassAtomicModeToService() {
+	product := Product{
+		Name:        "Titan Edge 1",
+		Category:    "watch",
+		Brand:       "titan",
+		Price:       money.New(1299900, "INR"),
+		Description: "Titan Edge Slim Series",
+		Images:      []string{"https://cdn.example.com/titan1.png"},
+		Inventory:   20,
+	}
+
+	mph.service.On("BulkCreateProducts", mock.Anything, []Product{product}, CreateOptions{Atomic: true}).Return(CreateProductsResponse{Success: true}, nil)
+
+	requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product}, Atomic: true})
+	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+
+	assert.Equal(mph.T(), http.StatusOK, mph.server.Recorder().Code)
+	mph.service.AssertExpectations(mph.T())
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldRejectTranslationForDefaultLocale() {
+	product := Product{
+		Name:         "Titan Edge 1",
+		Category:     "watch",
+		Brand:        "titan",
+		Price:        money.New(1299900, "INR"),
+		Description:  "Titan Edge Slim Series",
+		Images:       []string{"https://cdn.example.com/titan1.png"},
+		Inventory:    20,
+		Translations: map[string]Translation{"EN": {Name: "Titan Edge One"}},
+	}
+
+	requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product}})
+	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+	var actualResponse types.ErrorResponse
+	json.NewDecoder(mph.server.Recorder().Body).Decode(&actualResponse)
+
+	assert.Equal(mph.T(), http.StatusBadRequest, mph.server.Recorder().Code)
+	assert.Equal(mph.T(), "Product at index 0: en content belongs in name and description", actualResponse.Error.Message)
+	mph.service.AssertNotCalled(mph.T(), "BulkCreateProducts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldRefuseMoreProductsThanOneRequestMayCarry() {
+	product := Product{
+		Name:        "Titan Edge 1",
+		Category:    "watch",
+		Brand:       "titan",
+		Price:       money.New(1299900, "INR"),
+		Description: "Titan Edge Slim Series",
+		Images:      []string{"https://cdn.example.com/titan1.png"},
+		Inventory:   20,
+	}
+
+	requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product, product, product, product}})
+	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+	var actualResponse types.ErrorResponse
+	json.NewDecoder(mph.server.Recorder().Body).Decode(&actualResponse)
+
+	assert.Equal(mph.T(), http.StatusRequestEntityTooLarge, mph.server.Recorder().Code)
+	assert.Equal(mph.T(), "batch_too_large", actualResponse.Error.Code)
+	assert.Equal(mph.T(), "Bulk uploads are limited to 3 products, got 4", actualResponse.Error.Message)
+	mph.service.AssertNotCalled(mph.T(), "BulkCreateProducts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldRefuseProductsOverLengthLimits() {
+	tests := map[string]func(*Product){
+		"Product at index 0: name is limited to 40 characters, got 41":        func(p *Product) { p.Name = strings.Repeat("n", 41) },
+		"Product at index 0: brand is limited to 40 characters, got 41":       func(p *Product) { p.Brand = strings.Repeat("b", 41) },
+		"Product at index 0: description is limited to 80 characters, got 81": func(p *Product) { p.Description = strings.Repeat("d", 81) },
+		"Product at index 0: image 1 is limited to 40 characters, got 41":     func(p *Product) { p.Images = []string{"a.png", strings.Repeat("i", 41)} },
+		"Product at index 0: images are limited to 2, got 3":                  func(p *Product) { p.Images = []string{"a.png", "b.png", "c.png"} },
+		"Product at index 0: fr description is limited to 80 characters, got 81": func(p *Product) {
+			p.Translations = map[string]Translation{"fr": {Description: strings.Repeat("é", 81)}}
+		},
+	}
+	for expected, modify := range tests {
+		mph.SetupTest()
+		product := Product{
+			Name:        "Titan Edge 1",
+			Category:    "watch",
+			Brand:       "titan",
+			Price:       money.New(1299900, "INR"),
+			Description: "Titan Edge Slim Series",
+			Images:      []string{"a.png"},
+			Inventory:   20,
+		}
+		modify(&product)
+
+		requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product}})
+		mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+		var actualResponse types.ErrorResponse
+		json.NewDecoder(mph.server.Recorder().Body).Decode(&actualResponse)
+
+		assert.Equal(mph.T(), http.StatusBadRequest, mph.server.Recorder().Code, expected)
+		assert.Equal(mph.T(), expected, actualResponse.Error.Message)
+		mph.service.AssertNotCalled(mph.T(), "BulkCreateProducts", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldCountLengthsInCharacters() {
+	product := Product{
+		Name:        "घड़ी टाइटन एज स्लिम",
+		Category:    "watch",
+		Brand:       "titan",
+		Price:       money.New(1299900, "INR"),
+		Description: "Titan Edge Slim Series",
+		Images:      []string{"a.png"},
+		Inventory:   20,
+	}
+	mph.service.On("BulkCreateProducts", mock.Anything, mock.Anything, mock.Anything).Return(CreateProductsResponse{Success: true, Created: 1}, nil)
+
+	requestBodyBytes, _ := json.Marshal(BulkCreateProductsRequest{Products: []Product{product}})
+	mph.server.PerformRequest("/products/bulk", "post", requestBodyBytes)
+
+	assert.Equal(mph.T(), http.StatusOK, mph.server.Recorder().Code)
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldDeleteProduct() {
+	productID := primitive.NewObjectID()
+	mph.service.On("DeleteProduct", mock.Anything, productID).Return(nil)
+
+	mph.server.PerformRequest("/products/"+productID.Hex(), "delete", nil)
+
+	assert.Equal(mph.T(), http.StatusNoContent, mph.server.Recorder().Code)
+}
+
+func (mph *ProductUploadHandlerTestSuite) TestShouldReturnNotFoundWhenDeletingMissingProduct() {
+	productID := primitive.NewObjectID()
+	mph.service.On("DeleteProduct", mock.Anything, productID).Return(types.NewNotFoundError("Product not found"))
+
+	mph.server.PerformRequest("/products/"+productID.Hex(), "delete", nil)
+
+	assert.Equal(mph.T(), http.StatusNotFound, mph.server.Recorder().Code)
+}
@@ -0,0 +1,35 @@
+package product
+
+import (
+	"github.com/roppenlabs/rapid-product-catalog/internal/locale"
+)
+
+// localize replaces the name and description of product with the first
+// translation in chain that has them, keeping the default locale content
+// otherwise. Locale reports the locale the name was taken from.
+func localize(product *Product, chain []string) {
+	product.Locale = locale.Default()
+
+	name, description := "", ""
+	for _, tag := range chain {
+		translation, ok := product.Translations[tag]
+		if !ok {
+			continue
+		}
+		if name == "" && translation.Name != "" {
+			name = translation.Name
+			product.Locale = tag
+		}
+		if description == "" && translation.Description != "" {
+			description = translation.Description
+		}
+	}
+
+	if name != "" {
+		product.Name = name
+	}
+	if description != "" {
+		product.Description = description
+	}
+	product.Translations = nil
+}
@@ -0,0 +1,330 @@
+package product
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryRepository keeps products in process for local development and
+// tests. It follows the Mongo repository where the conformance suite checks
+// them, but search text is a Go regular expression rather than a Mongo
+// $regex, and its writes are audited but record no change events, since the
+// outbox lives in Mongo.
+type memoryRepository struct {
+	mu sync.RWMutex
+	// products is in insertion order, which breaks sort ties the way the
+	// natural order does in Mongo
+	products []*Product
+}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepository{}
+}
+
+// CreateProducts applies the whole upload under the lock and checks it before
+// writing anything, so every upload is atomic whatever opts asks for
+func (r *memoryRepository) CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error) {
+	if len(products) == 0 {
+		return &CreateProductsResult{
+			Created:    0,
+			Updated:    0,
+			ProductIDs: []primitive.ObjectID{},
+		}, nil
+	}
+
+	tenantID := tenant.ID(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := map[primitive.ObjectID]Product{}
+	existing := []Product{}
+	for _, product := range products {
+		if stored := r.findByKey(tenantID, product.Name, product.Category); stored != nil {
+			previous[stored.ID] = copyProduct(*stored)
+			existing = append(existing, *stored)
+		}
+	}
+	if err := checkUploadPermissions(ctx, products, existing); err != nil {
+		return nil, err
+	}
+	if t, ok := tenant.FromContext(ctx); ok {
+		if err := checkProductLimit(t, r.count(tenantID), addedProducts(products, existing)); err != nil {
+			return nil, err
+		}
+	}
+
+	created, updated := 0, 0
+	touched := map[primitive.ObjectID]bool{}
+	for _, product := range products {
+		stored := r.findByKey(tenantID, product.Name, product.Category)
+		if stored == nil {
+			stored = &Product{ID: primitive.NewObjectID(), TenantID: tenantID}
+			r.products = append(r.products, stored)
+			created++
+		} else {
+			updated++
+		}
+
+		stored.Name = product.Name
+		stored.Category = product.Category
+		stored.Brand = product.Brand
+		stored.Price = product.Price
+		stored.Description = product.Description
+		stored.Images = copyProduct(product).Images
+		stored.Inventory = product.Inventory
+		stored.Popularity = product.Popularity
+		stored.BasePrice = product.Price
+		stored.CurrencyPrices = copyProduct(product).CurrencyPrices
+		stored.Translations = copyProduct(product).Translations
+		stored.Version++
+		touched[stored.ID] = true
+	}
+
+	result := &CreateProductsResult{
+		Created:    created,
+		Updated:    updated,
+		ProductIDs: []primitive.ObjectID{},
+		Products:   []Product{},
+		Previous:   previous,
+	}
+	for _, stored := range r.products {
+		if !touched[stored.ID] {
+			continue
+		}
+		product := copyProduct(*stored)
+		result.ProductIDs = append(result.ProductIDs, product.ID)
+		result.Products = append(result.Products, product)
+
+		if before, existed := previous[product.ID]; existed {
+			audit.Record(ctx, auditChanges(&before, &product)...)
+		} else {
+			audit.Record(ctx, auditChanges(nil, &product)...)
+		}
+	}
+
+	return result, nil
+}
+
+// findByKey must be called with mu held
+func (r *memoryRepository) findByKey(tenantID, name, category string) *Product {
+	for _, product := range r.products {
+		if product.TenantID == tenantID && product.Name == name && product.Category == category {
+			return product
+		}
+	}
+	return nil
+}
+
+// count must be called with mu held
+func (r *memoryRepository) count(tenantID string) int {
+	count := 0
+	for _, product := range r.products {
+		if product.TenantID == tenantID {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *memoryRepository) SearchProducts(ctx context.Context, params SearchParams) ([]Product, error) {
+	var text *regexp.Regexp
+	if params.SearchText != "" {
+		compiled, err := regexp.Compile("(?i)" + params.SearchText)
+		if err != nil {
+			logging.Error(ctx, logger.Format{
+				Message: "Error searching products",
+				Data: map[string]string{
+					"error": err.Error(),
+				},
+			})
+			return nil, types.NewInternalServerError()
+		}
+		text = compiled
+	}
+
+	tenantID := tenant.ID(ctx)
+	r.mu.RLock()
+	matches := []Product{}
+	for _, stored := range r.products {
+		if stored.TenantID != tenantID {
+			continue
+		}
+		if !containsString(params.Categories, stored.Category) || !containsString(params.Brands, stored.Brand) {
+			continue
+		}
+		if len(params.Scopes) > 0 && !coveredByAny(params.Scopes, *stored) {
+			continue
+		}
+		if text != nil {
+			name, description := localizedText(*stored, params.TextLocales)
+			if !text.MatchString(name) && !text.MatchString(description) {
+				continue
+			}
+		}
+		price := effectivePrice(*stored, params.PriceList)
+		if params.MinPrice != nil && price < params.MinPrice.Amount {
+			continue
+		}
+		if params.MaxPrice != nil && price > params.MaxPrice.Amount {
+			continue
+		}
+		matches = append(matches, copyProduct(*stored))
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if params.Sort == SortPriceAsc || params.Sort == SortPriceDesc {
+			left, right := effectivePrice(matches[i], params.PriceList), effectivePrice(matches[j], params.PriceList)
+			if left != right {
+				return (left < right) == (params.Sort == SortPriceAsc)
+			}
+		}
+		return matches[i].Popularity > matches[j].Popularity
+	})
+
+	var products []Product
+	for _, product := range matches {
+		if params.Limit > 0 && len(products) == params.Limit {
+			break
+		}
+		if params.Match == nil || params.Match(product) {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *memoryRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.products {
+		if stored.ID == productID && stored.TenantID == tenant.ID(ctx) {
+			product := copyProduct(*stored)
+			return &product, nil
+		}
+	}
+	return nil, types.NewNotFoundError("Product not found")
+}
+
+func (r *memoryRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.products {
+		if stored.ID == productID && stored.TenantID == tenant.ID(ctx) {
+			r.products = append(r.products[:i], r.products[i+1:]...)
+			audit.Record(ctx, auditChanges(stored, nil)...)
+			return nil
+		}
+	}
+	return types.NewNotFoundError("Product not found")
+}
+
+func (r *memoryRepository) CountProducts(ctx context.Context) (CatalogStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := CatalogStats{}
+	for _, product := range r.products {
+		if product.TenantID != tenant.ID(ctx) {
+			continue
+		}
+		stats.Products++
+		if product.Inventory <= 0 {
+			stats.OutOfStock++
+		}
+	}
+	return stats, nil
+}
+
+// containsString reports whether value is in values; an empty list matches
+// everything, as an absent filter does
+func containsString(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// localizedText is the name and description of the first translation found
+// along chain for each, falling back to the base fields
+func localizedText(product Product, chain []string) (string, string) {
+	name, description := "", ""
+	for _, tag := range chain {
+		translation := product.Translations[tag]
+		if name == "" {
+			name = translation.Name
+		}
+		if description == "" {
+			description = translation.Description
+		}
+	}
+	if name == "" {
+		name = product.Name
+	}
+	if description == "" {
+		description = product.Description
+	}
+	return name, description
+}
+
+// effectivePrice is the product's amount in priceList, falling back to its
+// base price
+func effectivePrice(product Product, priceList string) int64 {
+	if override, ok := product.PriceLists[priceList]; ok && priceList != "" {
+		return override.Amount
+	}
+	return product.Price.Amount
+}
+
+// copyProduct copies the product's slices and maps, so callers cannot change
+// a stored product through what they were given
+func copyProduct(product Product) Product {
+	if product.Images != nil {
+		product.Images = append([]string{}, product.Images...)
+	}
+	if product.CurrencyPrices != nil {
+		product.CurrencyPrices = append([]money.Money{}, product.CurrencyPrices...)
+	}
+	if product.Translations != nil {
+		translations := make(map[string]Translation, len(product.Translations))
+		for tag, translation := range product.Translations {
+			translations[tag] = translation
+		}
+		product.Translations = translations
+	}
+	if product.PriceLists != nil {
+		priceLists := make(map[string]money.Money, len(product.PriceLists))
+		for code, price := range product.PriceLists {
+			priceLists[code] = price
+		}
+		product.PriceLists = priceLists
+	}
+	return product
+}
+
+func coveredByAny(scopes []auth.Grant, product Product) bool {
+	for _, scope := range scopes {
+		if scope.Covers(product.Brand, product.Category) {
+			return true
+		}
+	}
+	return false
+}
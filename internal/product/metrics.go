@@ -0,0 +1,81 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const defaultCatalogMetricsInterval = 60 * time.Second
+
+var (
+	catalogProducts = metrics.NewGaugeVec("catalog_products",
+		"Live products in the catalog, by tenant.", "tenant")
+	catalogOutOfStock = metrics.NewGaugeVec("catalog_products_out_of_stock",
+		"Live products with no inventory, by tenant.", "tenant")
+	cacheRequests = metrics.NewCounterVec("catalog_cache_requests_total",
+		"Product cache lookups, by cache and result.", "cache", "result")
+	cacheEvictions = metrics.NewCounterVec("catalog_cache_evictions_total",
+		"Products evicted from the product cache to stay within its size.")
+)
+
+// CatalogMetrics periodically refreshes the catalog gauges of every tenant.
+// Counting is too costly to do on every scrape.
+type CatalogMetrics struct {
+	repository Repository
+	tenants    *tenant.Registry
+	interval   time.Duration
+}
+
+func NewCatalogMetrics(cfg config.Config, repository Repository, tenants *tenant.Registry) *CatalogMetrics {
+	interval := time.Duration(cfg.Get().Metrics.CatalogInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultCatalogMetricsInterval
+	}
+
+	return &CatalogMetrics{
+		repository: repository,
+		tenants:    tenants,
+		interval:   interval,
+	}
+}
+
+// Run blocks until ctx is cancelled, refreshing the gauges on every tick
+func (m *CatalogMetrics) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh leaves a tenant's gauges at their last values when its catalog
+// cannot be counted
+func (m *CatalogMetrics) refresh(ctx context.Context) {
+	for _, t := range m.tenants.All() {
+		stats, err := m.repository.CountProducts(tenant.WithTenant(ctx, t))
+		if err != nil {
+			logger.Error(logger.Format{
+				Message: "Failed to refresh catalog metrics",
+				Data: map[string]string{
+					"error":    err.Error(),
+					"tenantID": t.ID,
+				},
+			})
+			continue
+		}
+		catalogProducts.Set(float64(stats.Products), t.ID)
+		catalogOutOfStock.Set(float64(stats.OutOfStock), t.ID)
+	}
+}
@@ -0,0 +1,72 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CatalogMetricsTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	metrics    *CatalogMetrics
+}
+
+func (cm *CatalogMetricsTestSuite) SetupTest() {
+	logger.Init("debug")
+	values := &config.Values{
+		Tenancy: config.TenancyConfig{
+			Enabled: true,
+			Default: "metricsRetail",
+			Tenants: []config.TenantConfig{{ID: "metricsRetail"}, {ID: "metricsWholesale"}},
+		},
+	}
+	cm.repository = &MockRepository{}
+	cm.metrics = NewCatalogMetrics(values, cm.repository, tenant.NewRegistry(values))
+}
+
+func TestCatalogMetricsSuite(t *testing.T) {
+	suite.Run(t, new(CatalogMetricsTestSuite))
+}
+
+func ofTenant(id string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool { return tenant.ID(ctx) == id })
+}
+
+func exposed() string {
+	var out bytes.Buffer
+	metrics.Default.Write(&out)
+	return out.String()
+}
+
+func (cm *CatalogMetricsTestSuite) TestShouldRefreshGaugesOfEveryTenant() {
+	cm.repository.On("CountProducts", ofTenant("metricsRetail")).Return(CatalogStats{Products: 12, OutOfStock: 3}, nil)
+	cm.repository.On("CountProducts", ofTenant("metricsWholesale")).Return(CatalogStats{Products: 40}, nil)
+
+	cm.metrics.refresh(context.Background())
+
+	out := exposed()
+	assert.Contains(cm.T(), out, `catalog_products{tenant="metricsRetail"} 12`)
+	assert.Contains(cm.T(), out, `catalog_products_out_of_stock{tenant="metricsRetail"} 3`)
+	assert.Contains(cm.T(), out, `catalog_products{tenant="metricsWholesale"} 40`)
+}
+
+func (cm *CatalogMetricsTestSuite) TestShouldKeepLastValuesWhenCountFails() {
+	cm.repository.On("CountProducts", ofTenant("metricsRetail")).Return(CatalogStats{Products: 7}, nil).Once()
+	cm.repository.On("CountProducts", ofTenant("metricsRetail")).Return(CatalogStats{}, errors.New("unreachable"))
+	cm.repository.On("CountProducts", ofTenant("metricsWholesale")).Return(CatalogStats{Products: 1}, nil)
+
+	cm.metrics.refresh(context.Background())
+	cm.metrics.refresh(context.Background())
+
+	assert.Contains(cm.T(), exposed(), `catalog_products{tenant="metricsRetail"} 7`)
+}
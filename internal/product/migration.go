@@ -0,0 +1,43 @@
+package product
+
+import (
+	"context"
+	"math"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateLegacyPrices rewrites prices stored as plain numbers, from before
+// prices carried a currency, as Money documents in the given currency. Reads
+// already accept both shapes; the migration is needed for price range
+// filters, which only match Money documents.
+func MigrateLegacyPrices(ctx context.Context, db *utils.DBInstance, currency string) (int64, error) {
+	collection := db.Collection("rapidProducts")
+	factor := math.Pow10(money.Exponent(currency))
+
+	toMoney := func(field interface{}) bson.M {
+		return bson.M{
+			"amount": bson.M{"$toLong": bson.M{"$round": bson.A{
+				bson.M{"$multiply": bson.A{bson.M{"$toDecimal": field}, factor}}, 0,
+			}}},
+			"currency": currency,
+		}
+	}
+
+	filter := bson.M{"price": bson.M{"$type": "number"}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"price":     toMoney("$price"),
+			"basePrice": toMoney(bson.M{"$ifNull": bson.A{"$basePrice", "$price"}}),
+		}}},
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
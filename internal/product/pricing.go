@@ -0,0 +1,183 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+// pricer presents product prices in one currency and price list with the live
+// promotions applied. It is built once per request so every product in a
+// response is priced against the same rates and promotions.
+type pricer struct {
+	currency   string
+	priceList  string
+	rates      money.Rates
+	promotions []promotion.Promotion
+	now        time.Time
+}
+
+func (s *serviceImpl) newPricer(ctx context.Context, view ViewOptions) (*pricer, error) {
+	rates, err := s.currencies.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := view.Currency
+	if currency == "" {
+		currency = rates.Base
+	}
+	if !rates.Supports(currency) {
+		return nil, types.NewValidationError(fmt.Sprintf("Unsupported currency %s", currency))
+	}
+
+	if view.PriceList != "" {
+		if err := s.checkPriceList(ctx, view.PriceList); err != nil {
+			return nil, err
+		}
+	}
+
+	promotions, err := s.promotions.GetActivePromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pricer{
+		currency:   currency,
+		priceList:  view.PriceList,
+		rates:      rates,
+		promotions: promotions,
+		now:        time.Now().UTC(),
+	}, nil
+}
+
+func (s *serviceImpl) checkPriceList(ctx context.Context, code string) error {
+	priceLists, err := s.priceLists.GetActivePriceLists(ctx)
+	if err != nil {
+		return err
+	}
+	for _, priceList := range priceLists {
+		if priceList.Code == code {
+			return nil
+		}
+	}
+	return types.NewValidationError(fmt.Sprintf("Unknown price list %s", code))
+}
+
+// override is the product's price in the pricer's price list, if it has one
+func (p *pricer) override(product Product) (money.Money, bool) {
+	if p.priceList == "" {
+		return money.Money{}, false
+	}
+	price, ok := product.PriceLists[p.priceList]
+	return price, ok
+}
+
+// listPrice is the product's override in the price list when it has one,
+// then its explicit price in the currency, and its base price converted
+// otherwise. Overrides are in the base currency.
+func (p *pricer) listPrice(product Product) (money.Money, error) {
+	if price, ok := p.override(product); ok {
+		return p.rates.Convert(price, p.currency)
+	}
+	for _, currencyPrice := range product.CurrencyPrices {
+		if currencyPrice.Currency == p.currency {
+			return currencyPrice, nil
+		}
+	}
+	return p.rates.Convert(product.Price, p.currency)
+}
+
+func (p *pricer) evaluate(product Product) (promotion.Pricing, error) {
+	listPrice, err := p.listPrice(product)
+	if err != nil {
+		return promotion.Pricing{}, err
+	}
+
+	item := promotion.Item{
+		ProductID: product.ID,
+		Category:  product.Category,
+		Brand:     product.Brand,
+		Price:     listPrice,
+	}
+	return promotion.Evaluate(p.promotions, item, p.now, p.rates), nil
+}
+
+func (p *pricer) apply(ctx context.Context, product *Product) error {
+	pricing, err := p.evaluate(*product)
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error pricing product",
+			Data: map[string]string{
+				"error":     err.Error(),
+				"productID": product.ID.Hex(),
+				"currency":  p.currency,
+			},
+		})
+		return types.NewInternalServerError()
+	}
+
+	if _, ok := p.override(*product); ok {
+		product.PriceList = p.priceList
+	}
+	product.ListPrice = &pricing.ListPrice
+	product.SalePrice = &pricing.SalePrice
+	product.Promotion = pricing.Applied
+	return nil
+}
+
+// rangeParams pushes down the part of the price range the datastore can
+// match on its base list price; the rest is matched per product.
+func (p *pricer) rangeParams(params SearchParams) SearchParams {
+	if p.currency == p.rates.Base && params.PriceBasis != PriceBasisSale {
+		return params
+	}
+
+	minPrice, maxPrice, basis := params.MinPrice, params.MaxPrice, params.PriceBasis
+	params.MaxPrice = nil
+	if p.currency != p.rates.Base {
+		params.MinPrice = nil
+	}
+
+	params.Match = func(product Product) bool {
+		pricing, err := p.evaluate(product)
+		if err != nil {
+			return false
+		}
+
+		value := pricing.ListPrice
+		if basis == PriceBasisSale {
+			value = pricing.SalePrice
+		}
+		if minPrice != nil && value.Amount < minPrice.Amount {
+			return false
+		}
+		return maxPrice == nil || value.Amount <= maxPrice.Amount
+	}
+	return params
+}
+
+// sortsInMemory reports whether a price sort must be applied after pricing.
+// The datastore orders by the effective base price, which matches the order
+// in the base currency; in another currency explicit currency prices can
+// break it, so the matches, up to the configured cap, are read and sorted by
+// list price.
+func (p *pricer) sortsInMemory(params SearchParams) bool {
+	return (params.Sort == SortPriceAsc || params.Sort == SortPriceDesc) && p.currency != p.rates.Base
+}
+
+func sortByListPrice(products []Product, order string) {
+	sort.SliceStable(products, func(i, j int) bool {
+		if order == SortPriceDesc {
+			return products[i].ListPrice.Amount > products[j].ListPrice.Amount
+		}
+		return products[i].ListPrice.Amount < products[j].ListPrice.Amount
+	})
+}
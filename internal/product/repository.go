@@ -2,7 +2,15 @@ package product
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"time"
 
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
 	"github.com/roppenlabs/rapid-product-catalog/internal/types"
 	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
 	logger "github.com/roppenlabs/rapido-logger-go"
@@ -13,25 +21,86 @@ import (
 )
 
 type Repository interface {
-	CreateProducts(ctx context.Context, products []Product) (*CreateProductsResult, error)
-	SearchProducts(ctx context.Context, categories []string, brands []string, minPrice, maxPrice *float64, searchText string, limit int) ([]Product, error)
+	CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error)
+	SearchProducts(ctx context.Context, params SearchParams) ([]Product, error)
 	GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error)
+	DeleteProduct(ctx context.Context, productID primitive.ObjectID) error
+	CountProducts(ctx context.Context) (CatalogStats, error)
+}
+
+const (
+	StoreMongo  = "mongo"
+	StoreMemory = "memory"
+)
+
+// newStore returns the product repository backend selected by config
+func newStore(cfg config.Config, db *utils.DBInstance, eventsRepository events.Repository) Repository {
+	if cfg.Get().Datastores.ProductStore == StoreMemory {
+		logger.Info(logger.Format{Message: "Using in-memory product store; products are not persisted, and the rest of the catalog stays in Mongo"})
+		return NewMemoryRepository()
+	}
+	return NewRepository(db, eventsRepository)
+}
+
+// productKey identifies a product within its tenant's catalog; uploads
+// upsert on it
+type productKey struct {
+	name     string
+	category string
+}
+
+// addedProducts counts the distinct products of an upload not among existing
+func addedProducts(products []Product, existing []Product) int {
+	seen := map[productKey]bool{}
+	for _, product := range existing {
+		seen[productKey{product.Name, product.Category}] = true
+	}
+	added := 0
+	for _, product := range products {
+		key := productKey{product.Name, product.Category}
+		if !seen[key] {
+			seen[key] = true
+			added++
+		}
+	}
+	return added
+}
+
+// checkProductLimit refuses an upload adding products to a tenant that
+// already holds live of them, if it would take the tenant past its limit
+func checkProductLimit(t tenant.Tenant, live, added int) error {
+	if t.MaxProducts <= 0 || added == 0 || live+added <= t.MaxProducts {
+		return nil
+	}
+	return types.NewLimitExceededError(fmt.Sprintf("Tenant %s is limited to %d products", t.ID, t.MaxProducts))
 }
 
 type repositoryImpl struct {
+	db         *utils.DBInstance
 	collection *mongo.Collection
+	// reads serves searches and lookups, which may lag behind writes
+	reads  *mongo.Collection
+	events events.Repository
 }
 
-func NewRepository(db *utils.DBInstance) Repository {
+func NewRepository(db *utils.DBInstance, eventsRepository events.Repository) Repository {
 	if db == nil || db.TestDB == nil {
 		panic("database cannot be nil")
 	}
 	return &repositoryImpl{
-		collection: db.TestDB.Collection("rapidProducts"),
+		db:         db,
+		collection: db.Collection("rapidProducts"),
+		reads:      db.ReadCollection("rapidProducts"),
+		events:     eventsRepository,
 	}
 }
 
-func (r *repositoryImpl) CreateProducts(ctx context.Context, products []Product) (*CreateProductsResult, error) {
+// CreateProducts upserts the products in a causally consistent session, so
+// the products read back after the write include it even when served by a
+// secondary. An atomic upload runs in a transaction and is rolled back if any
+// product fails; otherwise each product is upserted on its own. Events are
+// recorded once the products are written.
+func (r *repositoryImpl) CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error) {
 	if len(products) == 0 {
 		return &CreateProductsResult{
 			Created:    0,
@@ -40,76 +109,137 @@ func (r *repositoryImpl) CreateProducts(ctx context.Context, products []Product)
 		}, nil
 	}
 
+	var result *CreateProductsResult
+	err := r.db.CausalSession(ctx, func(ctx context.Context) error {
+		var err error
+		if opts.Atomic {
+			result, err = r.upsertInTransaction(ctx, products)
+		} else {
+			result, err = r.upsertProducts(ctx, products, false)
+		}
+		if err != nil {
+			return err
+		}
+		r.recordChanges(ctx, result.Products, result.Previous)
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*types.StatusError); ok {
+			return nil, err
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error applying product upload",
+			Data: map[string]string{
+				"error":  err.Error(),
+				"atomic": fmt.Sprintf("%t", opts.Atomic),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+	return result, nil
+}
+
+// upsertInTransaction upserts the products in a transaction on the session
+// of ctx
+func (r *repositoryImpl) upsertInTransaction(ctx context.Context, products []Product) (*CreateProductsResult, error) {
+	var result *CreateProductsResult
+	err := r.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.upsertProducts(ctx, products, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// upsertProducts writes the products and reads them back. In a transaction
+// the write is ordered, so it stops at the first failure, and every read
+// goes to the primary, as transactions require.
+func (r *repositoryImpl) upsertProducts(ctx context.Context, products []Product, inTransaction bool) (*CreateProductsResult, error) {
 	models := make([]mongo.WriteModel, 0, len(products))
 	productFilters := make([]bson.M, 0, len(products))
+	now := time.Now().UTC()
 
 	for _, product := range products {
-		filter := bson.M{
+		filter := tenant.Filter(ctx, events.Live(bson.M{
 			"name":     product.Name,
 			"category": product.Category,
-		}
+		}))
 		productFilters = append(productFilters, filter)
 
 		update := bson.M{
 			"$set": bson.M{
-				"name":         product.Name,
-				"category":     product.Category,
-				"brand":        product.Brand,
-				"price":        product.Price,
-				"description":  product.Description,
-				"images":       product.Images,
-				"availableQty": product.Inventory,
-				"popularity":   product.Popularity,
+				"name":           product.Name,
+				"category":       product.Category,
+				"brand":          product.Brand,
+				"price":          product.Price,
+				"description":    product.Description,
+				"images":         product.Images,
+				"availableQty":   product.Inventory,
+				"popularity":     product.Popularity,
+				"basePrice":      product.Price,
+				"currencyPrices": product.CurrencyPrices,
+				"translations":   product.Translations,
 			},
 		}
 
 		updateModel := mongo.NewUpdateOneModel().
 			SetFilter(filter).
-			SetUpdate(update).
+			SetUpdate(events.Track(update, now)).
 			SetUpsert(true)
 
 		models = append(models, updateModel)
 	}
 
-	bulkWriteOptions := options.BulkWrite().SetOrdered(false)
-	bulkResult, err := r.collection.BulkWrite(ctx, models, bulkWriteOptions)
+	previousProducts, err := r.findByFilters(ctx, r.collection, productFilters)
 	if err != nil {
-		logger.Error(logger.Format{
-			Message: "Error executing bulk write for products",
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching existing products before bulk write",
 			Data: map[string]string{
 				"error": err.Error(),
 			},
 		})
-		return nil, types.NewInternalServerError()
+		return nil, utils.DatastoreError(err)
+	}
+	if err := checkUploadPermissions(ctx, products, previousProducts); err != nil {
+		return nil, err
+	}
+	if err := r.checkLimit(ctx, products, previousProducts); err != nil {
+		return nil, err
 	}
 
-	var updatedProducts []Product
-	if len(productFilters) > 0 {
-		findFilter := bson.M{
-			"$or": productFilters,
-		}
-
-		cursor, err := r.collection.Find(ctx, findFilter)
-		if err != nil {
-			logger.Error(logger.Format{
-				Message: "Error fetching products after bulk write",
-				Data: map[string]string{
-					"error": err.Error(),
-				},
-			})
-			return nil, types.NewInternalServerError()
-		}
-		defer cursor.Close(ctx)
+	bulkWriteOptions := options.BulkWrite().SetOrdered(inTransaction)
+	var bulkResult *mongo.BulkWriteResult
+	err = r.db.Write(ctx, "product.CreateProducts", func(ctx context.Context) error {
+		var err error
+		bulkResult, err = r.collection.BulkWrite(ctx, models, bulkWriteOptions)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error executing bulk write for products",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
 
-		if err := cursor.All(ctx, &updatedProducts); err != nil {
-			logger.Error(logger.Format{
-				Message: "Error decoding products after bulk write",
-				Data: map[string]string{
-					"error": err.Error(),
-				},
-			})
-			return nil, types.NewInternalServerError()
-		}
+	reads := r.reads
+	if inTransaction {
+		reads = r.collection
+	}
+	updatedProducts, err := r.findByFilters(ctx, reads, productFilters)
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching products after bulk write",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
 	}
 
 	// Extract product IDs
@@ -129,92 +259,347 @@ func (r *repositoryImpl) CreateProducts(ctx context.Context, products []Product)
 	// All matched documents are considered updated (even if values didn't change)
 	updated := int(bulkResult.MatchedCount)
 
+	previous := make(map[primitive.ObjectID]Product, len(previousProducts))
+	for _, product := range previousProducts {
+		previous[product.ID] = product
+	}
+
 	return &CreateProductsResult{
 		Created:    created,
 		Updated:    updated,
 		ProductIDs: productIDs,
+		Products:   updatedProducts,
+		Previous:   previous,
 	}, nil
 }
 
-func (r *repositoryImpl) SearchProducts(ctx context.Context, categories []string, brands []string, minPrice, maxPrice *float64, searchText string, limit int) ([]Product, error) {
-	filter := bson.M{}
+// checkLimit refuses an upload that would take the tenant of ctx past its
+// product limit. Concurrent uploads may each pass it, so the limit can be
+// overshot by what they add together.
+func (r *repositoryImpl) checkLimit(ctx context.Context, products []Product, existing []Product) error {
+	t, ok := tenant.FromContext(ctx)
+	if !ok || t.MaxProducts <= 0 {
+		return nil
+	}
+	added := addedProducts(products, existing)
+	if added == 0 {
+		return nil
+	}
+
+	var live int64
+	err := r.db.Read(ctx, "product.checkLimit", func(ctx context.Context) error {
+		var err error
+		live, err = r.collection.CountDocuments(ctx, tenant.Filter(ctx, events.Live(bson.M{})))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error counting tenant products",
+			Data: map[string]string{
+				"error":    err.Error(),
+				"tenantID": t.ID,
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+	return checkProductLimit(t, int(live), added)
+}
+
+// recordChanges records an event for each product the upload created or
+// changed. The write already succeeded, so a failure is only logged; the
+// products stay pending and the dispatcher's sweeper records them later.
+func (r *repositoryImpl) recordChanges(ctx context.Context, products []Product, previous map[primitive.ObjectID]Product) {
+	changes := []events.Event{}
+	unchanged := []events.Snapshot{}
+	for _, product := range products {
+		product := product
+		before, existed := previous[product.ID]
+		if !existed {
+			audit.Record(ctx, auditChanges(nil, &product)...)
+			changes = append(changes, events.NewEvent(events.TypeProductCreated, snapshot(product), nil))
+			continue
+		}
+		if fields := changedFields(before, product); len(fields) > 0 {
+			audit.Record(ctx, auditChanges(&before, &product)...)
+			changes = append(changes, events.NewEvent(events.TypeProductUpdated, snapshot(product), fields))
+			continue
+		}
+		unchanged = append(unchanged, snapshot(product))
+	}
+
+	if err := r.events.Record(ctx, changes); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to record product events", Data: map[string]string{"error": err.Error()}})
+	}
+	if err := r.events.Settle(ctx, unchanged); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to settle unchanged products", Data: map[string]string{"error": err.Error()}})
+	}
+}
+
+func (r *repositoryImpl) findByFilters(ctx context.Context, collection *mongo.Collection, filters []bson.M) ([]Product, error) {
+	products := []Product{}
+	if len(filters) == 0 {
+		return products, nil
+	}
+
+	err := r.db.Read(ctx, "product.findByFilters", func(ctx context.Context) error {
+		cursor, err := collection.Find(ctx, bson.M{"$or": filters})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &products)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (r *repositoryImpl) SearchProducts(ctx context.Context, params SearchParams) ([]Product, error) {
+	filter := tenant.Filter(ctx, events.Live(bson.M{}))
 
 	// Category filter - support multiple categories
-	if len(categories) > 0 {
-		filter["category"] = bson.M{"$in": categories}
+	if len(params.Categories) > 0 {
+		filter["category"] = bson.M{"$in": params.Categories}
 	}
 
 	// Brand filter - support multiple brands
-	if len(brands) > 0 {
-		filter["brand"] = bson.M{"$in": brands}
+	if len(params.Brands) > 0 {
+		filter["brand"] = bson.M{"$in": params.Brands}
 	}
 
-	// Price range filter
-	if minPrice != nil || maxPrice != nil {
-		priceFilter := bson.M{}
-		if minPrice != nil {
-			priceFilter["$gte"] = *minPrice
-		}
-		if maxPrice != nil {
-			priceFilter["$lte"] = *maxPrice
+	// Scoped callers only read the brands and categories of their grants
+	if len(params.Scopes) > 0 {
+		scopes := make([]bson.M, 0, len(params.Scopes))
+		for _, scope := range params.Scopes {
+			match := bson.M{}
+			if len(scope.Brands) > 0 {
+				match["brand"] = bson.M{"$in": caseInsensitive(scope.Brands)}
+			}
+			if len(scope.Categories) > 0 {
+				match["category"] = bson.M{"$in": caseInsensitive(scope.Categories)}
+			}
+			scopes = append(scopes, match)
 		}
-		filter["price"] = priceFilter
+		filter["$and"] = []bson.M{{"$or": scopes}}
 	}
 
 	// Text search - search in name and description
-	if searchText != "" {
+	if params.SearchText != "" && len(params.TextLocales) == 0 {
 		filter["$or"] = []bson.M{
-			{"name": bson.M{"$regex": searchText, "$options": "i"}},
-			{"description": bson.M{"$regex": searchText, "$options": "i"}},
+			{"name": bson.M{"$regex": params.SearchText, "$options": "i"}},
+			{"description": bson.M{"$regex": params.SearchText, "$options": "i"}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+
+	// In another locale, search the name and description the caller is shown
+	if params.SearchText != "" && len(params.TextLocales) > 0 {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$addFields", Value: bson.M{
+				"localizedName":        localizedField("name", params.TextLocales),
+				"localizedDescription": localizedField("description", params.TextLocales),
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"$or": []bson.M{
+				{"localizedName": bson.M{"$regex": params.SearchText, "$options": "i"}},
+				{"localizedDescription": bson.M{"$regex": params.SearchText, "$options": "i"}},
+			}}}},
+		)
+	}
+
+	// The effective price is the override in the requested price list,
+	// falling back to the base price
+	priceField := "price.amount"
+	if params.PriceList != "" {
+		priceField = "effectivePrice"
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
+			priceField: bson.M{"$ifNull": bson.A{"$priceLists." + params.PriceList + ".amount", "$price.amount"}},
+		}}})
+	}
+
+	// Price range filter, on amounts in the base currency
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		priceFilter := bson.M{}
+		if params.MinPrice != nil {
+			priceFilter["$gte"] = params.MinPrice.Amount
+		}
+		if params.MaxPrice != nil {
+			priceFilter["$lte"] = params.MaxPrice.Amount
 		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{priceField: priceFilter}}})
+	}
+
+	// Sort by popularity (descending) unless a price order is requested
+	sort := bson.D{{Key: "popularity", Value: -1}}
+	switch params.Sort {
+	case SortPriceAsc:
+		sort = bson.D{{Key: priceField, Value: 1}, {Key: "popularity", Value: -1}}
+	case SortPriceDesc:
+		sort = bson.D{{Key: priceField, Value: -1}, {Key: "popularity", Value: -1}}
 	}
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
 
-	// Sort by popularity (descending) and limit results
-	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "popularity", Value: -1}})
-	findOptions.SetLimit(int64(limit))
+	// The limit cannot be pushed down when products are matched after
+	// decoding
+	if params.Match == nil && params.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(params.Limit)}})
+	}
 
-	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	var products []Product
+	err := r.db.Read(ctx, "product.SearchProducts", func(ctx context.Context) error {
+		var err error
+		products, err = r.search(ctx, pipeline, params.Match, params.Limit)
+		return err
+	})
 	if err != nil {
-		logger.Error(logger.Format{
+		logging.Error(ctx, logger.Format{
 			Message: "Error searching products",
 			Data: map[string]string{
 				"error": err.Error(),
 			},
 		})
-		return nil, types.NewInternalServerError()
+		return nil, utils.DatastoreError(err)
+	}
+
+	return products, nil
+}
+
+// search runs the search pipeline and decodes the products, keeping up to
+// limit of those match accepts when it is set
+func (r *repositoryImpl) search(ctx context.Context, pipeline mongo.Pipeline, match func(Product) bool, limit int) ([]Product, error) {
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var products []Product
-	if err := cursor.All(ctx, &products); err != nil {
-		logger.Error(logger.Format{
-			Message: "Error decoding products",
-			Data: map[string]string{
-				"error": err.Error(),
-			},
-		})
-		return nil, types.NewInternalServerError()
+	if match == nil {
+		if err := cursor.All(ctx, &products); err != nil {
+			return nil, err
+		}
+		return products, nil
 	}
 
-	return products, nil
+	for (limit == 0 || len(products) < limit) && cursor.Next(ctx) {
+		var product Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		if match(product) {
+			products = append(products, product)
+		}
+	}
+	return products, cursor.Err()
+}
+
+// localizedField is an expression for the first translation of field found
+// along chain, falling back to the base field
+func localizedField(field string, chain []string) interface{} {
+	var expression interface{} = "$" + field
+	for i := len(chain) - 1; i >= 0; i-- {
+		expression = bson.M{"$ifNull": bson.A{"$translations." + chain[i] + "." + field, expression}}
+	}
+	return expression
 }
 
 func (r *repositoryImpl) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
 	var product Product
-	err := r.collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&product)
+	err := r.db.Read(ctx, "product.GetProductByID", func(ctx context.Context) error {
+		return r.reads.FindOne(ctx, tenant.Filter(ctx, events.Live(bson.M{"_id": productID}))).Decode(&product)
+	})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, types.NewNotFoundError("Product not found")
 		}
-		logger.Error(logger.Format{
+		logging.Error(ctx, logger.Format{
 			Message: "Error fetching product by ID",
 			Data: map[string]string{
 				"error":     err.Error(),
 				"productID": productID.Hex(),
 			},
 		})
-		return nil, types.NewInternalServerError()
+		return nil, utils.DatastoreError(err)
 	}
 	return &product, nil
 }
+
+// DeleteProduct marks the product deleted and records its deletion; the
+// document is removed once the event is recorded
+func (r *repositoryImpl) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	now := time.Now().UTC()
+	update := events.Track(bson.M{"$set": bson.M{events.DeletedField: now}}, now)
+	findOneAndUpdateOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var deleted bson.Raw
+	err := r.db.Write(ctx, "product.DeleteProduct", func(ctx context.Context) error {
+		var err error
+		deleted, err = r.collection.FindOneAndUpdate(ctx, tenant.Filter(ctx, events.Live(bson.M{"_id": productID})), update, findOneAndUpdateOptions).DecodeBytes()
+		return err
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return types.NewNotFoundError("Product not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error deleting product",
+			Data: map[string]string{
+				"error":     err.Error(),
+				"productID": productID.Hex(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	var product Product
+	var deletedSnapshot events.Snapshot
+	if err := bson.Unmarshal(deleted, &product); err == nil {
+		audit.Record(ctx, auditChanges(&product, nil)...)
+	}
+	if err := bson.Unmarshal(deleted, &deletedSnapshot); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to decode deleted product", Data: map[string]string{"error": err.Error(), "productID": productID.Hex()}})
+		return nil
+	}
+	if err := r.events.Record(ctx, []events.Event{events.NewEvent(events.TypeProductDeleted, deletedSnapshot, nil)}); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to record product deletion", Data: map[string]string{"error": err.Error(), "productID": productID.Hex()}})
+	}
+	return nil
+}
+
+// CountProducts counts the live products of the tenant of ctx
+func (r *repositoryImpl) CountProducts(ctx context.Context) (CatalogStats, error) {
+	var stats CatalogStats
+	err := r.db.Read(ctx, "product.CountProducts", func(ctx context.Context) error {
+		products, err := r.reads.CountDocuments(ctx, tenant.Filter(ctx, events.Live(bson.M{})))
+		if err != nil {
+			return err
+		}
+		outOfStock, err := r.reads.CountDocuments(ctx, tenant.Filter(ctx, events.Live(bson.M{"availableQty": bson.M{"$lte": 0}})))
+		if err != nil {
+			return err
+		}
+		stats = CatalogStats{Products: int(products), OutOfStock: int(outOfStock)}
+		return nil
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error counting catalog products",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return CatalogStats{}, utils.DatastoreError(err)
+	}
+	return stats, nil
+}
+
+// caseInsensitive matches any of names whatever their case, as grants do
+func caseInsensitive(names []string) []interface{} {
+	patterns := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"})
+	}
+	return patterns
+}
@@ -0,0 +1,412 @@
+package product
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// conformanceMongoURI names the environment variable pointing the
+// conformance suite at a Mongo deployment; without it only the in-memory
+// backend is checked
+const conformanceMongoURI = "RAPID_CONFORMANCE_MONGO_URI"
+
+// RepositoryConformanceSuite is the behaviour every Repository backend must
+// share
+type RepositoryConformanceSuite struct {
+	suite.Suite
+	newRepository func() (Repository, func())
+	repository    Repository
+	cleanup       func()
+}
+
+func (rc *RepositoryConformanceSuite) SetupSuite() {
+	logger.Init("debug")
+}
+
+func (rc *RepositoryConformanceSuite) SetupTest() {
+	rc.repository, rc.cleanup = rc.newRepository()
+}
+
+func (rc *RepositoryConformanceSuite) TearDownTest() {
+	if rc.cleanup != nil {
+		rc.cleanup()
+	}
+}
+
+func TestMemoryRepositoryConformance(t *testing.T) {
+	suite.Run(t, &RepositoryConformanceSuite{
+		newRepository: func() (Repository, func()) {
+			return NewMemoryRepository(), nil
+		},
+	})
+}
+
+func TestMongoRepositoryConformance(t *testing.T) {
+	uri := os.Getenv(conformanceMongoURI)
+	if uri == "" {
+		t.Skipf("%s is not set", conformanceMongoURI)
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Failed to connect to mongo: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	suite.Run(t, &RepositoryConformanceSuite{
+		newRepository: func() (Repository, func()) {
+			db := &utils.DBInstance{TestDB: client.Database("rapidConformance_" + primitive.NewObjectID().Hex())}
+			return NewRepository(db, events.NewRepository(&config.Values{}, db)), func() {
+				db.TestDB.Drop(context.Background())
+			}
+		},
+	})
+}
+
+func (rc *RepositoryConformanceSuite) seed(products ...Product) []Product {
+	result, err := rc.repository.CreateProducts(context.Background(), products, CreateOptions{})
+	rc.Require().NoError(err)
+	return result.Products
+}
+
+func catalogProduct(name, category, brand string, price int64, popularity float64) Product {
+	return Product{
+		Name:        name,
+		Category:    category,
+		Brand:       brand,
+		Price:       money.New(price, "INR"),
+		Description: name + " description",
+		Images:      []string{"https://cdn.example.com/" + name + ".png"},
+		Inventory:   10,
+		Popularity:  popularity,
+	}
+}
+
+func names(products []Product) []string {
+	result := []string{}
+	for _, product := range products {
+		result = append(result, product.Name)
+	}
+	return result
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldUpsertByNameAndCategory() {
+	first, err := rc.repository.CreateProducts(context.Background(), []Product{
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Titan Edge", "strap", "titan", 99900, 3.0),
+	}, CreateOptions{})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), 2, first.Created)
+	assert.Equal(rc.T(), 0, first.Updated)
+	assert.Len(rc.T(), first.ProductIDs, 2)
+	assert.Empty(rc.T(), first.Previous)
+
+	changed := catalogProduct("Titan Edge", "watch", "titan", 1199900, 4.7)
+	second, err := rc.repository.CreateProducts(context.Background(), []Product{changed}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	assert.Equal(rc.T(), 0, second.Created)
+	assert.Equal(rc.T(), 1, second.Updated)
+	rc.Require().Len(second.Products, 1)
+	updated := second.Products[0]
+	assert.Equal(rc.T(), first.Products[0].ID, updated.ID)
+	assert.Equal(rc.T(), money.New(1199900, "INR"), updated.Price)
+	assert.Equal(rc.T(), money.New(1199900, "INR"), updated.BasePrice)
+	assert.Equal(rc.T(), int64(2), updated.Version)
+	assert.Equal(rc.T(), money.New(1299900, "INR"), second.Previous[updated.ID].Price)
+
+	stored, err := rc.repository.GetProductByID(context.Background(), updated.ID)
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), 4.7, stored.Popularity)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldReturnEmptyResultForEmptyUpload() {
+	result, err := rc.repository.CreateProducts(context.Background(), []Product{}, CreateOptions{})
+
+	assert.NoError(rc.T(), err)
+	assert.Equal(rc.T(), 0, result.Created)
+	assert.Empty(rc.T(), result.ProductIDs)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldFilterByCategoryBrandAndText() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+		catalogProduct("Titan Aviator", "sunglasses", "titan", 599900, 3.9),
+		catalogProduct("Nike Pegasus", "shoes", "nike", 899900, 4.8),
+	)
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{Categories: []string{"watch", "sunglasses"}, Brands: []string{"titan"}})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Edge", "Titan Aviator"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{SearchText: "REFLEX"})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Fastrack Reflex"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{SearchText: "^titan"})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Edge", "Titan Aviator"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldSearchOnlyWithinScopes() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+		catalogProduct("Titan Aviator", "sunglasses", "titan", 599900, 3.9),
+		catalogProduct("Nike Pegasus", "shoes", "nike", 899900, 4.8),
+	)
+	scopes := []auth.Grant{
+		{Role: auth.RoleViewer, Brands: []string{"Titan"}, Categories: []string{"watch"}},
+		{Role: auth.RoleViewer, Categories: []string{"shoes"}},
+	}
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{Scopes: scopes})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Nike Pegasus", "Titan Edge"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{Scopes: scopes, SearchText: "titan"})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Edge"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldSearchTranslationsAlongLocaleChain() {
+	watch := catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)
+	watch.Translations = map[string]Translation{"hi-IN": {Name: "टाइटन एज"}}
+	rc.seed(watch, catalogProduct("Titan Raga", "watch", "titan", 999900, 4.0))
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{SearchText: "टाइटन", TextLocales: []string{"hi-IN"}})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Edge"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{SearchText: "raga", TextLocales: []string{"hi-IN"}})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Raga"}, names(products), "untranslated products fall back to the base name")
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldSortAndLimit() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	)
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Raga", "Titan Edge", "Fastrack Reflex"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{Sort: SortPriceAsc, Limit: 2})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Fastrack Reflex", "Titan Raga"}, names(products))
+
+	products, err = rc.repository.SearchProducts(context.Background(), SearchParams{Sort: SortPriceDesc})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Edge", "Titan Raga", "Fastrack Reflex"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldFilterByPriceRange() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	)
+	minPrice, maxPrice := money.New(399900, "INR"), money.New(999900, "INR")
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{MinPrice: &minPrice, MaxPrice: &maxPrice})
+
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Raga", "Fastrack Reflex"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldApplyMatchBeforeLimit() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	)
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{
+		Limit: 1,
+		Match: func(product Product) bool { return product.Brand == "fastrack" },
+	})
+
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Fastrack Reflex"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldReportMissingProducts() {
+	_, err := rc.repository.GetProductByID(context.Background(), primitive.NewObjectID())
+	assert.Equal(rc.T(), http.StatusNotFound, types.ToStatusError(err).HTTPCode)
+
+	err = rc.repository.DeleteProduct(context.Background(), primitive.NewObjectID())
+	assert.Equal(rc.T(), http.StatusNotFound, types.ToStatusError(err).HTTPCode)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldHideDeletedProducts() {
+	seeded := rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	)
+
+	rc.Require().NoError(rc.repository.DeleteProduct(context.Background(), seeded[0].ID))
+
+	_, err := rc.repository.GetProductByID(context.Background(), seeded[0].ID)
+	assert.Equal(rc.T(), http.StatusNotFound, types.ToStatusError(err).HTTPCode)
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), []string{"Titan Raga"}, names(products))
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldNotShareStateWithCallers() {
+	seeded := rc.seed(catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5))
+
+	product, err := rc.repository.GetProductByID(context.Background(), seeded[0].ID)
+	rc.Require().NoError(err)
+	product.Images[0] = "changed"
+
+	stored, err := rc.repository.GetProductByID(context.Background(), seeded[0].ID)
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), "https://cdn.example.com/Titan Edge.png", stored.Images[0])
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldIsolateTenants() {
+	retail := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "retail"})
+	wholesale := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "wholesale"})
+
+	retailResult, err := rc.repository.CreateProducts(retail, []Product{catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)}, CreateOptions{})
+	rc.Require().NoError(err)
+	wholesaleResult, err := rc.repository.CreateProducts(wholesale, []Product{catalogProduct("Titan Edge", "watch", "titan", 999900, 4.5)}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	assert.Equal(rc.T(), 1, wholesaleResult.Created, "the same name and category is a new product in another tenant")
+	retailID := retailResult.ProductIDs[0]
+	_, err = rc.repository.GetProductByID(wholesale, retailID)
+	assert.Equal(rc.T(), http.StatusNotFound, types.ToStatusError(err).HTTPCode)
+	err = rc.repository.DeleteProduct(wholesale, retailID)
+	assert.Equal(rc.T(), http.StatusNotFound, types.ToStatusError(err).HTTPCode)
+
+	products, err := rc.repository.SearchProducts(retail, SearchParams{})
+	rc.Require().NoError(err)
+	rc.Require().Len(products, 1)
+	assert.Equal(rc.T(), int64(1299900), products[0].Price.Amount)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldApplyAtomicUpload() {
+	result, err := rc.repository.CreateProducts(context.Background(), []Product{
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	}, CreateOptions{Atomic: true})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), 2, result.Created)
+	assert.Len(rc.T(), result.Products, 2)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldWriteNothingWhenAtomicUploadIsRefused() {
+	limited := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "limited", MaxProducts: 1})
+	_, err := rc.repository.CreateProducts(limited, []Product{
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	}, CreateOptions{Atomic: true})
+	assert.Equal(rc.T(), "limit_exceeded", types.ToStatusError(err).Code)
+
+	products, err := rc.repository.SearchProducts(limited, SearchParams{})
+	rc.Require().NoError(err)
+	assert.Empty(rc.T(), products)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldEnforceTenantProductLimit() {
+	limited := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "limited", MaxProducts: 2})
+	_, err := rc.repository.CreateProducts(limited, []Product{
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	_, err = rc.repository.CreateProducts(limited, []Product{catalogProduct("Fastrack Reflex", "watch", "fastrack", 299900, 4.1)}, CreateOptions{})
+	assert.Equal(rc.T(), "limit_exceeded", types.ToStatusError(err).Code)
+
+	result, err := rc.repository.CreateProducts(limited, []Product{catalogProduct("Titan Edge", "watch", "titan", 1199900, 4.5)}, CreateOptions{})
+	rc.Require().NoError(err, "updating an existing product adds nothing")
+	assert.Equal(rc.T(), 1, result.Updated)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldCountLiveProductsOfTenant() {
+	soldOut := catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9)
+	soldOut.Inventory = 0
+	seeded := rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		soldOut,
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 299900, 4.1),
+	)
+	rc.Require().NoError(rc.repository.DeleteProduct(context.Background(), seeded[2].ID))
+	wholesale := tenant.WithTenant(context.Background(), tenant.Tenant{ID: "wholesale"})
+	_, err := rc.repository.CreateProducts(wholesale, []Product{catalogProduct("Titan Edge", "watch", "titan", 999900, 4.5)}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	stats, err := rc.repository.CountProducts(context.Background())
+
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), CatalogStats{Products: 2, OutOfStock: 1}, stats)
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldLimitBrandPartnerToItsOwnProducts() {
+	rc.seed(
+		catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5),
+		catalogProduct("Fastrack Reflex", "watch", "fastrack", 399900, 4.1),
+	)
+	partner := auth.WithIdentity(context.Background(), auth.Identity{Subject: "titan", Roles: []string{"merchandiser:brand=titan"}})
+
+	_, err := rc.repository.CreateProducts(partner, []Product{
+		catalogProduct("Titan Edge", "watch", "titan", 1199900, 4.6),
+		catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9),
+	}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	for name, product := range map[string]Product{
+		"another brand's product": catalogProduct("Fastrack Reflex", "watch", "fastrack", 299900, 4.1),
+		"taking a product over":   catalogProduct("Fastrack Reflex", "watch", "titan", 399900, 4.1),
+		"giving a product away":   catalogProduct("Titan Edge", "watch", "fastrack", 1199900, 4.6),
+	} {
+		_, err := rc.repository.CreateProducts(partner, []Product{product}, CreateOptions{})
+		assert.Equal(rc.T(), "forbidden", types.ToStatusError(err).Code, name)
+	}
+
+	products, err := rc.repository.SearchProducts(context.Background(), SearchParams{Brands: []string{"fastrack"}})
+	rc.Require().NoError(err)
+	assert.Equal(rc.T(), money.New(399900, "INR"), products[0].Price, "refused uploads write nothing")
+}
+
+func (rc *RepositoryConformanceSuite) TestShouldRequirePermissionOfEachChangedField() {
+	rc.seed(catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5))
+	inventoryManager := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"inventory-manager"}})
+	merchandiser := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"merchandiser"}})
+	restocked := catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)
+	restocked.Inventory = 25
+	repriced := catalogProduct("Titan Edge", "watch", "titan", 1199900, 4.5)
+
+	_, err := rc.repository.CreateProducts(inventoryManager, []Product{restocked}, CreateOptions{})
+	rc.Require().NoError(err)
+
+	_, err = rc.repository.CreateProducts(inventoryManager, []Product{repriced}, CreateOptions{})
+	assert.Equal(rc.T(), "forbidden", types.ToStatusError(err).Code)
+	_, err = rc.repository.CreateProducts(inventoryManager, []Product{catalogProduct("Titan Raga", "watch", "titan", 999900, 4.9)}, CreateOptions{})
+	assert.Equal(rc.T(), "forbidden", types.ToStatusError(err).Code)
+	_, err = rc.repository.CreateProducts(merchandiser, []Product{catalogProduct("Titan Edge", "watch", "titan", 1299900, 4.5)}, CreateOptions{})
+	assert.Equal(rc.T(), "forbidden", types.ToStatusError(err).Code, "the upload would undo the restock")
+}
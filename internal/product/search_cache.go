@@ -0,0 +1,251 @@
+package product
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultSearchCacheEntries = 1000
+	defaultSearchCacheTTL     = 30 * time.Second
+	// anyCategoryTag marks results of searches not filtered by category,
+	// which a change to any product may affect
+	anyCategoryTag = "category:*"
+)
+
+// SearchCache stores search results under a key, tagged with the categories
+// and products they depend on so a change can invalidate them. The in-memory
+// implementation is the default; a shared backend such as Redis can replace
+// it through the wire provider.
+type SearchCache interface {
+	Get(ctx context.Context, key string) ([]Product, bool, error)
+	Set(ctx context.Context, key string, products []Product, tags []string, ttl time.Duration) error
+	// Invalidate removes every entry carrying any of tags
+	Invalidate(ctx context.Context, tags ...string) error
+	Purge(ctx context.Context) error
+	Len() int
+}
+
+func NewSearchCache(cfg config.Config) SearchCache {
+	maxEntries := cfg.Get().SearchCache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultSearchCacheEntries
+	}
+	return NewMemorySearchCache(maxEntries)
+}
+
+// searchKey hashes the tenant and the parameters that decide which products
+// a search reads, with category and brand lists sorted and deduplicated.
+func searchKey(tenantID string, params SearchParams) string {
+	type scopeKey struct {
+		Brands     []string `json:"b"`
+		Categories []string `json:"c"`
+	}
+	scopes := make([]scopeKey, 0, len(params.Scopes))
+	for _, scope := range params.Scopes {
+		scopes = append(scopes, scopeKey{Brands: normalizedList(scope.Brands), Categories: normalizedList(scope.Categories)})
+	}
+
+	canonical := struct {
+		TenantID    string       `json:"t"`
+		Categories  []string     `json:"c"`
+		Brands      []string     `json:"b"`
+		MinPrice    *money.Money `json:"min"`
+		MaxPrice    *money.Money `json:"max"`
+		PriceList   string       `json:"pl"`
+		SearchText  string       `json:"q"`
+		TextLocales []string     `json:"ql"`
+		Sort        string       `json:"s"`
+		Limit       int          `json:"l"`
+		Scopes      []scopeKey   `json:"sc"`
+	}{
+		TenantID:    tenantID,
+		Categories:  normalizedList(params.Categories),
+		Brands:      normalizedList(params.Brands),
+		MinPrice:    params.MinPrice,
+		MaxPrice:    params.MaxPrice,
+		PriceList:   params.PriceList,
+		SearchText:  params.SearchText,
+		TextLocales: params.TextLocales,
+		Sort:        params.Sort,
+		Limit:       params.Limit,
+		Scopes:      scopes,
+	}
+
+	encoded, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizedList(values []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			normalized = append(normalized, value)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// searchTags lists what a search result depends on: the categories it was
+// filtered by and the products it holds
+func searchTags(params SearchParams, products []Product) []string {
+	tags := []string{}
+	for _, category := range normalizedList(params.Categories) {
+		tags = append(tags, categoryTag(category))
+	}
+	if len(tags) == 0 {
+		tags = append(tags, anyCategoryTag)
+	}
+	for _, product := range products {
+		tags = append(tags, productTag(product.ID))
+	}
+	return tags
+}
+
+// changeTags lists the tags of the searches a change to a product in the
+// given categories may affect
+func changeTags(productID primitive.ObjectID, categories ...string) []string {
+	tags := []string{anyCategoryTag, productTag(productID)}
+	for _, category := range categories {
+		if category != "" {
+			tags = append(tags, categoryTag(category))
+		}
+	}
+	return tags
+}
+
+func categoryTag(category string) string {
+	return "category:" + category
+}
+
+func productTag(productID primitive.ObjectID) string {
+	return "product:" + productID.Hex()
+}
+
+type searchEntry struct {
+	key       string
+	products  []Product
+	tags      []string
+	expiresAt time.Time
+}
+
+// MemorySearchCache is a SearchCache bounded to maxEntries, evicting the
+// least recently used entry
+type MemorySearchCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+	tags       map[string]map[string]struct{}
+	now        func() time.Time
+}
+
+func NewMemorySearchCache(maxEntries int) *MemorySearchCache {
+	return &MemorySearchCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		tags:       map[string]map[string]struct{}{},
+		now:        time.Now,
+	}
+}
+
+func (m *MemorySearchCache) Get(ctx context.Context, key string) ([]Product, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*searchEntry)
+	if !m.now().Before(entry.expiresAt) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.products, true, nil
+}
+
+func (m *MemorySearchCache) Set(ctx context.Context, key string, products []Product, tags []string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	m.entries[key] = m.order.PushFront(&searchEntry{
+		key:       key,
+		products:  products,
+		tags:      tags,
+		expiresAt: m.now().Add(ttl),
+	})
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = map[string]struct{}{}
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+func (m *MemorySearchCache) Invalidate(ctx context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if element, ok := m.entries[key]; ok {
+				m.remove(element)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MemorySearchCache) Purge(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = map[string]*list.Element{}
+	m.order.Init()
+	m.tags = map[string]map[string]struct{}{}
+	return nil
+}
+
+func (m *MemorySearchCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove must be called with mu held
+func (m *MemorySearchCache) remove(element *list.Element) {
+	entry := element.Value.(*searchEntry)
+	m.order.Remove(element)
+	delete(m.entries, entry.key)
+	for _, tag := range entry.tags {
+		delete(m.tags[tag], entry.key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
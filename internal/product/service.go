@@ -3,38 +3,80 @@ package product
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/currency"
+	"github.com/roppenlabs/rapid-product-catalog/internal/locale"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
 	"github.com/roppenlabs/rapid-product-catalog/internal/types"
 	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	defaultAtomicMaxProducts = 1000
+	defaultAtomicMaxBytes    = 8 << 20
+	defaultAtomicTimeout     = 30 * time.Second
+	defaultMaxPriceSorted    = 1000
+)
+
 type Service interface {
-	BulkCreateProducts(ctx context.Context, products []Product) (CreateProductsResponse, error)
+	BulkCreateProducts(ctx context.Context, products []Product, opts CreateOptions) (CreateProductsResponse, error)
 	SearchProducts(ctx context.Context, params SearchParams) (SearchProductsResponse, error)
-	GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error)
+	GetProductByID(ctx context.Context, productID primitive.ObjectID, view ViewOptions) (*Product, error)
+	DeleteProduct(ctx context.Context, productID primitive.ObjectID) error
 }
 
 type serviceImpl struct {
 	cfg        config.Config
 	repository Repository
+	prices     price.Service
+	promotions promotion.Service
+	currencies currency.Service
+	priceLists pricelist.Service
 }
 
-func NewService(cfg config.Config, repo Repository) Service {
+// NewService returns the service traced, each call in a span of its own
+func NewService(cfg config.Config, repo Repository, prices price.Service, promotions promotion.Service, currencies currency.Service, priceLists pricelist.Service) Service {
 	service := &serviceImpl{
 		cfg:        cfg,
 		repository: repo,
+		prices:     prices,
+		promotions: promotions,
+		currencies: currencies,
+		priceLists: priceLists,
 	}
-	return service
+	return &tracedService{service: service}
 }
 
-func (s *serviceImpl) BulkCreateProducts(ctx context.Context, products []Product) (CreateProductsResponse, error) {
-	result, err := s.repository.CreateProducts(ctx, products)
+func (s *serviceImpl) BulkCreateProducts(ctx context.Context, products []Product, opts CreateOptions) (CreateProductsResponse, error) {
+	writeCtx := ctx
+	if opts.Atomic {
+		if err := s.checkAtomicUpload(products); err != nil {
+			return CreateProductsResponse{}, err
+		}
+		var cancel context.CancelFunc
+		writeCtx, cancel = context.WithTimeout(ctx, s.atomicTimeout())
+		defer cancel()
+	}
+
+	result, err := s.repository.CreateProducts(writeCtx, products, opts)
 	if err != nil {
 		return CreateProductsResponse{}, err
 	}
 
+	// The products are already written, so a failure to record history must
+	// not fail the upload
+	if err := s.prices.RecordFeedPrices(ctx, priceChanges(result), uploadedIDs(result)); err != nil {
+		logging.Error(ctx, logger.Format{Message: "Failed to record price history", Data: map[string]string{"error": err.Error()}})
+	}
+
 	totalProcessed := result.Created + result.Updated
 	response := CreateProductsResponse{
 		Success:    true,
@@ -46,15 +88,108 @@ func (s *serviceImpl) BulkCreateProducts(ctx context.Context, products []Product
 	return response, nil
 }
 
+// checkAtomicUpload refuses an atomic upload too large to apply in one
+// transaction
+func (s *serviceImpl) checkAtomicUpload(products []Product) error {
+	limits := s.cfg.Get().AtomicUploads
+	maxProducts := limits.MaxProducts
+	if maxProducts <= 0 {
+		maxProducts = defaultAtomicMaxProducts
+	}
+	if len(products) > maxProducts {
+		return types.NewBatchTooLargeError(fmt.Sprintf("Atomic uploads are limited to %d products, got %d", maxProducts, len(products)))
+	}
+
+	maxBytes := limits.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultAtomicMaxBytes
+	}
+	size := 0
+	for _, product := range products {
+		document, err := bson.Marshal(product)
+		if err != nil {
+			return types.NewValidationError(fmt.Sprintf("Invalid product %s: %v", product.Name, err))
+		}
+		size += len(document)
+	}
+	if size > maxBytes {
+		return types.NewBatchTooLargeError(fmt.Sprintf("Atomic uploads are limited to %d bytes, got %d", maxBytes, size))
+	}
+	return nil
+}
+
+func (s *serviceImpl) atomicTimeout() time.Duration {
+	if timeout := s.cfg.Get().AtomicUploads.Timeout; timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return defaultAtomicTimeout
+}
+
+func (s *serviceImpl) maxPriceSorted() int {
+	if maxSorted := s.cfg.Get().Limits.Products.MaxPriceSorted; maxSorted > 0 {
+		return maxSorted
+	}
+	return defaultMaxPriceSorted
+}
+
 func (s *serviceImpl) SearchProducts(ctx context.Context, params SearchParams) (SearchProductsResponse, error) {
 
-	logger.Info(logger.Format{Message: "Searching products", Data: map[string]string{"params": fmt.Sprintf("%+v", params)}})
+	logging.Info(ctx, logger.Format{
+		Message: "Searching products",
+		Data: map[string]string{
+			"sort":      params.Sort,
+			"limit":     strconv.Itoa(params.Limit),
+			"priceList": params.PriceList,
+		},
+	})
+
+	scopes, err := readScopes(ctx)
+	if err != nil {
+		return SearchProductsResponse{}, err
+	}
+	params.Scopes = scopes
 
-	products, err := s.repository.SearchProducts(ctx, params.Categories, params.Brands, params.MinPrice, params.MaxPrice, params.SearchText, params.Limit)
+	pricer, err := s.newPricer(ctx, params.ViewOptions)
 	if err != nil {
 		return SearchProductsResponse{}, err
 	}
 
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		params = pricer.rangeParams(params)
+	}
+
+	chain := locale.Chain(params.Locales)
+	params.TextLocales = chain
+
+	limit := params.Limit
+	sortInMemory := pricer.sortsInMemory(params)
+	maxSorted := s.maxPriceSorted()
+	if sortInMemory {
+		// One more than the cap tells a search at the cap from one beyond it
+		params.Limit = maxSorted + 1
+	}
+
+	products, err := s.repository.SearchProducts(ctx, params)
+	if err != nil {
+		return SearchProductsResponse{}, err
+	}
+	if sortInMemory && len(products) > maxSorted {
+		return SearchProductsResponse{}, types.NewValidationError(fmt.Sprintf("More than %d products match; narrow the search to sort it by price in %s", maxSorted, pricer.currency))
+	}
+	for i := range products {
+		if err := pricer.apply(ctx, &products[i]); err != nil {
+			return SearchProductsResponse{}, err
+		}
+		localize(&products[i], chain)
+	}
+
+	if sortInMemory {
+		sortByListPrice(products, params.Sort)
+		if len(products) > limit {
+			products = products[:limit]
+		}
+	}
+
 	if len(products) == 0 {
 		return SearchProductsResponse{}, types.NewNotFoundError("No products found matching the search criteria")
 	}
@@ -69,13 +204,61 @@ func (s *serviceImpl) SearchProducts(ctx context.Context, params SearchParams) (
 	return response, nil
 }
 
-func (s *serviceImpl) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
-	logger.Info(logger.Format{Message: "Fetching product by ID", Data: map[string]string{"productID": productID.Hex()}})
+func (s *serviceImpl) GetProductByID(ctx context.Context, productID primitive.ObjectID, view ViewOptions) (*Product, error) {
+	logging.Info(ctx, logger.Format{Message: "Fetching product by ID", Data: map[string]string{"productID": productID.Hex()}})
+
+	pricer, err := s.newPricer(ctx, view)
+	if err != nil {
+		return nil, err
+	}
 
 	product, err := s.repository.GetProductByID(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkReadPermission(ctx, product); err != nil {
+		return nil, err
+	}
+
+	if err := pricer.apply(ctx, product); err != nil {
+		return nil, err
+	}
+	localize(product, locale.Chain(view.Locales))
 
 	return product, nil
 }
+
+func (s *serviceImpl) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	if err := checkDeletePermission(ctx, s.repository, productID); err != nil {
+		return err
+	}
+	return s.repository.DeleteProduct(ctx, productID)
+}
+
+// uploadedIDs returns the products written by the upload, whose price the
+// upload reset to their base price
+func uploadedIDs(result *CreateProductsResult) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(result.Products))
+	for _, product := range result.Products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+// priceChanges returns the products whose base price was set or changed by
+// the upload
+func priceChanges(result *CreateProductsResult) []price.Change {
+	changes := []price.Change{}
+	for _, product := range result.Products {
+		previous, existed := result.Previous[product.ID]
+		previousPrice := previous.BasePrice
+		if previousPrice.IsZero() {
+			previousPrice = previous.Price
+		}
+		if existed && previousPrice == product.BasePrice {
+			continue
+		}
+		changes = append(changes, price.Change{ProductID: product.ID, Price: product.BasePrice})
+	}
+	return changes
+}
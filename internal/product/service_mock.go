@@ -11,8 +11,8 @@ type MockService struct {
 	mock.Mock
 }
 
-func (s *MockService) BulkCreateProducts(ctx context.Context, products []Product) (CreateProductsResponse, error) {
-	ret := s.Mock.Called(ctx, products)
+func (s *MockService) BulkCreateProducts(ctx context.Context, products []Product, opts CreateOptions) (CreateProductsResponse, error) {
+	ret := s.Mock.Called(ctx, products, opts)
 	return ret.Get(0).(CreateProductsResponse), ret.Error(1)
 }
 
@@ -21,28 +21,33 @@ func (s *MockService) SearchProducts(ctx context.Context, params SearchParams) (
 	return ret.Get(0).(SearchProductsResponse), ret.Error(1)
 }
 
-func (s *MockService) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
-	ret := s.Mock.Called(ctx, productID)
+func (s *MockService) GetProductByID(ctx context.Context, productID primitive.ObjectID, view ViewOptions) (*Product, error) {
+	ret := s.Mock.Called(ctx, productID, view)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
 	return ret.Get(0).(*Product), ret.Error(1)
 }
 
+func (m *MockService) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	ret := m.Mock.Called(ctx, productID)
+	return ret.Error(0)
+}
+
 type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) CreateProducts(ctx context.Context, products []Product) (*CreateProductsResult, error) {
-	ret := m.Mock.Called(ctx, products)
+func (m *MockRepository) CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error) {
+	ret := m.Mock.Called(ctx, products, opts)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
 	return ret.Get(0).(*CreateProductsResult), ret.Error(1)
 }
 
-func (m *MockRepository) SearchProducts(ctx context.Context, categories []string, brands []string, minPrice, maxPrice *float64, searchText string, limit int) ([]Product, error) {
-	ret := m.Mock.Called(ctx, categories, brands, minPrice, maxPrice, searchText, limit)
+func (m *MockRepository) SearchProducts(ctx context.Context, params SearchParams) ([]Product, error) {
+	ret := m.Mock.Called(ctx, params)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
@@ -56,3 +61,13 @@ func (m *MockRepository) GetProductByID(ctx context.Context, productID primitive
 	}
 	return ret.Get(0).(*Product), ret.Error(1)
 }
+
+func (m *MockRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	ret := m.Mock.Called(ctx, productID)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) CountProducts(ctx context.Context) (CatalogStats, error) {
+	ret := m.Mock.Called(ctx)
+	return ret.Get(0).(CatalogStats), ret.Error(1)
+}
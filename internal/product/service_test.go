@@ -3,8 +3,16 @@ package product
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/currency"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
 	logger "github.com/roppenlabs/rapido-logger-go"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,7 +23,9 @@ import (
 
 type ProductUploadServiceTestSuite struct {
 	suite.Suite
-	config config.Config
+	config     config.Config
+	currencies *currency.MockService
+	priceLists *pricelist.MockService
 }
 
 func (mps *ProductUploadServiceTestSuite) SetupTest() {
@@ -25,6 +35,12 @@ func (mps *ProductUploadServiceTestSuite) SetupTest() {
 	}
 	mps.config = cfg
 	logger.Init(mps.config.Get().Log.Level)
+
+	rates, _ := money.NewRates("INR", map[string]string{"USD": "0.012"})
+	mps.currencies = new(currency.MockService)
+	mps.currencies.On("GetRates", mock.Anything).Return(rates, nil)
+	mps.priceLists = new(pricelist.MockService)
+	mps.priceLists.On("GetActivePriceLists", mock.Anything).Return([]pricelist.PriceList{{Code: "wholesale", Name: "Wholesale", Active: true}}, nil)
 }
 
 func TestProductUploadServiceSuite(t *testing.T) {
@@ -37,7 +53,7 @@ func (mps *ProductUploadServiceTestSuite) TestShouldReturnSuccessWithValidProduc
 			Name:        "Titan Edge 1",
 			Category:    "watch",
 			Brand:       "titan",
-			Price:       12999,
+			Price:       money.New(1299900, "INR"),
 			Description: "Titan Edge Slim Series",
 			Images:      []string{"https://cdn.example.com/titan1.png"},
 			Inventory:   20,
@@ -47,7 +63,7 @@ func (mps *ProductUploadServiceTestSuite) TestShouldReturnSuccessWithValidProduc
 			Name:        "Apple iPhone 16",
 			Category:    "mobile",
 			Brand:       "apple",
-			Price:       50000,
+			Price:       money.New(5000000, "INR"),
 			Description: "Latest iPhone model",
 			Images:      []string{"https://cdn.example.com/iphone16.png"},
 			Inventory:   5,
@@ -62,13 +78,397 @@ func (mps *ProductUploadServiceTestSuite) TestShouldReturnSuccessWithValidProduc
 		Created:    2,
 		Updated:    0,
 	}
-	mockRepo.On("CreateProducts", mock.Anything, products).Return(mockResult, nil)
+	mockRepo.On("CreateProducts", mock.Anything, products, CreateOptions{}).Return(mockResult, nil)
+	mockPrices := new(price.MockService)
+	mockPrices.On("RecordFeedPrices", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	testService := NewService(mps.config, mockRepo)
-	resp, err := testService.BulkCreateProducts(context.Background(), products)
+	testService := NewService(mps.config, mockRepo, mockPrices, new(promotion.MockService), mps.currencies, mps.priceLists)
+	resp, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{})
 
 	assert.Nil(mps.T(), err)
 	assert.Equal(mps.T(), 2, resp.Created)
 	assert.Equal(mps.T(), 0, resp.Updated)
 	assert.Equal(mps.T(), 2, len(resp.ProductIDs))
 }
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRecordOnlyChangedPrices() {
+	products := []Product{
+		{Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1199900, "INR")},
+		{Name: "Titan Edge 2", Category: "watch", Brand: "titan", Price: money.New(1499900, "INR")},
+		{Name: "Apple iPhone 16", Category: "mobile", Brand: "apple", Price: money.New(5000000, "INR")},
+	}
+	changedID, unchangedID, createdID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	mockRepo := new(MockRepository)
+	mockResult := &CreateProductsResult{
+		ProductIDs: []primitive.ObjectID{changedID, unchangedID, createdID},
+		Created:    1,
+		Updated:    2,
+		Products: []Product{
+			{ID: changedID, Price: money.New(1199900, "INR"), BasePrice: money.New(1199900, "INR")},
+			{ID: unchangedID, Price: money.New(999900, "INR"), BasePrice: money.New(1499900, "INR")},
+			{ID: createdID, Price: money.New(5000000, "INR"), BasePrice: money.New(5000000, "INR")},
+		},
+		Previous: map[primitive.ObjectID]Product{
+			changedID:   {ID: changedID, Price: money.New(1299900, "INR"), BasePrice: money.New(1299900, "INR")},
+			unchangedID: {ID: unchangedID, Price: money.New(999900, "INR"), BasePrice: money.New(1499900, "INR")},
+		},
+	}
+	mockRepo.On("CreateProducts", mock.Anything, products, CreateOptions{}).Return(mockResult, nil)
+
+	expectedChanges := []price.Change{
+		{ProductID: changedID, Price: money.New(1199900, "INR")},
+		{ProductID: createdID, Price: money.New(5000000, "INR")},
+	}
+	mockPrices := new(price.MockService)
+	mockPrices.On("RecordFeedPrices", mock.Anything, expectedChanges, []primitive.ObjectID{changedID, unchangedID, createdID}).Return(nil)
+
+	testService := NewService(mps.config, mockRepo, mockPrices, new(promotion.MockService), mps.currencies, mps.priceLists)
+	resp, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{})
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), 1, resp.Created)
+	mockPrices.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldApplyAtomicUploadWithDeadline() {
+	products := []Product{{Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1299900, "INR")}}
+
+	mockRepo := new(MockRepository)
+	withDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	mockRepo.On("CreateProducts", withDeadline, products, CreateOptions{Atomic: true}).Return(&CreateProductsResult{Created: 1}, nil)
+	mockPrices := new(price.MockService)
+	mockPrices.On("RecordFeedPrices", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	testService := NewService(mps.config, mockRepo, mockPrices, new(promotion.MockService), mps.currencies, mps.priceLists)
+	resp, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{Atomic: true})
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), 1, resp.Created)
+	mockRepo.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRejectAtomicUploadOverProductLimit() {
+	mps.config.Get().AtomicUploads.MaxProducts = 1
+	products := []Product{
+		{Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1299900, "INR")},
+		{Name: "Titan Edge 2", Category: "watch", Brand: "titan", Price: money.New(1499900, "INR")},
+	}
+
+	mockRepo := new(MockRepository)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	_, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{Atomic: true})
+
+	statusError := types.ToStatusError(err)
+	assert.Equal(mps.T(), "batch_too_large", statusError.Code)
+	assert.Equal(mps.T(), 413, statusError.HTTPCode)
+	mockRepo.AssertNotCalled(mps.T(), "CreateProducts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRejectAtomicUploadOverByteLimit() {
+	mps.config.Get().AtomicUploads.MaxBytes = 64
+	products := []Product{{Name: "Titan Edge 1", Category: "watch", Brand: "titan", Description: "Titan Edge Slim Series", Price: money.New(1299900, "INR")}}
+
+	mockRepo := new(MockRepository)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	_, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{Atomic: true})
+
+	assert.Equal(mps.T(), "batch_too_large", types.ToStatusError(err).Code)
+	mockRepo.AssertNotCalled(mps.T(), "CreateProducts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldNotLimitBestEffortUpload() {
+	mps.config.Get().AtomicUploads.MaxProducts = 1
+	products := []Product{
+		{Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1299900, "INR")},
+		{Name: "Titan Edge 2", Category: "watch", Brand: "titan", Price: money.New(1499900, "INR")},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("CreateProducts", mock.Anything, products, CreateOptions{}).Return(&CreateProductsResult{Created: 2}, nil)
+	mockPrices := new(price.MockService)
+	mockPrices.On("RecordFeedPrices", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	testService := NewService(mps.config, mockRepo, mockPrices, new(promotion.MockService), mps.currencies, mps.priceLists)
+	resp, err := testService.BulkCreateProducts(context.Background(), products, CreateOptions{})
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), 2, resp.Created)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldApplyPromotionToSearchResults() {
+	params := SearchParams{Categories: []string{"watch"}, PriceBasis: PriceBasisList, Limit: 15}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1200000, "INR")},
+	}
+	promotions := []promotion.Promotion{
+		{ID: primitive.NewObjectID(), Name: "Watch week", Type: promotion.TypePercentage, Value: 25, Categories: []string{"watch"}, StartsAt: time.Now().Add(-time.Hour), Active: true},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, params).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return(promotions, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), money.New(1200000, "INR"), *resp.Products[0].ListPrice)
+	assert.Equal(mps.T(), money.New(900000, "INR"), *resp.Products[0].SalePrice)
+	assert.Equal(mps.T(), "Watch week", resp.Products[0].Promotion.Name)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldFilterPriceRangeOnSalePrice() {
+	minPrice, maxPrice := money.New(500000, "INR"), money.New(1000000, "INR")
+	params := SearchParams{MinPrice: &minPrice, MaxPrice: &maxPrice, PriceBasis: PriceBasisSale, Limit: 15}
+	promotions := []promotion.Promotion{
+		{ID: primitive.NewObjectID(), Name: "Flat 3000", Type: promotion.TypeFlat, Value: 3000, Brands: []string{"titan"}, StartsAt: time.Now().Add(-time.Hour), Active: true},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.MatchedBy(func(p SearchParams) bool {
+		return p.MaxPrice == nil && p.MinPrice != nil && *p.MinPrice == minPrice && p.Match != nil &&
+			p.Match(Product{Brand: "titan", Price: money.New(1200000, "INR")}) &&
+			!p.Match(Product{Brand: "titan", Price: money.New(1400000, "INR")}) &&
+			!p.Match(Product{Brand: "sonata", Price: money.New(1200000, "INR")})
+	})).Return([]Product{{Brand: "titan", Price: money.New(1200000, "INR")}}, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return(promotions, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), money.New(900000, "INR"), *resp.Products[0].SalePrice)
+	mockRepo.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldPresentPricesInRequestedCurrency() {
+	params := SearchParams{ViewOptions: ViewOptions{Currency: "USD"}, Limit: 15}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1000000, "INR")},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 2", Category: "watch", Brand: "titan", Price: money.New(1000000, "INR"),
+			CurrencyPrices: []money.Money{money.New(9900, "USD")}},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.Anything).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), money.New(12000, "USD"), *resp.Products[0].ListPrice)
+	assert.Equal(mps.T(), money.New(9900, "USD"), *resp.Products[1].ListPrice)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRejectUnsupportedCurrency() {
+	params := SearchParams{ViewOptions: ViewOptions{Currency: "JPY"}, Limit: 15}
+	mockRepo := new(MockRepository)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	_, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Equal(mps.T(), types.NewValidationError("Unsupported currency JPY"), err)
+	mockRepo.AssertNotCalled(mps.T(), "SearchProducts", mock.Anything, mock.Anything)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldPriceFromRequestedPriceList() {
+	params := SearchParams{ViewOptions: ViewOptions{PriceList: "wholesale"}, Limit: 15}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Category: "watch", Brand: "titan", Price: money.New(1200000, "INR"),
+			PriceLists: map[string]money.Money{"wholesale": money.New(1000000, "INR")}},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 2", Category: "watch", Brand: "titan", Price: money.New(1400000, "INR")},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, params).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), money.New(1000000, "INR"), *resp.Products[0].ListPrice)
+	assert.Equal(mps.T(), "wholesale", resp.Products[0].PriceList)
+	assert.Equal(mps.T(), money.New(1400000, "INR"), *resp.Products[1].ListPrice)
+	assert.Equal(mps.T(), "", resp.Products[1].PriceList)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRejectUnknownPriceList() {
+	mockRepo := new(MockRepository)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	_, err := testService.GetProductByID(context.Background(), primitive.NewObjectID(), ViewOptions{PriceList: "b2b"})
+
+	assert.Equal(mps.T(), types.NewValidationError("Unknown price list b2b"), err)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldSortByListPriceInRequestedCurrency() {
+	params := SearchParams{ViewOptions: ViewOptions{Currency: "USD", PriceList: "wholesale"}, Sort: SortPriceAsc, Limit: 2}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Brand: "titan", Price: money.New(1000000, "INR"),
+			PriceLists: map[string]money.Money{"wholesale": money.New(900000, "INR")}},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 2", Brand: "titan", Price: money.New(1000000, "INR"),
+			CurrencyPrices: []money.Money{money.New(9900, "USD")}},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 3", Brand: "titan", Price: money.New(1100000, "INR"),
+			CurrencyPrices: []money.Money{money.New(9000, "USD")}},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.MatchedBy(func(p SearchParams) bool {
+		return p.Limit == defaultMaxPriceSorted+1 && p.Sort == SortPriceAsc
+	})).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), 2, resp.Count)
+	assert.Equal(mps.T(), money.New(9000, "USD"), *resp.Products[0].ListPrice)
+	assert.Equal(mps.T(), money.New(9900, "USD"), *resp.Products[1].ListPrice)
+	mockRepo.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldRejectPriceSortInCurrencyBeyondCap() {
+	mps.config.Get().Limits.Products.MaxPriceSorted = 2
+	params := SearchParams{ViewOptions: ViewOptions{Currency: "USD"}, Sort: SortPriceDesc, Limit: 1}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Brand: "titan", Price: money.New(1000000, "INR")},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 2", Brand: "titan", Price: money.New(1100000, "INR")},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 3", Brand: "titan", Price: money.New(1200000, "INR")},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.MatchedBy(func(p SearchParams) bool {
+		return p.Limit == 3
+	})).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	_, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Equal(mps.T(), types.NewValidationError("More than 2 products match; narrow the search to sort it by price in USD"), err)
+	mockRepo.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldLocalizeContentAlongFallbackChain() {
+	params := SearchParams{ViewOptions: ViewOptions{Locales: []string{"hi-IN", "ta"}}, SearchText: "घड़ी", Limit: 15}
+	products := []Product{
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 1", Description: "Slim watch", Category: "watch", Brand: "titan", Price: money.New(1200000, "INR"),
+			Translations: map[string]Translation{
+				"hi": {Name: "टाइटन एज 1"},
+				"ta": {Name: "டைட்டன் எட்ஜ் 1", Description: "மெலிதான கடிகாரம்"},
+			}},
+		{ID: primitive.NewObjectID(), Name: "Titan Edge 2", Description: "Slim watch", Category: "watch", Brand: "titan", Price: money.New(1400000, "INR")},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.MatchedBy(func(p SearchParams) bool {
+		return assert.ObjectsAreEqual([]string{"hi-IN", "hi", "ta"}, p.TextLocales)
+	})).Return(products, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	resp, err := testService.SearchProducts(context.Background(), params)
+
+	assert.Nil(mps.T(), err)
+	assert.Equal(mps.T(), "टाइटन एज 1", resp.Products[0].Name)
+	assert.Equal(mps.T(), "மெலிதான கடிகாரம்", resp.Products[0].Description)
+	assert.Equal(mps.T(), "hi", resp.Products[0].Locale)
+	assert.Nil(mps.T(), resp.Products[0].Translations)
+	assert.Equal(mps.T(), "Titan Edge 2", resp.Products[1].Name)
+	assert.Equal(mps.T(), "en", resp.Products[1].Locale)
+	mockRepo.AssertExpectations(mps.T())
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldListOnlyChangedFields() {
+	before := Product{
+		Name:      "Titan Edge 1",
+		Category:  "watch",
+		Price:     money.New(1299900, "INR"),
+		Images:    []string{"https://cdn.example.com/titan1.png"},
+		Inventory: 20,
+		Version:   3,
+	}
+	after := before
+	after.Price = money.New(1199900, "INR")
+	after.Inventory = 18
+	after.Version = 4
+
+	assert.Equal(mps.T(), []string{"price", "availableQty"}, changedFields(before, after))
+	assert.Empty(mps.T(), changedFields(before, before))
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldDeleteOnlyProductsInCallersScope() {
+	titan, fastrack := primitive.NewObjectID(), primitive.NewObjectID()
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetProductByID", mock.Anything, titan).Return(&Product{ID: titan, Brand: "titan", Category: "watch"}, nil)
+	mockRepo.On("GetProductByID", mock.Anything, fastrack).Return(&Product{ID: fastrack, Brand: "fastrack", Category: "watch"}, nil)
+	mockRepo.On("DeleteProduct", mock.Anything, titan).Return(nil)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"admin:brand=titan"}})
+
+	assert.NoError(mps.T(), testService.DeleteProduct(ctx, titan))
+	err := testService.DeleteProduct(ctx, fastrack)
+
+	assert.Equal(mps.T(), "forbidden", types.ToStatusError(err).Code)
+	mockRepo.AssertNotCalled(mps.T(), "DeleteProduct", mock.Anything, fastrack)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldDeleteWithoutLookupForUnscopedAdmin() {
+	productID := primitive.NewObjectID()
+	mockRepo := new(MockRepository)
+	mockRepo.On("DeleteProduct", mock.Anything, productID).Return(nil)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), new(promotion.MockService), mps.currencies, mps.priceLists)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"admin"}})
+
+	assert.NoError(mps.T(), testService.DeleteProduct(ctx, productID))
+	mockRepo.AssertNotCalled(mps.T(), "GetProductByID", mock.Anything, mock.Anything)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldSearchOnlyCallersReadScope() {
+	scoped := func(params SearchParams) bool {
+		return assert.ObjectsAreEqual([]auth.Grant{{Role: auth.RoleViewer, Brands: []string{"titan"}}}, params.Scopes)
+	}
+	mockRepo := new(MockRepository)
+	mockRepo.On("SearchProducts", mock.Anything, mock.MatchedBy(scoped)).Return([]Product{{ID: primitive.NewObjectID(), Brand: "titan", Price: money.New(1200000, "INR")}}, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"viewer:brand=titan"}})
+
+	resp, err := testService.SearchProducts(ctx, SearchParams{Limit: 15})
+
+	mps.Require().NoError(err)
+	assert.Equal(mps.T(), 1, resp.Count)
+}
+
+func (mps *ProductUploadServiceTestSuite) TestShouldNotFindProductOutsideCallersReadScope() {
+	titan, fastrack := primitive.NewObjectID(), primitive.NewObjectID()
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetProductByID", mock.Anything, titan).Return(&Product{ID: titan, Brand: "titan", Category: "watch", Price: money.New(1200000, "INR")}, nil)
+	mockRepo.On("GetProductByID", mock.Anything, fastrack).Return(&Product{ID: fastrack, Brand: "fastrack", Category: "watch", Price: money.New(300000, "INR")}, nil)
+	mockPromotions := new(promotion.MockService)
+	mockPromotions.On("GetActivePromotions", mock.Anything).Return([]promotion.Promotion{}, nil)
+	testService := NewService(mps.config, mockRepo, new(price.MockService), mockPromotions, mps.currencies, mps.priceLists)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{Roles: []string{"viewer:brand=titan"}})
+
+	product, err := testService.GetProductByID(ctx, titan, ViewOptions{})
+	mps.Require().NoError(err)
+	assert.Equal(mps.T(), titan, product.ID)
+
+	_, err = testService.GetProductByID(ctx, fastrack, ViewOptions{})
+	assert.Equal(mps.T(), "not_found", types.ToStatusError(err).Code)
+}
@@ -0,0 +1,152 @@
+package product
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// tracedService runs each service call in a span of its own
+type tracedService struct {
+	service Service
+}
+
+func (t *tracedService) BulkCreateProducts(ctx context.Context, products []Product, opts CreateOptions) (CreateProductsResponse, error) {
+	ctx, span := startSpan(ctx, "product.Service.BulkCreateProducts")
+	defer span.End()
+	span.SetAttribute("products.count", len(products))
+	span.SetAttribute("upload.atomic", opts.Atomic)
+
+	response, err := t.service.BulkCreateProducts(ctx, products, opts)
+	span.RecordError(err)
+	span.SetAttribute("products.created", response.Created)
+	span.SetAttribute("products.updated", response.Updated)
+	return response, err
+}
+
+func (t *tracedService) SearchProducts(ctx context.Context, params SearchParams) (SearchProductsResponse, error) {
+	ctx, span := startSpan(ctx, "product.Service.SearchProducts")
+	defer span.End()
+	setSearchAttributes(span, params)
+
+	response, err := t.service.SearchProducts(ctx, params)
+	span.RecordError(err)
+	span.SetAttribute("result.count", response.Count)
+	return response, err
+}
+
+func (t *tracedService) GetProductByID(ctx context.Context, productID primitive.ObjectID, view ViewOptions) (*Product, error) {
+	ctx, span := startSpan(ctx, "product.Service.GetProductByID")
+	defer span.End()
+	span.SetAttribute("product.id", productID.Hex())
+	setViewAttributes(span, view)
+
+	product, err := t.service.GetProductByID(ctx, productID, view)
+	span.RecordError(err)
+	return product, err
+}
+
+func (t *tracedService) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	ctx, span := startSpan(ctx, "product.Service.DeleteProduct")
+	defer span.End()
+	span.SetAttribute("product.id", productID.Hex())
+
+	err := t.service.DeleteProduct(ctx, productID)
+	span.RecordError(err)
+	return err
+}
+
+// tracedRepository runs each repository call in a span of its own; the
+// Mongo commands it sends are traced as its children
+type tracedRepository struct {
+	repository Repository
+}
+
+// NewTracedRepository is the Repository the service and workers use: the
+// cached repository, traced
+func NewTracedRepository(repository *CachedRepository) Repository {
+	return &tracedRepository{repository: repository}
+}
+
+func (t *tracedRepository) CreateProducts(ctx context.Context, products []Product, opts CreateOptions) (*CreateProductsResult, error) {
+	ctx, span := startSpan(ctx, "product.Repository.CreateProducts")
+	defer span.End()
+	span.SetAttribute("products.count", len(products))
+	span.SetAttribute("upload.atomic", opts.Atomic)
+
+	result, err := t.repository.CreateProducts(ctx, products, opts)
+	span.RecordError(err)
+	if result != nil {
+		span.SetAttribute("products.created", result.Created)
+		span.SetAttribute("products.updated", result.Updated)
+	}
+	return result, err
+}
+
+func (t *tracedRepository) SearchProducts(ctx context.Context, params SearchParams) ([]Product, error) {
+	ctx, span := startSpan(ctx, "product.Repository.SearchProducts")
+	defer span.End()
+	setSearchAttributes(span, params)
+
+	products, err := t.repository.SearchProducts(ctx, params)
+	span.RecordError(err)
+	span.SetAttribute("result.count", len(products))
+	return products, err
+}
+
+func (t *tracedRepository) GetProductByID(ctx context.Context, productID primitive.ObjectID) (*Product, error) {
+	ctx, span := startSpan(ctx, "product.Repository.GetProductByID")
+	defer span.End()
+	span.SetAttribute("product.id", productID.Hex())
+
+	product, err := t.repository.GetProductByID(ctx, productID)
+	span.RecordError(err)
+	return product, err
+}
+
+func (t *tracedRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID) error {
+	ctx, span := startSpan(ctx, "product.Repository.DeleteProduct")
+	defer span.End()
+	span.SetAttribute("product.id", productID.Hex())
+
+	err := t.repository.DeleteProduct(ctx, productID)
+	span.RecordError(err)
+	return err
+}
+
+func (t *tracedRepository) CountProducts(ctx context.Context) (CatalogStats, error) {
+	ctx, span := startSpan(ctx, "product.Repository.CountProducts")
+	defer span.End()
+
+	stats, err := t.repository.CountProducts(ctx)
+	span.RecordError(err)
+	return stats, err
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, name, tracing.KindInternal)
+	span.SetAttribute("tenant.id", tenant.ID(ctx))
+	return ctx, span
+}
+
+// setSearchAttributes records the shape of a search rather than its values,
+// which may be long or personal, so that slow searches can be told apart
+func setSearchAttributes(span *tracing.Span, params SearchParams) {
+	setViewAttributes(span, params.ViewOptions)
+	span.SetAttribute("search.categories", len(params.Categories))
+	span.SetAttribute("search.brands", len(params.Brands))
+	span.SetAttribute("search.min_price", params.MinPrice != nil)
+	span.SetAttribute("search.max_price", params.MaxPrice != nil)
+	span.SetAttribute("search.text", params.SearchText != "")
+	span.SetAttribute("search.text_locales", len(params.TextLocales))
+	span.SetAttribute("search.sort", params.Sort)
+	span.SetAttribute("search.limit", params.Limit)
+	span.SetAttribute("search.match", params.Match != nil)
+}
+
+func setViewAttributes(span *tracing.Span, view ViewOptions) {
+	span.SetAttribute("view.currency", view.Currency)
+	span.SetAttribute("view.price_list", view.PriceList)
+}
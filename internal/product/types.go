@@ -1,21 +1,80 @@
 package product
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PriceBasisList = "list"
+	PriceBasisSale = "sale"
+)
+
+const (
+	SortPopularity = "popularity"
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+)
 
 type BulkCreateProductsRequest struct {
 	Products []Product `json:"products" binding:"required"`
+	// Atomic applies every product or none; by default each product is
+	// upserted on its own and the rest still apply if one fails
+	Atomic bool `json:"atomic"`
+}
+
+// CatalogStats counts the live products of a tenant
+type CatalogStats struct {
+	Products   int
+	OutOfStock int
+}
+
+// CreateOptions selects how a bulk upload is applied
+type CreateOptions struct {
+	// Atomic applies the upload in one transaction, rolled back on any error
+	Atomic bool
 }
 
 type Product struct {
 	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	TenantID    string             `json:"-" bson:"tenantId,omitempty"`
 	Name        string             `json:"name" binding:"required" bson:"name"`
 	Category    string             `json:"category" binding:"required" bson:"category"`
 	Brand       string             `json:"brand" binding:"required" bson:"brand"`
-	Price       float64            `json:"price" binding:"required,gt=0" bson:"price"`
+	Price       money.Money        `json:"price" binding:"required" bson:"price"`
 	Description string             `json:"description" binding:"required" bson:"description"`
 	Images      []string           `json:"images" binding:"required" bson:"images"`
 	Inventory   int                `json:"inventory" binding:"required,min=0" bson:"availableQty"`
 	Popularity  float64            `json:"popularity" binding:"required" bson:"popularity"`
+	BasePrice   money.Money        `json:"-" bson:"basePrice,omitempty"`
+	// Translations holds the name and description in other locales, keyed by
+	// locale tag; Name and Description are in the default locale. Reads
+	// return the content in the caller's locale instead.
+	Translations map[string]Translation `json:"translations,omitempty" bson:"translations,omitempty"`
+	// CurrencyPrices overrides the converted price in the listed currencies
+	CurrencyPrices []money.Money `json:"currencyPrices,omitempty" bson:"currencyPrices,omitempty"`
+	// PriceLists holds the product's overrides keyed by price list code; they
+	// are managed through the price list endpoints, not the bulk upload
+	PriceLists map[string]money.Money `json:"-" bson:"priceLists,omitempty"`
+	// Version counts the writes to the product and orders its change events
+	Version int64 `json:"version,omitempty" bson:"version,omitempty"`
+
+	// Computed on read, in the requested locale, currency and price list,
+	// from the live promotions; never stored
+	Locale    string                      `json:"locale,omitempty" bson:"-"`
+	PriceList string                      `json:"priceList,omitempty" bson:"-"`
+	ListPrice *money.Money                `json:"listPrice,omitempty" bson:"-"`
+	SalePrice *money.Money                `json:"salePrice,omitempty" bson:"-"`
+	Promotion *promotion.AppliedPromotion `json:"promotion,omitempty" bson:"-"`
+}
+
+// Translation is the localized content of a product. An empty field falls
+// back to the next locale in the caller's chain.
+type Translation struct {
+	Name        string `json:"name,omitempty" bson:"name,omitempty"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
 }
 
 type CreateProductsResponse struct {
@@ -26,9 +85,12 @@ type CreateProductsResponse struct {
 	ProductIDs []primitive.ObjectID `json:"productIds,omitempty"`
 }
 
+// PriceRange bounds are in major units of the requested currency
 type PriceRange struct {
 	Min float64 `json:"min"`
 	Max float64 `json:"max"`
+	// Basis selects whether the range applies to the list or the sale price
+	Basis string `json:"basis" binding:"omitempty,oneof=list sale"`
 }
 
 type SearchProductsRequest struct {
@@ -36,16 +98,43 @@ type SearchProductsRequest struct {
 	Brand      interface{} `json:"brand"`    // Can be string or []string
 	PriceRange *PriceRange `json:"priceRange"`
 	Search     string      `json:"search"`
+	Currency   string      `json:"currency"`
+	PriceList  string      `json:"priceList"`
+	Sort       string      `json:"sort" binding:"omitempty,oneof=popularity price_asc price_desc"`
+}
+
+// ViewOptions selects how products are presented to the caller
+type ViewOptions struct {
+	Currency string
+	// PriceList is the code of the price list to price against; empty for
+	// the base price
+	PriceList string
+	// Locales are the caller's preferred locale tags, most preferred first
+	Locales []string
 }
 
 // SearchParams is the normalized internal representation used by the service
 type SearchParams struct {
+	ViewOptions
 	Categories []string
 	Brands     []string
-	MinPrice   *float64
-	MaxPrice   *float64
+	MinPrice   *money.Money
+	MaxPrice   *money.Money
+	PriceBasis string
 	SearchText string
-	Limit      int
+	// TextLocales are the translations searched before the base name and
+	// description, most preferred first
+	TextLocales []string
+	// Sort orders by popularity, or by the list price in the view's price list
+	Sort string
+	// Limit of 0 returns every match
+	Limit int
+	// Scopes, when set, limits the search to products covered by one of
+	// them, the grants of a caller whose read permission is scoped
+	Scopes []auth.Grant
+	// Match, when set, is applied to each product after the datastore filter,
+	// and products are read until Limit of them match
+	Match func(Product) bool
 }
 
 type SearchProductsResponse struct {
@@ -59,4 +148,14 @@ type CreateProductsResult struct {
 	Created    int
 	Updated    int
 	ProductIDs []primitive.ObjectID
+	// Products holds the upserted documents as stored after the write, and
+	// Previous the documents that already existed, keyed by ID
+	Products []Product
+	Previous map[primitive.ObjectID]Product
+}
+
+type CacheStatsResponse struct {
+	Success  bool             `json:"success"`
+	Products CacheStats       `json:"products"`
+	Search   SearchCacheStats `json:"search"`
 }
@@ -4,6 +4,10 @@ import "github.com/google/wire"
 
 var WireSet = wire.NewSet(
 	NewHandler,
+	NewCacheHandler,
 	NewService,
-	NewRepository,
+	NewCachedRepository,
+	NewSearchCache,
+	NewCatalogMetrics,
+	NewTracedRepository,
 )
@@ -0,0 +1,112 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+)
+
+// Evaluate prices item against the given promotions. Only one promotion is
+// applied: the highest priority one targeting the item, with ties going to
+// the larger discount and then to the older promotion. Flat discounts are
+// converted into the item's currency with rates.
+func Evaluate(promotions []Promotion, item Item, now time.Time, rates money.Rates) Pricing {
+	pricing := Pricing{
+		ListPrice: item.Price,
+		SalePrice: item.Price,
+	}
+
+	var best *Promotion
+	var bestDiscount money.Money
+	for i := range promotions {
+		candidate := &promotions[i]
+		if !candidate.IsLive(now) || !candidate.Targets(item) {
+			continue
+		}
+
+		discount, ok := candidate.Discount(item.Price, rates)
+		if !ok || discount.Amount <= 0 {
+			continue
+		}
+		if best == nil || candidate.Priority > best.Priority ||
+			(candidate.Priority == best.Priority && discount.Amount > bestDiscount.Amount) ||
+			(candidate.Priority == best.Priority && discount.Amount == bestDiscount.Amount && candidate.CreatedAt.Before(best.CreatedAt)) {
+			best = candidate
+			bestDiscount = discount
+		}
+	}
+
+	if best == nil {
+		return pricing
+	}
+
+	pricing.SalePrice = item.Price.Sub(bestDiscount)
+	pricing.Applied = &AppliedPromotion{
+		ID:       best.ID,
+		Name:     best.Name,
+		Type:     best.Type,
+		Value:    best.Value,
+		Discount: bestDiscount,
+	}
+	return pricing
+}
+
+// IsLive reports whether the promotion is enabled and its window contains now
+func (p Promotion) IsLive(now time.Time) bool {
+	if !p.Active || now.Before(p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || now.Before(*p.EndsAt)
+}
+
+// Targets reports whether item matches every target dimension the promotion
+// sets. A dimension left empty matches all items.
+func (p Promotion) Targets(item Item) bool {
+	if len(p.Categories) > 0 && !containsString(p.Categories, item.Category) {
+		return false
+	}
+	if len(p.Brands) > 0 && !containsString(p.Brands, item.Brand) {
+		return false
+	}
+	if len(p.ProductIDs) > 0 {
+		for _, productID := range p.ProductIDs {
+			if productID == item.ProductID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Discount returns the amount taken off price, never more than price itself.
+// It reports false when a flat amount cannot be converted to price's currency.
+func (p Promotion) Discount(price money.Money, rates money.Rates) (money.Money, bool) {
+	var discount money.Money
+	switch p.Type {
+	case TypePercentage:
+		discount = price.Percent(p.Value)
+	case TypeFlat:
+		converted, err := rates.Convert(money.FromMajor(p.Value, rates.Base), price.Currency)
+		if err != nil {
+			return money.Money{}, false
+		}
+		discount = converted
+	default:
+		return money.Money{}, false
+	}
+
+	if discount.Amount > price.Amount {
+		discount = price
+	}
+	return discount, true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
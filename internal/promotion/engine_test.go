@@ -0,0 +1,118 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PromotionEngineTestSuite struct {
+	suite.Suite
+	now   time.Time
+	item  Item
+	rates money.Rates
+}
+
+func (pe *PromotionEngineTestSuite) SetupTest() {
+	pe.now = time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	pe.item = Item{ProductID: primitive.NewObjectID(), Category: "watch", Brand: "titan", Price: money.New(1200000, "INR")}
+	pe.rates, _ = money.NewRates("INR", map[string]string{"USD": "0.012"})
+}
+
+func TestPromotionEngineSuite(t *testing.T) {
+	suite.Run(t, new(PromotionEngineTestSuite))
+}
+
+func (pe *PromotionEngineTestSuite) promotion(name, promotionType string, value float64, priority int) Promotion {
+	return Promotion{
+		ID:         primitive.NewObjectID(),
+		Name:       name,
+		Type:       promotionType,
+		Value:      value,
+		Categories: []string{"watch"},
+		StartsAt:   pe.now.Add(-time.Hour),
+		Priority:   priority,
+		Active:     true,
+	}
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldReturnListPriceWithoutPromotions() {
+	pricing := Evaluate(nil, pe.item, pe.now, pe.rates)
+
+	assert.Equal(pe.T(), money.New(1200000, "INR"), pricing.ListPrice)
+	assert.Equal(pe.T(), money.New(1200000, "INR"), pricing.SalePrice)
+	assert.Nil(pe.T(), pricing.Applied)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldPreferHigherPriorityOverLargerDiscount() {
+	promotions := []Promotion{
+		pe.promotion("Half off", TypePercentage, 50, 1),
+		pe.promotion("Flat 1000", TypeFlat, 1000, 5),
+	}
+
+	pricing := Evaluate(promotions, pe.item, pe.now, pe.rates)
+
+	assert.Equal(pe.T(), money.New(1100000, "INR"), pricing.SalePrice)
+	assert.Equal(pe.T(), "Flat 1000", pricing.Applied.Name)
+	assert.Equal(pe.T(), money.New(100000, "INR"), pricing.Applied.Discount)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldPreferLargerDiscountOnEqualPriority() {
+	promotions := []Promotion{
+		pe.promotion("Flat 1000", TypeFlat, 1000, 1),
+		pe.promotion("Ten percent", TypePercentage, 10, 1),
+	}
+
+	pricing := Evaluate(promotions, pe.item, pe.now, pe.rates)
+
+	assert.Equal(pe.T(), money.New(1080000, "INR"), pricing.SalePrice)
+	assert.Equal(pe.T(), "Ten percent", pricing.Applied.Name)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldIgnorePromotionsOutsideTheirWindow() {
+	ended := pe.promotion("Ended", TypePercentage, 20, 1)
+	endsAt := pe.now.Add(-time.Minute)
+	ended.EndsAt = &endsAt
+	upcoming := pe.promotion("Upcoming", TypePercentage, 20, 1)
+	upcoming.StartsAt = pe.now.Add(time.Minute)
+	disabled := pe.promotion("Disabled", TypePercentage, 20, 1)
+	disabled.Active = false
+
+	pricing := Evaluate([]Promotion{ended, upcoming, disabled}, pe.item, pe.now, pe.rates)
+
+	assert.Nil(pe.T(), pricing.Applied)
+	assert.Equal(pe.T(), money.New(1200000, "INR"), pricing.SalePrice)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldRequireEveryTargetDimensionToMatch() {
+	otherBrand := pe.promotion("Sonata watches", TypePercentage, 20, 1)
+	otherBrand.Brands = []string{"sonata"}
+	otherProduct := pe.promotion("Another product", TypePercentage, 20, 1)
+	otherProduct.Categories = nil
+	otherProduct.ProductIDs = []primitive.ObjectID{primitive.NewObjectID()}
+
+	pricing := Evaluate([]Promotion{otherBrand, otherProduct}, pe.item, pe.now, pe.rates)
+
+	assert.Nil(pe.T(), pricing.Applied)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldNotDiscountBelowZero() {
+	pricing := Evaluate([]Promotion{pe.promotion("Huge", TypeFlat, 50000, 1)}, pe.item, pe.now, pe.rates)
+
+	assert.Equal(pe.T(), money.New(0, "INR"), pricing.SalePrice)
+	assert.Equal(pe.T(), money.New(1200000, "INR"), pricing.Applied.Discount)
+}
+
+func (pe *PromotionEngineTestSuite) TestShouldConvertFlatDiscountToItemCurrency() {
+	item := pe.item
+	item.Price = money.New(14400, "USD")
+
+	pricing := Evaluate([]Promotion{pe.promotion("Flat 1000", TypeFlat, 1000, 1)}, item, pe.now, pe.rates)
+
+	assert.Equal(pe.T(), money.New(1200, "USD"), pricing.Applied.Discount)
+	assert.Equal(pe.T(), money.New(13200, "USD"), pricing.SalePrice)
+}
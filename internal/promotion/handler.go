@@ -0,0 +1,128 @@
+package promotion
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) CreatePromotionHandler(ctx *gin.Context) {
+	var req PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(ctx.Request.Context(), req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, PromotionResponse{
+		Success:   true,
+		Message:   "Promotion created",
+		Promotion: *promotion,
+	})
+}
+
+func (h *Handler) UpdatePromotionHandler(ctx *gin.Context) {
+	promotionID, ok := parsePromotionID(ctx)
+	if !ok {
+		return
+	}
+
+	var req PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	promotion, err := h.service.UpdatePromotion(ctx.Request.Context(), promotionID, req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PromotionResponse{
+		Success:   true,
+		Message:   "Promotion updated",
+		Promotion: *promotion,
+	})
+}
+
+func (h *Handler) DeletePromotionHandler(ctx *gin.Context) {
+	promotionID, ok := parsePromotionID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePromotion(ctx.Request.Context(), promotionID); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetPromotionByIDHandler(ctx *gin.Context) {
+	promotionID, ok := parsePromotionID(ctx)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.service.GetPromotionByID(ctx.Request.Context(), promotionID)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, promotion)
+}
+
+func (h *Handler) ListPromotionsHandler(ctx *gin.Context) {
+	promotions, err := h.service.ListPromotions(ctx.Request.Context())
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListPromotionsResponse{
+		Success:    true,
+		Count:      len(promotions),
+		Promotions: promotions,
+	})
+}
+
+func parsePromotionID(ctx *gin.Context) (primitive.ObjectID, bool) {
+	promotionID, err := primitive.ObjectIDFromHex(ctx.Param("promotionId"))
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid promotion ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid promotion ID format")))
+		return primitive.NilObjectID, false
+	}
+	return promotionID, true
+}
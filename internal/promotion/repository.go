@@ -0,0 +1,174 @@
+package promotion
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreatePromotion(ctx context.Context, promotion Promotion) (*Promotion, error)
+	UpdatePromotion(ctx context.Context, promotion Promotion) (*Promotion, error)
+	DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error
+	GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*Promotion, error)
+	ListPromotions(ctx context.Context) ([]Promotion, error)
+	GetActivePromotions(ctx context.Context, now time.Time) ([]Promotion, error)
+}
+
+type repositoryImpl struct {
+	db         *utils.DBInstance
+	collection *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:         db,
+		collection: db.Collection("rapidPromotions"),
+	}
+}
+
+func (r *repositoryImpl) CreatePromotion(ctx context.Context, promotion Promotion) (*Promotion, error) {
+	promotion.TenantID = tenant.ID(ctx)
+	var result *mongo.InsertOneResult
+	err := r.db.Write(ctx, "promotion.CreatePromotion", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.InsertOne(ctx, promotion)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error creating promotion",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	promotion.ID = result.InsertedID.(primitive.ObjectID)
+	return &promotion, nil
+}
+
+func (r *repositoryImpl) UpdatePromotion(ctx context.Context, promotion Promotion) (*Promotion, error) {
+	promotion.TenantID = tenant.ID(ctx)
+	var result *mongo.UpdateResult
+	err := r.db.Write(ctx, "promotion.UpdatePromotion", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.ReplaceOne(ctx, tenant.Filter(ctx, bson.M{"_id": promotion.ID}), promotion)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error updating promotion",
+			Data: map[string]string{
+				"error":       err.Error(),
+				"promotionID": promotion.ID.Hex(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, types.NewNotFoundError("Promotion not found")
+	}
+
+	return &promotion, nil
+}
+
+func (r *repositoryImpl) DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error {
+	var result *mongo.DeleteResult
+	err := r.db.Write(ctx, "promotion.DeletePromotion", func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteOne(ctx, tenant.Filter(ctx, bson.M{"_id": promotionID}))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error deleting promotion",
+			Data: map[string]string{
+				"error":       err.Error(),
+				"promotionID": promotionID.Hex(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+	if result.DeletedCount == 0 {
+		return types.NewNotFoundError("Promotion not found")
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*Promotion, error) {
+	var promotion Promotion
+	err := r.db.Read(ctx, "promotion.GetPromotionByID", func(ctx context.Context) error {
+		return r.collection.FindOne(ctx, tenant.Filter(ctx, bson.M{"_id": promotionID})).Decode(&promotion)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, types.NewNotFoundError("Promotion not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching promotion by ID",
+			Data: map[string]string{
+				"error":       err.Error(),
+				"promotionID": promotionID.Hex(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &promotion, nil
+}
+
+func (r *repositoryImpl) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "startsAt", Value: -1}})
+	return r.find(ctx, "promotion.ListPromotions", bson.M{}, findOptions)
+}
+
+func (r *repositoryImpl) GetActivePromotions(ctx context.Context, now time.Time) ([]Promotion, error) {
+	filter := bson.M{
+		"active":   true,
+		"startsAt": bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"endsAt": bson.M{"$exists": false}},
+			{"endsAt": bson.M{"$gt": now}},
+		},
+	}
+	return r.find(ctx, "promotion.GetActivePromotions", filter, options.Find())
+}
+
+func (r *repositoryImpl) find(ctx context.Context, operation string, filter bson.M, findOptions *options.FindOptions) ([]Promotion, error) {
+	promotions := []Promotion{}
+	err := r.db.Read(ctx, operation, func(ctx context.Context) error {
+		cursor, err := r.collection.Find(ctx, tenant.Filter(ctx, filter), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &promotions)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching promotions",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return promotions, nil
+}
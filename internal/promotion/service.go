@@ -0,0 +1,184 @@
+package promotion
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+type Service interface {
+	CreatePromotion(ctx context.Context, req PromotionRequest) (*Promotion, error)
+	UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, req PromotionRequest) (*Promotion, error)
+	DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error
+	GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*Promotion, error)
+	ListPromotions(ctx context.Context) ([]Promotion, error)
+	GetActivePromotions(ctx context.Context) ([]Promotion, error)
+}
+
+type serviceImpl struct {
+	repository Repository
+	cacheTTL   time.Duration
+
+	mu sync.Mutex
+	// active caches each tenant's live promotions, keyed by tenant ID
+	active map[string]activePromotions
+}
+
+type activePromotions struct {
+	promotions []Promotion
+	expiresAt  time.Time
+}
+
+func NewService(cfg config.Config, repo Repository) Service {
+	cacheTTL := time.Duration(cfg.Get().Promotions.CacheTTL) * time.Second
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	return &serviceImpl{
+		repository: repo,
+		cacheTTL:   cacheTTL,
+		active:     map[string]activePromotions{},
+	}
+}
+
+func (s *serviceImpl) CreatePromotion(ctx context.Context, req PromotionRequest) (*Promotion, error) {
+	if err := validatePromotion(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	promotion := fromRequest(req)
+	promotion.CreatedAt = now
+	promotion.UpdatedAt = now
+
+	created, err := s.repository.CreatePromotion(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Promotion created", Data: map[string]string{"promotionID": created.ID.Hex(), "name": created.Name}})
+	return created, nil
+}
+
+func (s *serviceImpl) UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, req PromotionRequest) (*Promotion, error) {
+	if err := validatePromotion(req); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.GetPromotionByID(ctx, promotionID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := fromRequest(req)
+	promotion.ID = promotionID
+	promotion.CreatedAt = existing.CreatedAt
+	promotion.UpdatedAt = time.Now().UTC()
+
+	updated, err := s.repository.UpdatePromotion(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Promotion updated", Data: map[string]string{"promotionID": promotionID.Hex()}})
+	return updated, nil
+}
+
+func (s *serviceImpl) DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error {
+	if err := s.repository.DeletePromotion(ctx, promotionID); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx)
+	logging.Info(ctx, logger.Format{Message: "Promotion deleted", Data: map[string]string{"promotionID": promotionID.Hex()}})
+	return nil
+}
+
+func (s *serviceImpl) GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*Promotion, error) {
+	return s.repository.GetPromotionByID(ctx, promotionID)
+}
+
+func (s *serviceImpl) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	return s.repository.ListPromotions(ctx)
+}
+
+// GetActivePromotions returns the promotions live at the time of the call.
+// The set is read on every product search and lookup, so it is cached for
+// cacheTTL per tenant and refreshed after any change made through this
+// service.
+func (s *serviceImpl) GetActivePromotions(ctx context.Context) ([]Promotion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	tenantID := tenant.ID(ctx)
+	if cached, ok := s.active[tenantID]; ok && now.Before(cached.expiresAt) {
+		return cached.promotions, nil
+	}
+
+	active, err := s.repository.GetActivePromotions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.active[tenantID] = activePromotions{promotions: active, expiresAt: now.Add(s.cacheTTL)}
+	return active, nil
+}
+
+func (s *serviceImpl) invalidate(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, tenant.ID(ctx))
+}
+
+func validatePromotion(req PromotionRequest) *types.StatusError {
+	if strings.TrimSpace(req.Name) == "" {
+		return types.NewValidationError("name cannot be empty")
+	}
+	if req.Type == TypePercentage && req.Value > 100 {
+		return types.NewValidationError("percentage discount cannot exceed 100")
+	}
+	if len(req.Categories) == 0 && len(req.Brands) == 0 && len(req.ProductIDs) == 0 {
+		return types.NewValidationError("promotion must target at least one category, brand or product")
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(req.StartsAt) {
+		return types.NewValidationError("endsAt must be after startsAt")
+	}
+	return nil
+}
+
+func fromRequest(req PromotionRequest) Promotion {
+	promotion := Promotion{
+		Name:       strings.TrimSpace(req.Name),
+		Type:       req.Type,
+		Value:      req.Value,
+		Categories: req.Categories,
+		Brands:     req.Brands,
+		ProductIDs: req.ProductIDs,
+		StartsAt:   req.StartsAt.UTC(),
+		Priority:   req.Priority,
+		Active:     true,
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		promotion.EndsAt = &endsAt
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+	return promotion
+}
@@ -0,0 +1,57 @@
+package promotion
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) CreatePromotion(ctx context.Context, req PromotionRequest) (*Promotion, error) {
+	ret := s.Mock.Called(ctx, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Promotion), ret.Error(1)
+}
+
+func (s *MockService) UpdatePromotion(ctx context.Context, promotionID primitive.ObjectID, req PromotionRequest) (*Promotion, error) {
+	ret := s.Mock.Called(ctx, promotionID, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Promotion), ret.Error(1)
+}
+
+func (s *MockService) DeletePromotion(ctx context.Context, promotionID primitive.ObjectID) error {
+	ret := s.Mock.Called(ctx, promotionID)
+	return ret.Error(0)
+}
+
+func (s *MockService) GetPromotionByID(ctx context.Context, promotionID primitive.ObjectID) (*Promotion, error) {
+	ret := s.Mock.Called(ctx, promotionID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Promotion), ret.Error(1)
+}
+
+func (s *MockService) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	ret := s.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Promotion), ret.Error(1)
+}
+
+func (s *MockService) GetActivePromotions(ctx context.Context) ([]Promotion, error) {
+	ret := s.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Promotion), ret.Error(1)
+}
@@ -0,0 +1,84 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TypePercentage = "percentage"
+	TypeFlat       = "flat"
+)
+
+// Promotion discounts every product matching all of its non-empty targets
+// (categories, brands, product IDs) while its time window is open. When
+// several promotions match a product, the highest priority wins. Value is a
+// percentage, or for flat discounts an amount in major units of the base
+// currency.
+type Promotion struct {
+	ID         primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	TenantID   string               `json:"-" bson:"tenantId"`
+	Name       string               `json:"name" bson:"name"`
+	Type       string               `json:"type" bson:"type"`
+	Value      float64              `json:"value" bson:"value"`
+	Categories []string             `json:"categories,omitempty" bson:"categories,omitempty"`
+	Brands     []string             `json:"brands,omitempty" bson:"brands,omitempty"`
+	ProductIDs []primitive.ObjectID `json:"productIds,omitempty" bson:"productIds,omitempty"`
+	StartsAt   time.Time            `json:"startsAt" bson:"startsAt"`
+	EndsAt     *time.Time           `json:"endsAt,omitempty" bson:"endsAt,omitempty"`
+	Priority   int                  `json:"priority" bson:"priority"`
+	Active     bool                 `json:"active" bson:"active"`
+	CreatedAt  time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time            `json:"updatedAt" bson:"updatedAt"`
+}
+
+type PromotionRequest struct {
+	Name       string               `json:"name" binding:"required"`
+	Type       string               `json:"type" binding:"required,oneof=percentage flat"`
+	Value      float64              `json:"value" binding:"required,gt=0"`
+	Categories []string             `json:"categories"`
+	Brands     []string             `json:"brands"`
+	ProductIDs []primitive.ObjectID `json:"productIds"`
+	StartsAt   time.Time            `json:"startsAt" binding:"required"`
+	EndsAt     *time.Time           `json:"endsAt"`
+	Priority   int                  `json:"priority"`
+	Active     *bool                `json:"active"`
+}
+
+// Item is the subset of a product the engine needs to price it
+type Item struct {
+	ProductID primitive.ObjectID
+	Category  string
+	Brand     string
+	Price     money.Money
+}
+
+// AppliedPromotion describes the promotion that produced a sale price
+type AppliedPromotion struct {
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"name"`
+	Type     string             `json:"type"`
+	Value    float64            `json:"value"`
+	Discount money.Money        `json:"discount"`
+}
+
+// Pricing is the outcome of evaluating promotions against an Item
+type Pricing struct {
+	ListPrice money.Money
+	SalePrice money.Money
+	Applied   *AppliedPromotion
+}
+
+type PromotionResponse struct {
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	Promotion Promotion `json:"promotion"`
+}
+
+type ListPromotionsResponse struct {
+	Success    bool        `json:"success"`
+	Count      int         `json:"count"`
+	Promotions []Promotion `json:"promotions"`
+}
@@ -0,0 +1,9 @@
+package promotion
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+)
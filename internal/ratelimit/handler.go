@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const (
+	LimitHeader      = "RateLimit-Limit"
+	RemainingHeader  = "RateLimit-Remaining"
+	ResetHeader      = "RateLimit-Reset"
+	PolicyHeader     = "RateLimit-Policy"
+	RetryAfterHeader = "Retry-After"
+)
+
+// unmatchedRoute labels requests matching no route, as the HTTP metrics do
+const unmatchedRoute = "unmatched"
+
+var rateLimitedRequests = metrics.NewCounterVec("http_requests_rate_limited_total",
+	"HTTP requests refused for exceeding their client's rate limit, by route.", "method", "route")
+
+type Handler struct {
+	enabled         bool
+	fallback        Limit
+	limits          map[string]Limit
+	unauthenticated Limit
+	limiter         *Limiter
+}
+
+// NewHandler reads the limit of each route, panicking on negative limits so
+// that a typo does not leave a route unlimited
+func NewHandler(cfg config.Config, limiter *Limiter) *Handler {
+	rateLimitConfig := cfg.Get().RateLimit
+	handler := &Handler{
+		enabled:  rateLimitConfig.Enabled,
+		fallback: parseLimit("default", rateLimitConfig.Default.Rate, rateLimitConfig.Default.Burst),
+		limits:   map[string]Limit{},
+		unauthenticated: parseLimit("unauthenticated",
+			rateLimitConfig.Unauthenticated.Rate, rateLimitConfig.Unauthenticated.Burst),
+		limiter: limiter,
+	}
+	for _, route := range rateLimitConfig.Routes {
+		handler.limits[strings.Join(strings.Fields(route.Route), " ")] = parseLimit(route.Route, route.Rate, route.Burst)
+	}
+	return handler
+}
+
+func parseLimit(route string, rate float64, burst int) Limit {
+	if rate < 0 || burst < 0 {
+		panic(fmt.Sprintf("negative rate limit for %s", route))
+	}
+	if burst == 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return Limit{Rate: rate, Burst: burst}
+}
+
+// Middleware refuses requests beyond their client's limit for the route with
+// 429. Clients are told their remaining quota in the RateLimit headers, and
+// refused clients when to retry in Retry-After.
+func (h *Handler) Middleware(ctx *gin.Context) {
+	if !h.enabled {
+		ctx.Next()
+		return
+	}
+	route := ctx.FullPath()
+	limit, ok := h.limits[ctx.Request.Method+" "+route]
+	if !ok {
+		limit = h.fallback
+	}
+	if limit.Rate == 0 {
+		ctx.Next()
+		return
+	}
+
+	decision := h.limiter.Take(ctx.Request.Method+" "+route+" "+clientKey(ctx), limit)
+	ctx.Header(LimitHeader, strconv.Itoa(decision.Limit))
+	ctx.Header(RemainingHeader, strconv.Itoa(decision.Remaining))
+	ctx.Header(ResetHeader, strconv.Itoa(wholeSeconds(decision.Reset)))
+	ctx.Header(PolicyHeader, fmt.Sprintf("%d;w=%d", limit.Burst, wholeSeconds(secondsOf(float64(limit.Burst)/limit.Rate))))
+	if decision.Allowed {
+		ctx.Next()
+		return
+	}
+
+	h.refuse(ctx, decision)
+}
+
+// Unauthenticated refuses requests from an address that has failed to
+// authenticate more often than the unauthenticated limit allows, before
+// their credentials are looked up. Each request answered with 401 takes a
+// token from the address's bucket; requests in flight when it runs out are
+// still served.
+func (h *Handler) Unauthenticated(ctx *gin.Context) {
+	if !h.enabled || h.unauthenticated.Rate == 0 {
+		ctx.Next()
+		return
+	}
+
+	key := "unauthenticated ip:" + ctx.ClientIP()
+	if decision := h.limiter.Peek(key, h.unauthenticated); !decision.Allowed {
+		h.refuse(ctx, decision)
+		return
+	}
+	ctx.Next()
+	if ctx.Writer.Status() == http.StatusUnauthorized {
+		h.limiter.Take(key, h.unauthenticated)
+	}
+}
+
+func (h *Handler) refuse(ctx *gin.Context, decision Decision) {
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	rateLimitedRequests.Inc(ctx.Request.Method, route)
+	retryAfter := strconv.Itoa(int(math.Max(1, float64(wholeSeconds(decision.RetryAfter)))))
+	logging.Debug(ctx.Request.Context(), logger.Format{
+		Message: "Rejected rate limited request",
+		Data: map[string]string{
+			"route":      ctx.Request.Method + " " + route,
+			"retryAfter": retryAfter,
+		},
+	})
+	ctx.Header(RetryAfterHeader, retryAfter)
+	statusError := types.NewTooManyRequestsError(fmt.Sprintf("Rate limit exceeded; retry in %s seconds", retryAfter))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, requestid.ErrorResponse(ctx, statusError))
+}
+
+// clientKey names the client of a request: the caller it authenticated as,
+// else its address. Credentials that were not verified, such as an API key
+// sent while authentication is off, are ignored, so that a client cannot
+// get a fresh quota by sending a new one.
+func clientKey(ctx *gin.Context) string {
+	if identity, ok := auth.Current(ctx); ok {
+		return "caller:" + identity.Subject
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+func wholeSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
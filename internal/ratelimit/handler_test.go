@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitHandlerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	values *config.Values
+	now    time.Time
+}
+
+func (rh *RateLimitHandlerTestSuite) SetupTest() {
+	logger.Init("debug")
+	rh.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rh.values = &config.Values{RateLimit: config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateConfig{Rate: 10, Burst: 20},
+		Routes: []config.RouteRateLimitConfig{
+			{Route: "POST  /products/bulk", Rate: 0.5, Burst: 2},
+			{Route: "GET /tenant", Rate: 0},
+		},
+		Unauthenticated: config.RateConfig{Rate: 0.1, Burst: 3},
+	}}
+	rh.setup()
+}
+
+func (rh *RateLimitHandlerTestSuite) setup() {
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return rh.now }
+	handler := NewHandler(rh.values, limiter)
+	rh.router = testutils.NewServer().Router()
+
+	router := rh.router
+	router.Use(handler.Unauthenticated)
+	router.Use(func(ctx *gin.Context) {
+		if ctx.GetHeader(auth.APIKeyHeader) == "rpc_guessed" {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if subject := ctx.GetHeader("X-Test-Subject"); subject != "" {
+			auth.SetIdentity(ctx, auth.Identity{Subject: subject})
+		}
+	})
+	router.Use(handler.Middleware)
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	router.GET("/tenant", ok)
+	router.POST("/products/bulk", ok)
+	router.POST("/products/search", ok)
+}
+
+func TestRateLimitHandlerSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitHandlerTestSuite))
+}
+
+func (rh *RateLimitHandlerTestSuite) perform(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "203.0.113.7:41000"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	rh.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldReportRemainingQuota() {
+	recorder := rh.perform(http.MethodPost, "/products/bulk", nil)
+
+	assert.Equal(rh.T(), http.StatusOK, recorder.Code)
+	assert.Equal(rh.T(), "2", recorder.Header().Get(LimitHeader))
+	assert.Equal(rh.T(), "1", recorder.Header().Get(RemainingHeader))
+	assert.Equal(rh.T(), "2", recorder.Header().Get(ResetHeader))
+	assert.Equal(rh.T(), "2;w=4", recorder.Header().Get(PolicyHeader))
+	assert.Empty(rh.T(), recorder.Header().Get(RetryAfterHeader))
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldRefuseRequestsBeyondRouteLimit() {
+	rh.perform(http.MethodPost, "/products/bulk", nil)
+	rh.perform(http.MethodPost, "/products/bulk", nil)
+
+	recorder := rh.perform(http.MethodPost, "/products/bulk", nil)
+
+	assert.Equal(rh.T(), http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(rh.T(), "2", recorder.Header().Get(RetryAfterHeader))
+	assert.Equal(rh.T(), "0", recorder.Header().Get(RemainingHeader))
+	var response types.ErrorResponse
+	rh.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(rh.T(), "rate_limited", response.Error.Code)
+	var exposed bytes.Buffer
+	metrics.Default.Write(&exposed)
+	assert.Contains(rh.T(), exposed.String(), `http_requests_rate_limited_total{method="POST",route="/products/bulk"}`)
+	assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodPost, "/products/search", nil).Code, "other routes keep their own quota")
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldLimitEachClientSeparately() {
+	for i := 0; i < 2; i++ {
+		rh.perform(http.MethodPost, "/products/bulk", map[string]string{"X-Test-Subject": "apikey:scraper"})
+	}
+
+	assert.Equal(rh.T(), http.StatusTooManyRequests, rh.perform(http.MethodPost, "/products/bulk", map[string]string{"X-Test-Subject": "apikey:scraper"}).Code)
+	assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodPost, "/products/bulk", map[string]string{"X-Test-Subject": "apikey:pim-sync"}).Code)
+	assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodPost, "/products/bulk", nil).Code)
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldLimitUnverifiedAPIKeysByAddress() {
+	for i := 0; i < 2; i++ {
+		rh.perform(http.MethodPost, "/products/bulk", map[string]string{auth.APIKeyHeader: fmt.Sprintf("rpc_random%d", i)})
+	}
+
+	recorder := rh.perform(http.MethodPost, "/products/bulk", map[string]string{auth.APIKeyHeader: "rpc_random2"})
+
+	assert.Equal(rh.T(), http.StatusTooManyRequests, recorder.Code)
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldServeAgainOnceTokensRefill() {
+	for i := 0; i < 3; i++ {
+		rh.perform(http.MethodPost, "/products/bulk", nil)
+	}
+
+	rh.now = rh.now.Add(2 * time.Second)
+
+	assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodPost, "/products/bulk", nil).Code)
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldLeaveRouteWithoutRateUnlimited() {
+	recorder := rh.perform(http.MethodGet, "/tenant", nil)
+
+	assert.Equal(rh.T(), http.StatusOK, recorder.Code)
+	assert.Empty(rh.T(), recorder.Header().Get(LimitHeader))
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldLeaveRoutesUnlimitedWhenDisabled() {
+	rh.values.RateLimit.Enabled = false
+	rh.setup()
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodPost, "/products/bulk", nil).Code)
+	}
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldRefuseToStartWithNegativeLimit() {
+	rh.values.RateLimit.Routes = []config.RouteRateLimitConfig{{Route: "POST /products/bulk", Rate: -1}}
+
+	assert.Panics(rh.T(), func() { NewHandler(rh.values, NewLimiter()) })
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldDefaultBurstToOneSecondOfRequests() {
+	assert.Equal(rh.T(), Limit{Rate: 5, Burst: 5}, parseLimit("default", 5, 0))
+	assert.Equal(rh.T(), Limit{Rate: 0.2, Burst: 1}, parseLimit("default", 0.2, 0))
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldRefuseAddressThatKeepsFailingToAuthenticate() {
+	guessed := map[string]string{auth.APIKeyHeader: "rpc_guessed"}
+	for i := 0; i < 3; i++ {
+		assert.Equal(rh.T(), http.StatusUnauthorized, rh.perform(http.MethodGet, "/tenant", guessed).Code)
+	}
+
+	recorder := rh.perform(http.MethodGet, "/tenant", guessed)
+
+	assert.Equal(rh.T(), http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(rh.T(), "10", recorder.Header().Get(RetryAfterHeader))
+	assert.Equal(rh.T(), http.StatusTooManyRequests, rh.perform(http.MethodGet, "/tenant", nil).Code, "the address is refused whatever it sends")
+
+	rh.now = rh.now.Add(10 * time.Second)
+	assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodGet, "/tenant", nil).Code)
+}
+
+func (rh *RateLimitHandlerTestSuite) TestShouldNotCountAuthenticatedRequestsAgainstAddress() {
+	for i := 0; i < 10; i++ {
+		assert.Equal(rh.T(), http.StatusOK, rh.perform(http.MethodGet, "/tenant", map[string]string{"X-Test-Subject": "apikey:pim-sync"}).Code)
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have filled up again are dropped,
+// since a full bucket allows as much as a missing one
+const sweepInterval = time.Minute
+
+// Limit lets a client send Rate requests per second, and up to Burst at once
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Decision is the outcome of taking a token from a client's bucket
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until a refused client may send again
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	limit   Limit
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	}
+	b.updated = now
+}
+
+// Limiter holds a token bucket per key in memory, so each instance limits
+// the requests it serves itself
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of key, which holds limit.Burst tokens
+// and earns limit.Rate a second, and reports whether there was one
+func (l *Limiter) Take(key string, limit Limit) Decision {
+	return l.take(key, limit, true)
+}
+
+// Peek reports whether the bucket of key holds a token, leaving it there
+func (l *Limiter) Peek(key string, limit Limit) Decision {
+	return l.take(key, limit, false)
+}
+
+func (l *Limiter) take(key string, limit Limit, consume bool) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	decision := Decision{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsOf((1 - b.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = secondsOf((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return decision
+}
+
+// sweep drops the buckets that have filled up again; callers hold l.mu
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func secondsOf(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LimiterTestSuite struct {
+	suite.Suite
+	limiter *Limiter
+	now     time.Time
+}
+
+func (lt *LimiterTestSuite) SetupTest() {
+	lt.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lt.limiter = NewLimiter()
+	lt.limiter.now = func() time.Time { return lt.now }
+}
+
+func TestLimiterSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
+
+func (lt *LimiterTestSuite) TestShouldAllowBurstThenRefuse() {
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		assert.True(lt.T(), lt.limiter.Take("client", limit).Allowed)
+	}
+	decision := lt.limiter.Take("client", limit)
+
+	assert.False(lt.T(), decision.Allowed)
+	assert.Equal(lt.T(), Decision{Limit: 3, Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}, decision)
+}
+
+func (lt *LimiterTestSuite) TestShouldRefillAtRate() {
+	limit := Limit{Rate: 0.5, Burst: 1}
+	lt.limiter.Take("client", limit)
+
+	lt.now = lt.now.Add(time.Second)
+	refused := lt.limiter.Take("client", limit)
+	lt.now = lt.now.Add(time.Second)
+	allowed := lt.limiter.Take("client", limit)
+
+	assert.False(lt.T(), refused.Allowed)
+	assert.Equal(lt.T(), time.Second, refused.RetryAfter)
+	assert.True(lt.T(), allowed.Allowed)
+}
+
+func (lt *LimiterTestSuite) TestShouldKeepSeparateBucketsPerKey() {
+	limit := Limit{Rate: 1, Burst: 1}
+
+	assert.True(lt.T(), lt.limiter.Take("scraper", limit).Allowed)
+	assert.False(lt.T(), lt.limiter.Take("scraper", limit).Allowed)
+	assert.True(lt.T(), lt.limiter.Take("storefront", limit).Allowed)
+}
+
+func (lt *LimiterTestSuite) TestShouldDropBucketsThatFilledUp() {
+	limit := Limit{Rate: 1, Burst: 10}
+	lt.limiter.Take("idle", limit)
+	lt.now = lt.now.Add(sweepInterval)
+	for i := 0; i < 5; i++ {
+		lt.limiter.Take("busy", limit)
+	}
+
+	lt.now = lt.now.Add(sweepInterval)
+	lt.limiter.Take("busy", limit)
+
+	assert.Len(lt.T(), lt.limiter.buckets, 1)
+	assert.Contains(lt.T(), lt.limiter.buckets, "busy")
+}
+
+func (lt *LimiterTestSuite) TestShouldAllowExactlyBurstToConcurrentRequests() {
+	limit := Limit{Rate: 1, Burst: 50}
+	var allowed int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < 500; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if lt.limiter.Take("client", limit).Allowed {
+				atomic.AddInt64(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(lt.T(), int64(50), allowed)
+}
+
+func (lt *LimiterTestSuite) TestShouldPeekWithoutTakingToken() {
+	limit := Limit{Rate: 1, Burst: 1}
+
+	assert.True(lt.T(), lt.limiter.Peek("client", limit).Allowed)
+	assert.True(lt.T(), lt.limiter.Take("client", limit).Allowed)
+	assert.False(lt.T(), lt.limiter.Peek("client", limit).Allowed)
+}
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewLimiter,
+)
@@ -0,0 +1,76 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Header carries the request ID in requests and responses
+const Header = "X-Request-ID"
+
+// Key is the gin context key the request ID is set under
+const Key = "requestId"
+
+// maxLength bounds accepted IDs, which are written to every log line
+const maxLength = 128
+
+type contextKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID of the request ctx serves, or "" outside a
+// request
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a fresh request ID
+func New() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// Valid reports whether a caller's ID can be accepted as is: IDs are
+// written to logs and response headers, so only printable characters that
+// need no escaping are allowed
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware accepts the caller's X-Request-ID, or assigns one, threads it
+// through the request's context and echoes it in the response
+func Middleware(ctx *gin.Context) {
+	id := ctx.GetHeader(Header)
+	if !Valid(id) {
+		id = New()
+	}
+
+	ctx.Set(Key, id)
+	ctx.Header(Header, id)
+	ctx.Request = ctx.Request.WithContext(WithID(ctx.Request.Context(), id))
+	ctx.Next()
+}
+
+// ErrorResponse is the standard error body for err, carrying the ID of the
+// request so that a caller can quote it
+func ErrorResponse(ctx *gin.Context, err *types.StatusError) types.ErrorResponse {
+	response := types.NewErrorResponse(err)
+	response.RequestID = ctx.GetString(Key)
+	return response
+}
@@ -0,0 +1,71 @@
+package requestid
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RequestIDTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	seen   string
+}
+
+func (rt *RequestIDTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	rt.seen = ""
+	rt.router = gin.New()
+	rt.router.Use(Middleware)
+	rt.router.GET("/products/:productId", func(ctx *gin.Context) {
+		rt.seen = FromContext(ctx.Request.Context())
+		ctx.JSON(http.StatusNotFound, ErrorResponse(ctx, types.NewNotFoundError("Product not found")))
+	})
+}
+
+func TestRequestIDSuite(t *testing.T) {
+	suite.Run(t, new(RequestIDTestSuite))
+}
+
+func (rt *RequestIDTestSuite) get(requestID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/products/abc", nil)
+	if requestID != "" {
+		request.Header.Set(Header, requestID)
+	}
+	recorder := httptest.NewRecorder()
+	rt.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (rt *RequestIDTestSuite) TestShouldAcceptCallersRequestID() {
+	recorder := rt.get("checkout-7f3a:2")
+
+	assert.Equal(rt.T(), "checkout-7f3a:2", rt.seen)
+	assert.Equal(rt.T(), "checkout-7f3a:2", recorder.Header().Get(Header))
+}
+
+func (rt *RequestIDTestSuite) TestShouldAssignRequestIDWhenAbsentOrInvalid() {
+	for _, requestID := range []string{"", "has spaces", "line\nbreak", strings.Repeat("a", maxLength+1)} {
+		recorder := rt.get(requestID)
+
+		assert.Len(rt.T(), rt.seen, 24, requestID)
+		assert.NotEqual(rt.T(), requestID, rt.seen)
+		assert.Equal(rt.T(), rt.seen, recorder.Header().Get(Header))
+	}
+}
+
+func (rt *RequestIDTestSuite) TestShouldIncludeRequestIDInErrorBody() {
+	recorder := rt.get("req-1")
+
+	var response types.ErrorResponse
+	rt.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(rt.T(), "req-1", response.RequestID)
+	assert.Equal(rt.T(), "not_found", response.Error.Code)
+}
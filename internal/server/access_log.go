@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const (
+	defaultMaxBodyBytes = 4096
+	redacted            = "[REDACTED]"
+	levelDebug          = "debug"
+)
+
+// AccessLogger logs every request outside skipPaths once it is served, with
+// its request ID and trace. Failed requests are always logged; successful
+// ones are sampled. Bodies are only logged when they parse as JSON, so that
+// the fields to redact can be found.
+func AccessLogger(conf config.AccessLogConfig, skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]struct{}, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = struct{}{}
+	}
+	redact := make(map[string]bool, len(conf.RedactFields))
+	for _, field := range conf.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+	maxBodyBytes := conf.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+	sampleRate := conf.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = 1
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := skip[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var requestBody []byte
+		var response *bodyRecorder
+		if conf.Bodies {
+			requestBody = peekBody(c.Request, maxBodyBytes)
+			response = &bodyRecorder{ResponseWriter: c.Writer, limit: maxBodyBytes}
+			c.Writer = response
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusBadRequest && sampleRate < 1 && rand.Float64() >= sampleRate {
+			return
+		}
+
+		path := c.Request.URL.Path
+		if query := redactQuery(c.Request.URL.RawQuery, redact); query != "" {
+			path += "?" + query
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		data := map[string]string{
+			"method":     c.Request.Method,
+			"path":       path,
+			"route":      route,
+			"statusCode": strconv.Itoa(status),
+			"latencyMs":  strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+			"size":       strconv.Itoa(c.Writer.Size()),
+			"clientIP":   c.ClientIP(),
+			"userAgent":  c.Request.UserAgent(),
+		}
+		if t, ok := tenant.FromContext(c.Request.Context()); ok {
+			data["tenantId"] = t.ID
+		}
+		if errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String(); errorMessage != "" {
+			data["error"] = errorMessage
+		}
+		if conf.Bodies {
+			data["requestBody"] = redactBody(requestBody, maxBodyBytes, redact)
+			data["responseBody"] = redactBody(response.body.Bytes(), maxBodyBytes, redact)
+		}
+
+		format := logger.Format{Message: fmt.Sprintf("%s %s %d", c.Request.Method, path, status), Data: data}
+		switch {
+		case status >= http.StatusInternalServerError:
+			logging.Error(c.Request.Context(), format)
+		case conf.Level == levelDebug:
+			logging.Debug(c.Request.Context(), format)
+		default:
+			logging.Info(c.Request.Context(), format)
+		}
+	}
+}
+
+// peekBody returns up to limit+1 bytes of the request body, leaving the
+// body whole for the handler
+func peekBody(request *http.Request, limit int) []byte {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+	peeked, _ := ioutil.ReadAll(io.LimitReader(request.Body, int64(limit)+1))
+	request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(peeked), request.Body), Closer: request.Body}
+	return peeked
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyRecorder keeps up to limit+1 bytes of the response as it is written
+type bodyRecorder struct {
+	gin.ResponseWriter
+	limit int
+	body  bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.keep(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *bodyRecorder) WriteString(data string) (int, error) {
+	r.keep([]byte(data))
+	return r.ResponseWriter.WriteString(data)
+}
+
+func (r *bodyRecorder) keep(data []byte) {
+	if room := r.limit + 1 - r.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		r.body.Write(data)
+	}
+}
+
+// redactBody returns body with the values of the redacted fields masked at
+// any depth. Bodies over the limit or not JSON cannot be redacted, so only
+// their size is logged.
+func redactBody(body []byte, limit int, redact map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > limit {
+		return fmt.Sprintf("[over %d bytes, not logged]", limit)
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Sprintf("[%d bytes, not JSON, not logged]", len(body))
+	}
+	encoded, _ := json.Marshal(redactValue(document, redact))
+	return string(encoded)
+}
+
+func redactValue(value interface{}, redact map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(field, redact)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, redact)
+		}
+	}
+	return value
+}
+
+// redactQuery masks the values of redacted query parameters
+func redactQuery(rawQuery string, redact map[string]bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "[unparsable query]"
+	}
+	for key := range values {
+		if redact[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccessLogTestSuite struct {
+	suite.Suite
+	redact map[string]bool
+}
+
+func (al *AccessLogTestSuite) SetupTest() {
+	logger.Init("debug")
+	al.redact = map[string]bool{"secret": true, "apikey": true}
+}
+
+func TestAccessLogSuite(t *testing.T) {
+	suite.Run(t, new(AccessLogTestSuite))
+}
+
+func (al *AccessLogTestSuite) TestShouldRedactFieldsAtAnyDepth() {
+	body := `{"url":"https://example.com/hook","secret":"s3cr3t","headers":[{"apiKey":"k-1","name":"x"}]}`
+
+	redactedBody := redactBody([]byte(body), 4096, al.redact)
+
+	assert.NotContains(al.T(), redactedBody, "s3cr3t")
+	assert.NotContains(al.T(), redactedBody, "k-1")
+	assert.Contains(al.T(), redactedBody, `"secret":"[REDACTED]"`)
+	assert.Contains(al.T(), redactedBody, `"name":"x"`)
+}
+
+func (al *AccessLogTestSuite) TestShouldNotLogBodiesThatCannotBeRedacted() {
+	assert.Equal(al.T(), "[over 8 bytes, not logged]", redactBody([]byte(`{"secret":"s3cr3t"}`), 8, al.redact))
+	assert.Equal(al.T(), "[9 bytes, not JSON, not logged]", redactBody([]byte("secret=s3"), 4096, al.redact))
+	assert.Equal(al.T(), "", redactBody(nil, 4096, al.redact))
+}
+
+func (al *AccessLogTestSuite) TestShouldRedactQueryParameters() {
+	assert.Equal(al.T(), "apiKey=%5BREDACTED%5D&limit=5", redactQuery("limit=5&apiKey=k-1", al.redact))
+}
+
+func (al *AccessLogTestSuite) TestShouldLeaveBodiesWholeForHandlers() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AccessLogger(config.AccessLogConfig{Bodies: true, MaxBodyBytes: 8}))
+	var received string
+	router.POST("/products/search", func(ctx *gin.Context) {
+		body, _ := ioutil.ReadAll(ctx.Request.Body)
+		received = string(body)
+		ctx.String(http.StatusOK, "a response longer than the limit")
+	})
+
+	body := `{"categories":["watch"],"limit":5}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/products/search", strings.NewReader(body)))
+
+	assert.Equal(al.T(), body, received)
+	assert.Equal(al.T(), "a response longer than the limit", recorder.Body.String())
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type contextKey struct{}
+
+// WithIdentity returns a context carrying the authenticated caller
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the caller ctx was authenticated as, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	return identity, ok
+}
+
+// SetIdentity stores the caller in the gin context and in the request's
+// context, for the services the request reaches
+func SetIdentity(ctx *gin.Context, identity Identity) {
+	ctx.Set(IdentityKey, identity)
+	ctx.Request = ctx.Request.WithContext(WithIdentity(ctx.Request.Context(), identity))
+}
+
+// Current returns the caller of a request, if it was authenticated
+func Current(ctx *gin.Context) (Identity, bool) {
+	value, ok := ctx.Get(IdentityKey)
+	if !ok {
+		return Identity{}, false
+	}
+	identity, ok := value.(Identity)
+	return identity, ok
+}
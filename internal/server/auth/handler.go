@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const challenge = `Bearer realm="rapid-product-catalog"`
+
+type Handler struct {
+	enabled      bool
+	service      Service
+	verifier     *Verifier
+	fallback     Requirement
+	requirements map[string]Requirement
+}
+
+// NewHandler reads the requirement of each route, panicking on unknown
+// requirements so that a typo does not leave a route open
+func NewHandler(cfg config.Config, service Service, verifier *Verifier) *Handler {
+	authConfig := cfg.Get().Auth
+	handler := &Handler{
+		enabled:      authConfig.Enabled,
+		service:      service,
+		verifier:     verifier,
+		fallback:     RequireAuthenticated,
+		requirements: map[string]Requirement{},
+	}
+	if authConfig.Default != "" {
+		handler.fallback = parseRequirement(authConfig.Default)
+	}
+	for _, route := range authConfig.Routes {
+		handler.requirements[strings.Join(strings.Fields(route.Route), " ")] = parseRequirement(route.Require)
+	}
+	return handler
+}
+
+func parseRequirement(value string) Requirement {
+	switch requirement := Requirement(value); requirement {
+	case RequirePublic, RequireAuthenticated, RequireAPIKey, RequireJWT:
+		return requirement
+	}
+	panic(fmt.Sprintf("unknown auth requirement %q", value))
+}
+
+// Middleware authenticates the caller of each request from its bearer token
+// or API key and rejects requests that do not meet their route's
+// requirement. The caller's identity is stored in the gin context, its
+// subject as the audit actor and its tenant for the tenant middleware.
+func (h *Handler) Middleware(ctx *gin.Context) {
+	if !h.enabled {
+		ctx.Next()
+		return
+	}
+
+	requirement := h.requirement(ctx)
+	identity, found, err := h.authenticate(ctx)
+	if err != nil {
+		h.reject(ctx, types.ToStatusError(err))
+		return
+	}
+	if !found {
+		if requirement == RequirePublic {
+			ctx.Next()
+			return
+		}
+		h.reject(ctx, types.NewUnauthorizedError("Authentication required"))
+		return
+	}
+	switch {
+	case requirement == RequireAPIKey && identity.Method != MethodAPIKey:
+		h.reject(ctx, types.NewUnauthorizedError("This route requires an API key"))
+		return
+	case requirement == RequireJWT && identity.Method != MethodJWT:
+		h.reject(ctx, types.NewUnauthorizedError("This route requires a bearer token"))
+		return
+	}
+
+	SetIdentity(ctx, identity)
+	ctx.Set(audit.ActorKey, identity.Subject)
+	ctx.Set(tenant.BoundKey, identity.TenantID)
+	ctx.Set(tenant.AnyTenantKey, identity.Can(PermissionAnyTenant))
+	ctx.Next()
+}
+
+func (h *Handler) requirement(ctx *gin.Context) Requirement {
+	if requirement, ok := h.requirements[ctx.Request.Method+" "+ctx.FullPath()]; ok {
+		return requirement
+	}
+	return h.fallback
+}
+
+// authenticate returns the caller named by the request's credentials, and
+// whether it sent any. A bearer token is preferred over an API key.
+func (h *Handler) authenticate(ctx *gin.Context) (Identity, bool, error) {
+	if authorization := ctx.GetHeader(AuthorizationHeader); authorization != "" {
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			return Identity{}, true, types.NewUnauthorizedError("Unsupported authorization scheme")
+		}
+		identity, err := h.verifier.Verify(strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix)))
+		return identity, true, err
+	}
+	if apiKey := ctx.GetHeader(APIKeyHeader); apiKey != "" {
+		identity, err := h.service.AuthenticateKey(ctx.Request.Context(), apiKey)
+		return identity, true, err
+	}
+	return Identity{}, false, nil
+}
+
+func (h *Handler) reject(ctx *gin.Context, statusError *types.StatusError) {
+	logging.Info(ctx.Request.Context(), logger.Format{
+		Message: "Rejected unauthorized request",
+		Data: map[string]string{
+			"error": statusError.Message,
+			"route": ctx.Request.Method + " " + ctx.FullPath(),
+		},
+	})
+	if statusError.HTTPCode == http.StatusUnauthorized {
+		ctx.Header("WWW-Authenticate", challenge)
+	}
+	ctx.AbortWithStatusJSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+}
+
+// Require rejects callers that hold none of permissions on the whole
+// catalog
+func (h *Handler) Require(permissions ...Permission) gin.HandlerFunc {
+	return h.authorize(permissions, Identity.Can)
+}
+
+// RequireScoped rejects callers that hold none of permissions on any
+// products. The products a request touches are checked against the scope of
+// the caller's grants where they are known.
+func (h *Handler) RequireScoped(permissions ...Permission) gin.HandlerFunc {
+	return h.authorize(permissions, Identity.CanSome)
+}
+
+func (h *Handler) authorize(permissions []Permission, allows func(Identity, Permission) bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !h.enabled {
+			ctx.Next()
+			return
+		}
+		identity, ok := Current(ctx)
+		if !ok {
+			h.reject(ctx, types.NewUnauthorizedError("Authentication required"))
+			return
+		}
+		for _, permission := range permissions {
+			if allows(identity, permission) {
+				ctx.Next()
+				return
+			}
+		}
+		h.reject(ctx, types.NewForbiddenError(fmt.Sprintf("Requires the %s permission", describe(permissions))))
+	}
+}
+
+// describe lists permissions for error messages
+func describe(permissions []Permission) string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = string(permission)
+	}
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthHandlerTestSuite struct {
+	suite.Suite
+	server     *testutils.TestServer
+	repository *MockRepository
+	values     *config.Values
+	identity   Identity
+	actor      string
+	bound      interface{}
+}
+
+func (ah *AuthHandlerTestSuite) SetupTest() {
+	logger.Init("debug")
+	ah.values = &config.Values{Auth: config.AuthConfig{
+		Enabled: true,
+		Routes: []config.RouteAuthConfig{
+			{Route: "GET  /tenant", Require: "public"},
+			{Route: "POST /products/bulk", Require: "apiKey"},
+			{Route: "GET /audit-log", Require: "jwt"},
+		},
+		JWT: config.JWTConfig{Keys: []config.JWTKeyConfig{{ID: "shared", Algorithm: algorithmHS256, Secret: hmacSecret}}},
+	}}
+	ah.repository = &MockRepository{}
+	ah.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_valid")).Return(&APIKey{Name: "pim-sync", TenantID: "wholesale"}, nil)
+	ah.repository.On("GetKeyByHash", mock.Anything, mock.Anything).Return(nil, types.NewNotFoundError("API key not found"))
+	ah.setup()
+}
+
+func (ah *AuthHandlerTestSuite) setup() {
+	handler := NewHandler(ah.values, NewService(ah.values, ah.repository, tenant.NewRegistry(ah.values)), NewVerifier(ah.values))
+	ah.server = testutils.NewServer()
+	ah.identity, ah.actor, ah.bound = Identity{}, "", nil
+
+	router := ah.server.Router()
+	router.GET("/sanity", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.Use(handler.Middleware)
+	record := func(ctx *gin.Context) {
+		ah.identity, _ = Current(ctx)
+		ah.actor = ctx.GetString(audit.ActorKey)
+		ah.bound, _ = ctx.Get(tenant.BoundKey)
+		if fromRequest, _ := FromContext(ctx.Request.Context()); fromRequest.Subject != ah.identity.Subject {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Status(http.StatusOK)
+	}
+	router.GET("/tenant", record)
+	router.POST("/products/bulk", record)
+	router.POST("/products/search", record)
+	router.GET("/audit-log", record)
+	router.GET("/webhooks", handler.Require(PermissionManage), record)
+	router.DELETE("/products/:id", handler.RequireScoped(PermissionDelete), record)
+}
+
+func TestAuthHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AuthHandlerTestSuite))
+}
+
+func (ah *AuthHandlerTestSuite) perform(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	ah.server.Start(req)
+	return ah.server.Recorder()
+}
+
+func (ah *AuthHandlerTestSuite) token(subject string) string {
+	return ah.tokenWithRoles(subject, "admin")
+}
+
+func (ah *AuthHandlerTestSuite) tokenWithRoles(subject string, roles ...string) string {
+	return "Bearer " + sign(map[string]string{"alg": "HS256"}, map[string]interface{}{
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}, []byte(hmacSecret))
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldKeepProbesPublic() {
+	recorder := ah.perform(http.MethodGet, "/sanity", nil)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldRejectRequestWithoutCredentials() {
+	recorder := ah.perform(http.MethodPost, "/products/search", nil)
+
+	assert.Equal(ah.T(), http.StatusUnauthorized, recorder.Code)
+	assert.Equal(ah.T(), challenge, recorder.Header().Get("WWW-Authenticate"))
+	var response types.ErrorResponse
+	ah.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(ah.T(), "unauthorized", response.Error.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldServePublicRouteWithoutCredentials() {
+	recorder := ah.perform(http.MethodGet, "/tenant", nil)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Nil(ah.T(), ah.bound)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldStillCheckCredentialsSentToPublicRoute() {
+	recorder := ah.perform(http.MethodGet, "/tenant", map[string]string{APIKeyHeader: "rpc_guessed"})
+
+	assert.Equal(ah.T(), http.StatusUnauthorized, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldAuthenticateAPIKey() {
+	recorder := ah.perform(http.MethodPost, "/products/bulk", map[string]string{APIKeyHeader: "rpc_valid"})
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Equal(ah.T(), Identity{Subject: "apikey:pim-sync", Method: MethodAPIKey, TenantID: "wholesale"}, ah.identity)
+	assert.Equal(ah.T(), "apikey:pim-sync", ah.actor)
+	assert.Equal(ah.T(), "wholesale", ah.bound)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldAuthenticateBearerToken() {
+	recorder := ah.perform(http.MethodPost, "/products/search", map[string]string{AuthorizationHeader: ah.token("ops@example.com")})
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Equal(ah.T(), Identity{Subject: "ops@example.com", Method: MethodJWT, Roles: []string{"admin"}}, ah.identity)
+	assert.Equal(ah.T(), "", ah.bound, "a token without a tenant is bound to none")
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldEnforceRouteMethod() {
+	assert.Equal(ah.T(), http.StatusUnauthorized, ah.perform(http.MethodPost, "/products/bulk", map[string]string{AuthorizationHeader: ah.token("ops@example.com")}).Code)
+
+	ah.setup()
+	assert.Equal(ah.T(), http.StatusUnauthorized, ah.perform(http.MethodGet, "/audit-log", map[string]string{APIKeyHeader: "rpc_valid"}).Code)
+
+	ah.setup()
+	assert.Equal(ah.T(), http.StatusOK, ah.perform(http.MethodGet, "/audit-log", map[string]string{AuthorizationHeader: ah.token("ops@example.com")}).Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldRejectUnsupportedScheme() {
+	recorder := ah.perform(http.MethodPost, "/products/search", map[string]string{AuthorizationHeader: "Basic b3BzOnNlY3JldA=="})
+
+	assert.Equal(ah.T(), http.StatusUnauthorized, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldLeaveRoutesOpenWhenDisabled() {
+	ah.values.Auth.Enabled = false
+	ah.setup()
+
+	recorder := ah.perform(http.MethodPost, "/products/bulk", nil)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Nil(ah.T(), ah.bound)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldRefuseToStartWithUnknownRequirement() {
+	ah.values.Auth.Routes = []config.RouteAuthConfig{{Route: "GET /tenant", Require: "pubic"}}
+
+	assert.Panics(ah.T(), func() { NewHandler(ah.values, nil, nil) })
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldAllowCallerHoldingPermission() {
+	recorder := ah.perform(http.MethodGet, "/webhooks", map[string]string{AuthorizationHeader: ah.token("ops@example.com")})
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldForbidCallerLackingPermission() {
+	recorder := ah.perform(http.MethodGet, "/webhooks", map[string]string{AuthorizationHeader: ah.tokenWithRoles("ops@example.com", "merchandiser")})
+
+	assert.Equal(ah.T(), http.StatusForbidden, recorder.Code)
+	var response types.ErrorResponse
+	ah.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(ah.T(), "forbidden", response.Error.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldForbidScopedGrantOnWholeCatalogRoute() {
+	recorder := ah.perform(http.MethodGet, "/webhooks", map[string]string{AuthorizationHeader: ah.tokenWithRoles("ops@example.com", "admin:brand=Acme")})
+
+	assert.Equal(ah.T(), http.StatusForbidden, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldLetScopedGrantThroughToProductRoute() {
+	recorder := ah.perform(http.MethodDelete, "/products/1", map[string]string{AuthorizationHeader: ah.tokenWithRoles("ops@example.com", "admin:brand=Acme")})
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+
+	ah.setup()
+	recorder = ah.perform(http.MethodDelete, "/products/1", map[string]string{AuthorizationHeader: ah.tokenWithRoles("ops@example.com", "merchandiser:brand=Acme")})
+
+	assert.Equal(ah.T(), http.StatusForbidden, recorder.Code)
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldSkipPermissionsWhenDisabled() {
+	ah.values.Auth.Enabled = false
+	ah.setup()
+
+	recorder := ah.perform(http.MethodGet, "/webhooks", nil)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+}
+
+// serveTenantProducts routes product reads through authentication and the
+// tenant middleware, answering with the tenant each read was confined to
+func (ah *AuthHandlerTestSuite) serveTenantProducts() *gin.Engine {
+	ah.values.Tenancy = config.TenancyConfig{
+		Enabled: true,
+		Default: "retail",
+		Tenants: []config.TenantConfig{{ID: "retail"}, {ID: "wholesale"}},
+	}
+	registry := tenant.NewRegistry(ah.values)
+	handler := NewHandler(ah.values, NewService(ah.values, ah.repository, registry), NewVerifier(ah.values))
+
+	router := gin.New()
+	router.Use(handler.Middleware, tenant.NewHandler(registry).Middleware)
+	router.GET("/products/:id", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, tenant.ID(ctx.Request.Context()))
+	})
+	return router
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldNotLetTenantlessTokenReadAnotherTenantsProducts() {
+	router := ah.serveTenantProducts()
+
+	req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	req.Header.Set(AuthorizationHeader, ah.token("ops@example.com"))
+	req.Header.Set(tenant.IDHeader, "wholesale")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(ah.T(), http.StatusForbidden, recorder.Code)
+
+	req.Header.Del(tenant.IDHeader)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Equal(ah.T(), "retail", recorder.Body.String())
+}
+
+func (ah *AuthHandlerTestSuite) TestShouldLetPlatformAdminNameTenant() {
+	router := ah.serveTenantProducts()
+
+	req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	req.Header.Set(AuthorizationHeader, ah.tokenWithRoles("ops@example.com", RolePlatformAdmin))
+	req.Header.Set(tenant.IDHeader, "wholesale")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(ah.T(), http.StatusOK, recorder.Code)
+	assert.Equal(ah.T(), "wholesale", recorder.Body.String())
+}
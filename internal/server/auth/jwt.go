@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+)
+
+const (
+	algorithmHS256 = "HS256"
+	algorithmRS256 = "RS256"
+	algorithmES256 = "ES256"
+
+	defaultTenantClaim = "tenant"
+	defaultRolesClaim  = "roles"
+	// clockSkew is how far the clocks of the issuer and this instance may
+	// drift apart before valid tokens are refused
+	clockSkew = 30 * time.Second
+)
+
+// verificationKey is a key tokens are verified with: a []byte secret for
+// HS256, an *rsa.PublicKey for RS256 or an *ecdsa.PublicKey for ES256
+type verificationKey struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+// Verifier verifies the signature and claims of JWT bearer tokens. The
+// algorithm a token names must be the one its key is configured for, so that
+// a public key is never used as an HMAC secret.
+type Verifier struct {
+	keys        []verificationKey
+	issuer      string
+	audience    string
+	tenantClaim string
+	rolesClaim  string
+	now         func() time.Time
+}
+
+// NewVerifier loads the configured keys, panicking on keys that cannot be
+// used so that a misconfigured instance does not start
+func NewVerifier(cfg config.Config) *Verifier {
+	jwtConfig := cfg.Get().Auth.JWT
+	verifier := &Verifier{
+		issuer:      jwtConfig.Issuer,
+		audience:    jwtConfig.Audience,
+		tenantClaim: jwtConfig.TenantClaim,
+		rolesClaim:  jwtConfig.RolesClaim,
+		now:         time.Now,
+	}
+	if verifier.tenantClaim == "" {
+		verifier.tenantClaim = defaultTenantClaim
+	}
+	if verifier.rolesClaim == "" {
+		verifier.rolesClaim = defaultRolesClaim
+	}
+
+	if jwtConfig.JWKSFile != "" {
+		keys, err := loadJWKS(jwtConfig.JWKSFile)
+		if err != nil {
+			panic(fmt.Errorf("cannot load JWKS file %s: %w", jwtConfig.JWKSFile, err))
+		}
+		verifier.keys = append(verifier.keys, keys...)
+	}
+	for _, keyConfig := range jwtConfig.Keys {
+		key, err := staticKey(keyConfig)
+		if err != nil {
+			panic(fmt.Errorf("cannot load JWT key %q: %w", keyConfig.ID, err))
+		}
+		verifier.keys = append(verifier.keys, key)
+	}
+
+	return verifier
+}
+
+// Enabled reports whether any key is configured; without one every bearer
+// token is refused
+func (v *Verifier) Enabled() bool {
+	return len(v.keys) > 0
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify returns the caller a token was issued to
+func (v *Verifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, types.NewUnauthorizedError("Malformed bearer token")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, types.NewUnauthorizedError("Malformed bearer token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, types.NewUnauthorizedError("Malformed bearer token")
+	}
+	key, ok := v.key(header)
+	if !ok {
+		return Identity{}, types.NewUnauthorizedError("Bearer token is not signed with a known key")
+	}
+	if !verifySignature(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return Identity{}, types.NewUnauthorizedError("Invalid bearer token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, types.NewUnauthorizedError("Malformed bearer token")
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return Identity{}, types.NewUnauthorizedError("Bearer token has no subject")
+	}
+	tenantID, _ := claims[v.tenantClaim].(string)
+	return Identity{
+		Subject:  subject,
+		Method:   MethodJWT,
+		TenantID: tenantID,
+		Roles:    stringList(claims[v.rolesClaim]),
+	}, nil
+}
+
+// key returns the key named by the token, or the only key of its algorithm
+// when it names none
+func (v *Verifier) key(header tokenHeader) (verificationKey, bool) {
+	var found verificationKey
+	matches := 0
+	for _, key := range v.keys {
+		if key.algorithm != header.Algorithm {
+			continue
+		}
+		if header.KeyID != "" && key.id == header.KeyID {
+			return key, true
+		}
+		found = key
+		matches++
+	}
+	return found, header.KeyID == "" && matches == 1
+}
+
+func (v *Verifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+
+	expiresAt, ok := numericDate(claims["exp"])
+	if !ok {
+		return types.NewUnauthorizedError("Bearer token has no expiry")
+	}
+	if now.After(expiresAt.Add(clockSkew)) {
+		return types.NewUnauthorizedError("Bearer token has expired")
+	}
+	if notBefore, ok := numericDate(claims["nbf"]); ok && now.Add(clockSkew).Before(notBefore) {
+		return types.NewUnauthorizedError("Bearer token is not valid yet")
+	}
+	if v.issuer != "" {
+		if issuer, _ := claims["iss"].(string); issuer != v.issuer {
+			return types.NewUnauthorizedError("Bearer token has an unexpected issuer")
+		}
+	}
+	if v.audience != "" && !contains(stringList(claims["aud"]), v.audience) {
+		return types.NewUnauthorizedError("Bearer token is not meant for this service")
+	}
+	return nil
+}
+
+func verifySignature(key verificationKey, signed, signature []byte) bool {
+	digest := sha256.Sum256(signed)
+	switch k := key.key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		// ES256 signatures are r and s concatenated, 32 bytes each
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, into interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, into)
+}
+
+// numericDate reads a claim holding seconds since the epoch
+func numericDate(claim interface{}) (time.Time, bool) {
+	seconds, ok := claim.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// stringList reads a claim holding a string, a space separated list as in
+// the OAuth scope claim, or an array of strings
+func stringList(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func staticKey(keyConfig config.JWTKeyConfig) (verificationKey, error) {
+	key := verificationKey{id: keyConfig.ID, algorithm: keyConfig.Algorithm}
+	switch keyConfig.Algorithm {
+	case algorithmHS256:
+		if keyConfig.Secret == "" {
+			return key, fmt.Errorf("%s requires a secret", keyConfig.Algorithm)
+		}
+		key.key = []byte(keyConfig.Secret)
+		return key, nil
+	case algorithmRS256, algorithmES256:
+		block, _ := pem.Decode([]byte(keyConfig.PublicKey))
+		if block == nil {
+			return key, fmt.Errorf("%s requires a PEM encoded public key", keyConfig.Algorithm)
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return key, err
+		}
+		key.key = publicKey
+		return key, checkKeyType(key)
+	}
+	return key, fmt.Errorf("unsupported algorithm %q", keyConfig.Algorithm)
+}
+
+// checkKeyType rejects keys of a type their algorithm cannot use
+func checkKeyType(key verificationKey) error {
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		if key.algorithm == algorithmRS256 {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if key.algorithm == algorithmES256 && k.Curve == elliptic.P256() {
+			return nil
+		}
+	}
+	return fmt.Errorf("key cannot be used with %s", key.algorithm)
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// loadJWKS reads the RS256 and ES256 signing keys of a JWKS document; keys
+// of other types or algorithms, or meant for encryption, are skipped
+func loadJWKS(path string) ([]verificationKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document jwks
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	var keys []verificationKey
+	for _, entry := range document.Keys {
+		if entry.Use != "" && entry.Use != "sig" {
+			continue
+		}
+		if entry.Algorithm != "" && entry.Algorithm != algorithmRS256 && entry.Algorithm != algorithmES256 {
+			continue
+		}
+		key, err := entry.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", entry.KeyID, err)
+		}
+		if key.key != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (entry jwk) verificationKey() (verificationKey, error) {
+	switch entry.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(entry.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(entry.E)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		return verificationKey{
+			id:        entry.KeyID,
+			algorithm: algorithmRS256,
+			key:       &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+		}, nil
+	case "EC":
+		if entry.Curve != "P-256" {
+			return verificationKey{}, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(entry.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(entry.Y)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return verificationKey{}, fmt.Errorf("point is not on P-256")
+		}
+		return verificationKey{id: entry.KeyID, algorithm: algorithmES256, key: publicKey}, nil
+	}
+	return verificationKey{}, nil
+}
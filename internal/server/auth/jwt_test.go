@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const hmacSecret = "catalog-test-secret"
+
+type JWTTestSuite struct {
+	suite.Suite
+	values *config.Values
+	now    time.Time
+}
+
+func (jt *JWTTestSuite) SetupTest() {
+	jt.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	jt.values = &config.Values{Auth: config.AuthConfig{JWT: config.JWTConfig{
+		Issuer:   "https://id.example.com",
+		Audience: "catalog",
+		Keys:     []config.JWTKeyConfig{{ID: "shared", Algorithm: algorithmHS256, Secret: hmacSecret}},
+	}}}
+}
+
+func TestJWTSuite(t *testing.T) {
+	suite.Run(t, new(JWTTestSuite))
+}
+
+func (jt *JWTTestSuite) verifier() *Verifier {
+	verifier := NewVerifier(jt.values)
+	verifier.now = func() time.Time { return jt.now }
+	return verifier
+}
+
+func (jt *JWTTestSuite) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":    "merchandiser@example.com",
+		"iss":    "https://id.example.com",
+		"aud":    []string{"catalog", "search"},
+		"exp":    jt.now.Add(time.Hour).Unix(),
+		"tenant": "wholesale",
+		"roles":  []string{"merchandiser"},
+	}
+}
+
+func encodeSegment(value interface{}) string {
+	encoded, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// sign returns a token of claims signed by key, which is an HMAC secret, an
+// RSA or an ECDSA private key
+func sign(header map[string]string, claims map[string]interface{}, key interface{}) string {
+	signed := encodeSegment(header) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (jt *JWTTestSuite) TestShouldVerifyTokenSignedWithSharedSecret() {
+	token := sign(map[string]string{"alg": "HS256", "kid": "shared"}, jt.claims(), []byte(hmacSecret))
+
+	identity, err := jt.verifier().Verify(token)
+
+	jt.Require().NoError(err)
+	assert.Equal(jt.T(), Identity{
+		Subject:  "merchandiser@example.com",
+		Method:   MethodJWT,
+		TenantID: "wholesale",
+		Roles:    []string{"merchandiser"},
+	}, identity)
+}
+
+func (jt *JWTTestSuite) TestShouldVerifyTokensSignedWithKeysOfJWKSFile() {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	jt.Require().NoError(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jt.Require().NoError(err)
+	path := filepath.Join(jt.T().TempDir(), "jwks.json")
+	document, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{
+			"kty": "RSA", "kid": "rsa-1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+			"y": base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+		},
+		{"kty": "RSA", "kid": "encryption", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	jt.Require().NoError(ioutil.WriteFile(path, document, 0600))
+	jt.values.Auth.JWT.JWKSFile = path
+	verifier := jt.verifier()
+
+	_, err = verifier.Verify(sign(map[string]string{"alg": "RS256", "kid": "rsa-1"}, jt.claims(), rsaKey))
+	assert.NoError(jt.T(), err)
+	_, err = verifier.Verify(sign(map[string]string{"alg": "ES256", "kid": "ec-1"}, jt.claims(), ecKey))
+	assert.NoError(jt.T(), err)
+}
+
+func (jt *JWTTestSuite) TestShouldVerifyTokenSignedWithStaticPublicKey() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jt.Require().NoError(err)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	jt.values.Auth.JWT.Keys = []config.JWTKeyConfig{{
+		ID:        "static",
+		Algorithm: algorithmES256,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}}
+
+	_, err = jt.verifier().Verify(sign(map[string]string{"alg": "ES256"}, jt.claims(), ecKey))
+
+	assert.NoError(jt.T(), err)
+}
+
+func (jt *JWTTestSuite) TestShouldRefuseInvalidTokens() {
+	valid := jt.claims()
+	tests := map[string]string{
+		"not a token":       "abc.def",
+		"wrong secret":      sign(map[string]string{"alg": "HS256"}, valid, []byte("guessed")),
+		"unknown key":       sign(map[string]string{"alg": "HS256", "kid": "other"}, valid, []byte(hmacSecret)),
+		"algorithm none":    encodeSegment(map[string]string{"alg": "none"}) + "." + encodeSegment(valid) + ".",
+		"algorithm changed": sign(map[string]string{"alg": "RS256", "kid": "shared"}, valid, []byte(hmacSecret)),
+	}
+	for name, claims := range map[string]func(map[string]interface{}){
+		"expired":       func(c map[string]interface{}) { c["exp"] = jt.now.Add(-time.Minute).Unix() },
+		"no expiry":     func(c map[string]interface{}) { delete(c, "exp") },
+		"not yet valid": func(c map[string]interface{}) { c["nbf"] = jt.now.Add(time.Hour).Unix() },
+		"other issuer":  func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"other service": func(c map[string]interface{}) { c["aud"] = "billing" },
+		"no subject":    func(c map[string]interface{}) { delete(c, "sub") },
+	} {
+		modified := jt.claims()
+		claims(modified)
+		tests[name] = sign(map[string]string{"alg": "HS256"}, modified, []byte(hmacSecret))
+	}
+
+	verifier := jt.verifier()
+	for name, token := range tests {
+		_, err := verifier.Verify(token)
+		assert.Error(jt.T(), err, name)
+	}
+}
+
+func (jt *JWTTestSuite) TestShouldTolerateClockSkew() {
+	claims := jt.claims()
+	claims["exp"] = jt.now.Add(-clockSkew / 2).Unix()
+
+	_, err := jt.verifier().Verify(sign(map[string]string{"alg": "HS256"}, claims, []byte(hmacSecret)))
+
+	assert.NoError(jt.T(), err)
+}
+
+func (jt *JWTTestSuite) TestShouldRefuseToStartWithUnusableKey() {
+	jt.values.Auth.JWT.Keys = []config.JWTKeyConfig{{ID: "broken", Algorithm: algorithmRS256, PublicKey: "not a key"}}
+
+	assert.Panics(jt.T(), func() { NewVerifier(jt.values) })
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const (
+	keyPrefix         = "rpc_"
+	keyBytes          = 32
+	shownPrefixLength = len(keyPrefix) + 8
+)
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// HashKey returns the hash a key is stored and looked up by. Keys are long
+// and random, so a fast unsalted hash is enough to keep a leaked collection
+// from revealing them.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// displayPrefix is the start of key kept to tell keys apart
+func displayPrefix(key string) string {
+	if len(key) <= shownPrefixLength {
+		return key
+	}
+	return key[:shownPrefixLength]
+}
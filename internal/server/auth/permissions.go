@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Permission is an operation a role allows
+type Permission string
+
+const (
+	PermissionRead           Permission = "read"
+	PermissionBulkWrite      Permission = "bulk-write"
+	PermissionPriceWrite     Permission = "price-write"
+	PermissionInventoryWrite Permission = "inventory-write"
+	PermissionDelete         Permission = "delete"
+	// PermissionManage covers the deployment's integrations and
+	// diagnostics: webhooks, the audit log, caches and currency rates
+	PermissionManage Permission = "manage"
+	// PermissionAnyTenant lets a caller serve any tenant named in the
+	// tenant ID header rather than only its own
+	PermissionAnyTenant Permission = "any-tenant"
+)
+
+const (
+	RoleViewer           = "viewer"
+	RoleMerchandiser     = "merchandiser"
+	RoleInventoryManager = "inventory-manager"
+	RoleAdmin            = "admin"
+	RolePlatformAdmin    = "platform-admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer:           {PermissionRead},
+	RoleMerchandiser:     {PermissionRead, PermissionBulkWrite, PermissionPriceWrite},
+	RoleInventoryManager: {PermissionRead, PermissionInventoryWrite},
+	RoleAdmin: {
+		PermissionRead, PermissionBulkWrite, PermissionPriceWrite, PermissionInventoryWrite,
+		PermissionDelete, PermissionManage,
+	},
+	RolePlatformAdmin: {
+		PermissionRead, PermissionBulkWrite, PermissionPriceWrite, PermissionInventoryWrite,
+		PermissionDelete, PermissionManage, PermissionAnyTenant,
+	},
+}
+
+// Grant is a role held by a caller, written as the role alone or scoped to
+// products of some brands or categories, as in
+// "merchandiser:brand=Acme,brand=Zeta" or
+// "inventory-manager:category=shoes". A product is in scope when its brand
+// is among the brands and its category among the categories, where listed.
+type Grant struct {
+	Role       string
+	Brands     []string
+	Categories []string
+}
+
+// ParseGrant reads a grant, refusing unknown roles and scopes
+func ParseGrant(value string) (Grant, error) {
+	role, scope := value, ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		role, scope = value[:i], value[i+1:]
+	}
+	grant := Grant{Role: strings.TrimSpace(role)}
+	if _, ok := rolePermissions[grant.Role]; !ok {
+		return Grant{}, fmt.Errorf("unknown role %q", grant.Role)
+	}
+	if scope == "" {
+		return grant, nil
+	}
+
+	for _, entry := range strings.Split(scope, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return Grant{}, fmt.Errorf("invalid scope %q of role %q", entry, grant.Role)
+		}
+		switch key, name := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]); key {
+		case "brand":
+			grant.Brands = append(grant.Brands, name)
+		case "category":
+			grant.Categories = append(grant.Categories, name)
+		default:
+			return Grant{}, fmt.Errorf("invalid scope %q of role %q", entry, grant.Role)
+		}
+	}
+	return grant, nil
+}
+
+// Scoped reports whether the grant is limited to some products
+func (g Grant) Scoped() bool {
+	return len(g.Brands) > 0 || len(g.Categories) > 0
+}
+
+func (g Grant) allows(permission Permission) bool {
+	for _, allowed := range rolePermissions[g.Role] {
+		if allowed == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether a product of brand and category is in scope
+func (g Grant) Covers(brand, category string) bool {
+	return matchesAny(g.Brands, brand) && matchesAny(g.Categories, category)
+}
+
+func matchesAny(names []string, value string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grants returns the caller's grants, skipping roles it holds for other
+// services
+func (i Identity) Grants() []Grant {
+	grants := make([]Grant, 0, len(i.Roles))
+	for _, role := range i.Roles {
+		if grant, err := ParseGrant(role); err == nil {
+			grants = append(grants, grant)
+		}
+	}
+	return grants
+}
+
+// Can reports whether the caller holds permission on the whole catalog
+func (i Identity) Can(permission Permission) bool {
+	for _, grant := range i.Grants() {
+		if !grant.Scoped() && grant.allows(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSome reports whether the caller holds permission on any products
+func (i Identity) CanSome(permission Permission) bool {
+	for _, grant := range i.Grants() {
+		if grant.allows(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes returns the scoped grants by which the caller holds permission, and
+// whether it is limited to them; it is not when it holds permission on the
+// whole catalog
+func (i Identity) Scopes(permission Permission) ([]Grant, bool) {
+	scopes := []Grant{}
+	for _, grant := range i.Grants() {
+		if !grant.allows(permission) {
+			continue
+		}
+		if !grant.Scoped() {
+			return nil, false
+		}
+		scopes = append(scopes, grant)
+	}
+	return scopes, true
+}
+
+// CanOn reports whether the caller holds permission on products of brand
+// and category
+func (i Identity) CanOn(permission Permission, brand, category string) bool {
+	for _, grant := range i.Grants() {
+		if grant.allows(permission) && grant.Covers(brand, category) {
+			return true
+		}
+	}
+	return false
+}
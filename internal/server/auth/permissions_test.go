@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldParseGrants(t *testing.T) {
+	tests := map[string]Grant{
+		"viewer":                              {Role: RoleViewer},
+		"merchandiser:brand=Acme,brand=Zeta":  {Role: RoleMerchandiser, Brands: []string{"Acme", "Zeta"}},
+		"inventory-manager:category=shoes":    {Role: RoleInventoryManager, Categories: []string{"shoes"}},
+		"admin: brand = Acme , category=bags": {Role: RoleAdmin, Brands: []string{"Acme"}, Categories: []string{"bags"}},
+	}
+	for value, expected := range tests {
+		grant, err := ParseGrant(value)
+
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, grant, value)
+	}
+}
+
+func TestShouldRefuseInvalidGrants(t *testing.T) {
+	for _, value := range []string{"owner", "merchandiser:brand", "merchandiser:brand=", "merchandiser:colour=red", ""} {
+		_, err := ParseGrant(value)
+
+		assert.Error(t, err, value)
+	}
+}
+
+func TestShouldGrantPermissionsOfRoles(t *testing.T) {
+	merchandiser := Identity{Roles: []string{RoleMerchandiser}}
+	inventoryManager := Identity{Roles: []string{RoleInventoryManager}}
+	unknown := Identity{Roles: []string{"billing:invoices"}}
+
+	assert.True(t, merchandiser.Can(PermissionPriceWrite))
+	assert.False(t, merchandiser.Can(PermissionInventoryWrite))
+	assert.True(t, inventoryManager.Can(PermissionInventoryWrite))
+	assert.False(t, inventoryManager.Can(PermissionDelete))
+	assert.False(t, unknown.Can(PermissionRead))
+	assert.False(t, Identity{}.CanSome(PermissionRead))
+}
+
+func TestShouldLimitScopedGrantsToTheirProducts(t *testing.T) {
+	partner := Identity{Roles: []string{"viewer", "merchandiser:brand=Acme,category=shoes,category=bags"}}
+
+	assert.True(t, partner.Can(PermissionRead))
+	assert.False(t, partner.Can(PermissionBulkWrite))
+	assert.True(t, partner.CanSome(PermissionBulkWrite))
+	assert.True(t, partner.CanOn(PermissionBulkWrite, "acme", "Shoes"))
+	assert.True(t, partner.CanOn(PermissionBulkWrite, "Acme", "bags"))
+	assert.False(t, partner.CanOn(PermissionBulkWrite, "Zeta", "shoes"))
+	assert.False(t, partner.CanOn(PermissionBulkWrite, "Acme", "watches"))
+	assert.True(t, partner.CanOn(PermissionRead, "Zeta", "watches"))
+}
+
+func TestShouldListScopesOfPermission(t *testing.T) {
+	scoped := Identity{Roles: []string{"viewer:brand=Acme", "merchandiser:category=bags", "inventory-manager"}}
+
+	scopes, limited := scoped.Scopes(PermissionPriceWrite)
+	assert.True(t, limited)
+	assert.Equal(t, []Grant{{Role: RoleMerchandiser, Categories: []string{"bags"}}}, scopes)
+
+	_, limited = scoped.Scopes(PermissionRead)
+	assert.False(t, limited, "the unscoped inventory-manager grant reads the whole catalog")
+
+	scopes, limited = scoped.Scopes(PermissionDelete)
+	assert.True(t, limited)
+	assert.Empty(t, scopes)
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository stores the issued API keys. Keys are looked up before the
+// tenant of a request is known, so queries are not confined to a tenant.
+type Repository interface {
+	CreateKey(ctx context.Context, key APIKey) (*APIKey, error)
+	// GetKeyByHash returns the key with hash that has not been revoked
+	GetKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	// ListKeys returns the keys of tenantID, or of every tenant when it is
+	// empty, revoked ones included
+	ListKeys(ctx context.Context, tenantID string) ([]APIKey, error)
+	RevokeKey(ctx context.Context, keyID primitive.ObjectID, at time.Time) (*APIKey, error)
+	// LatestRevocation returns when a key was last revoked, zero when none
+	// has been
+	LatestRevocation(ctx context.Context) (time.Time, error)
+}
+
+type repositoryImpl struct {
+	db   *utils.DBInstance
+	keys *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:   db,
+		keys: db.Collection("rapidApiKeys"),
+	}
+}
+
+func (r *repositoryImpl) CreateKey(ctx context.Context, key APIKey) (*APIKey, error) {
+	var result *mongo.InsertOneResult
+	err := r.db.Write(ctx, "auth.CreateKey", func(ctx context.Context) error {
+		var err error
+		result, err = r.keys.InsertOne(ctx, key)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error creating API key",
+			Data: map[string]string{
+				"error":    err.Error(),
+				"name":     key.Name,
+				"tenantID": key.TenantID,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return &key, nil
+}
+
+func (r *repositoryImpl) GetKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	err := r.db.Read(ctx, "auth.GetKeyByHash", func(ctx context.Context) error {
+		return r.keys.FindOne(ctx, bson.M{"hash": hash, "revokedAt": bson.M{"$exists": false}}).Decode(&key)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, types.NewNotFoundError("API key not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching API key",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &key, nil
+}
+
+func (r *repositoryImpl) ListKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	filter := bson.M{}
+	if tenantID != "" {
+		filter[tenant.Field] = tenantID
+	}
+	keys := []APIKey{}
+	err := r.db.Read(ctx, "auth.ListKeys", func(ctx context.Context) error {
+		cursor, err := r.keys.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &keys)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching API keys",
+			Data: map[string]string{
+				"error":    err.Error(),
+				"tenantID": tenantID,
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return keys, nil
+}
+
+func (r *repositoryImpl) RevokeKey(ctx context.Context, keyID primitive.ObjectID, at time.Time) (*APIKey, error) {
+	var key APIKey
+	err := r.db.Write(ctx, "auth.RevokeKey", func(ctx context.Context) error {
+		return r.keys.FindOneAndUpdate(ctx,
+			bson.M{"_id": keyID, "revokedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revokedAt": at}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&key)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, types.NewNotFoundError("Active API key not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error revoking API key",
+			Data: map[string]string{
+				"error": err.Error(),
+				"keyID": keyID.Hex(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &key, nil
+}
+
+func (r *repositoryImpl) LatestRevocation(ctx context.Context) (time.Time, error) {
+	var key APIKey
+	err := r.db.Read(ctx, "auth.LatestRevocation", func(ctx context.Context) error {
+		return r.keys.FindOne(ctx,
+			bson.M{"revokedAt": bson.M{"$exists": true}},
+			options.FindOne().SetSort(bson.D{{Key: "revokedAt", Value: -1}}).SetProjection(bson.M{"revokedAt": 1}),
+		).Decode(&key)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, nil
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching latest API key revocation",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return time.Time{}, utils.DatastoreError(err)
+	}
+
+	if key.RevokedAt == nil {
+		return time.Time{}, nil
+	}
+	return *key.RevokedAt, nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (r *MockRepository) CreateKey(ctx context.Context, key APIKey) (*APIKey, error) {
+	ret := r.Mock.Called(ctx, key)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*APIKey), ret.Error(1)
+}
+
+func (r *MockRepository) GetKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	ret := r.Mock.Called(ctx, hash)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*APIKey), ret.Error(1)
+}
+
+func (r *MockRepository) ListKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	ret := r.Mock.Called(ctx, tenantID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]APIKey), ret.Error(1)
+}
+
+func (r *MockRepository) RevokeKey(ctx context.Context, keyID primitive.ObjectID, at time.Time) (*APIKey, error) {
+	ret := r.Mock.Called(ctx, keyID, at)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*APIKey), ret.Error(1)
+}
+
+func (r *MockRepository) LatestRevocation(ctx context.Context) (time.Time, error) {
+	ret := r.Mock.Called(ctx)
+	return ret.Get(0).(time.Time), ret.Error(1)
+}
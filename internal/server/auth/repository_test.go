@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RepositoryTestSuite struct {
+	suite.Suite
+	client     *mongo.Client
+	repository Repository
+}
+
+func TestRepositorySuite(t *testing.T) {
+	suite.Run(t, new(RepositoryTestSuite))
+}
+
+func (rt *RepositoryTestSuite) SetupTest() {
+	logger.Init("debug")
+	// Nothing listens on the port, so no server can ever be selected
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1/?connect=direct"))
+	rt.Require().NoError(err)
+	rt.client = client
+
+	policy := utils.NewOperationPolicy(config.OperationsConfig{ReadTimeout: 50, MaxRetries: 2, MinBackoff: 1, MaxBackoff: 2})
+	rt.repository = NewRepository(&utils.DBInstance{TestDB: client.Database("rapidAuth"), Policy: &policy})
+}
+
+func (rt *RepositoryTestSuite) TearDownTest() {
+	rt.client.Disconnect(context.Background())
+}
+
+func (rt *RepositoryTestSuite) TestShouldBoundKeyLookupByReadTimeoutWhileDatastoreUnavailable() {
+	start := time.Now()
+
+	key, err := rt.repository.GetKeyByHash(context.Background(), "hash")
+
+	// Without the read timeout the lookup would wait out the driver's 30s
+	// server selection
+	assert.Less(rt.T(), int64(time.Since(start)), int64(5*time.Second))
+	assert.Nil(rt.T(), key)
+	if assert.IsType(rt.T(), &types.StatusError{}, err) {
+		assert.Equal(rt.T(), "datastore_timeout", err.(*types.StatusError).Code)
+	}
+}
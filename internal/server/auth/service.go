@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultKeyCacheTTL = time.Minute
+
+// revocationCheckInterval is how often an instance looks for keys revoked
+// elsewhere while serving cached ones, bounding how long a revoked key keeps
+// working on it
+const revocationCheckInterval = 5 * time.Second
+
+type Service interface {
+	// CreateKey issues a key, returning it along with the key itself, which
+	// is not stored and cannot be shown again
+	CreateKey(ctx context.Context, req CreateKeyRequest) (*APIKey, string, error)
+	ListKeys(ctx context.Context, tenantID string) ([]APIKey, error)
+	RevokeKey(ctx context.Context, keyID primitive.ObjectID) (*APIKey, error)
+	// AuthenticateKey returns the caller an API key was issued to. Keys
+	// configured for a tenant under tenancy are accepted as an admin of that
+	// tenant.
+	AuthenticateKey(ctx context.Context, key string) (Identity, error)
+}
+
+type serviceImpl struct {
+	repository Repository
+	registry   *tenant.Registry
+	cacheTTL   time.Duration
+	now        func() time.Time
+
+	mu sync.Mutex
+	// cache holds the identities of keys recently authenticated, keyed by
+	// their hash
+	cache map[string]cachedIdentity
+	// revokedAt is the latest revocation seen, and checkedAt when it was
+	// last looked for
+	revokedAt time.Time
+	checkedAt time.Time
+}
+
+type cachedIdentity struct {
+	identity  Identity
+	expiresAt time.Time
+}
+
+func NewService(cfg config.Config, repo Repository, registry *tenant.Registry) Service {
+	cacheTTL := time.Duration(cfg.Get().Auth.KeyCacheTTL) * time.Second
+	if cacheTTL <= 0 {
+		cacheTTL = defaultKeyCacheTTL
+	}
+
+	return &serviceImpl{
+		repository: repo,
+		registry:   registry,
+		cacheTTL:   cacheTTL,
+		now:        time.Now,
+		cache:      map[string]cachedIdentity{},
+	}
+}
+
+func (s *serviceImpl) CreateKey(ctx context.Context, req CreateKeyRequest) (*APIKey, string, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, "", types.NewValidationError("API key name is required")
+	}
+	if req.TenantID == "" {
+		req.TenantID = tenant.Default()
+	}
+	if _, ok := s.registry.Get(req.TenantID); !ok {
+		return nil, "", types.NewValidationError("Tenant not found")
+	}
+	for _, role := range req.Roles {
+		if _, err := ParseGrant(role); err != nil {
+			return nil, "", types.NewValidationError(err.Error())
+		}
+	}
+
+	secret, err := GenerateKey()
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error generating API key",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, "", types.NewInternalServerError()
+	}
+
+	key, err := s.repository.CreateKey(ctx, APIKey{
+		Name:      req.Name,
+		TenantID:  req.TenantID,
+		Prefix:    displayPrefix(secret),
+		Hash:      HashKey(secret),
+		Roles:     req.Roles,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	logging.Info(ctx, logger.Format{
+		Message: "Created API key",
+		Data: map[string]string{
+			"keyID":    key.ID.Hex(),
+			"name":     key.Name,
+			"tenantID": key.TenantID,
+		},
+	})
+	return key, secret, nil
+}
+
+func (s *serviceImpl) ListKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	return s.repository.ListKeys(ctx, tenantID)
+}
+
+func (s *serviceImpl) RevokeKey(ctx context.Context, keyID primitive.ObjectID) (*APIKey, error) {
+	key, err := s.repository.RevokeKey(ctx, keyID, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, key.Hash)
+	s.mu.Unlock()
+
+	logging.Info(ctx, logger.Format{
+		Message: "Revoked API key",
+		Data: map[string]string{
+			"keyID":    key.ID.Hex(),
+			"name":     key.Name,
+			"tenantID": key.TenantID,
+		},
+	})
+	return key, nil
+}
+
+func (s *serviceImpl) AuthenticateKey(ctx context.Context, secret string) (Identity, error) {
+	hash := HashKey(secret)
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) && s.checkRevocations(ctx, now) {
+		s.mu.Lock()
+		cached, ok = s.cache[hash]
+		s.mu.Unlock()
+		if ok {
+			return cached.identity, nil
+		}
+	}
+
+	var identity Identity
+	key, err := s.repository.GetKeyByHash(ctx, hash)
+	switch {
+	case err == nil:
+		identity = Identity{Subject: "apikey:" + key.Name, Method: MethodAPIKey, TenantID: key.TenantID, Roles: key.Roles}
+	case types.ToStatusError(err).HTTPCode == http.StatusNotFound:
+		owner, ok := s.registry.KeyOwner(secret)
+		if !ok {
+			return Identity{}, types.NewUnauthorizedError("Invalid API key")
+		}
+		// Keys configured for a tenant predate roles and keep their full
+		// access to its catalog
+		identity = Identity{Subject: "tenant:" + owner, Method: MethodAPIKey, TenantID: owner, Roles: []string{RoleAdmin}}
+	default:
+		return Identity{}, err
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = cachedIdentity{identity: identity, expiresAt: now.Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return identity, nil
+}
+
+// checkRevocations drops the cached keys when a key has been revoked since
+// the last check, which runs at most every revocationCheckInterval. It
+// reports false when the check failed and cached keys cannot be trusted.
+func (s *serviceImpl) checkRevocations(ctx context.Context, now time.Time) bool {
+	s.mu.Lock()
+	if now.Sub(s.checkedAt) < revocationCheckInterval {
+		s.mu.Unlock()
+		return true
+	}
+	s.checkedAt = now
+	s.mu.Unlock()
+
+	revokedAt, err := s.repository.LatestRevocation(ctx)
+	if err != nil {
+		s.mu.Lock()
+		s.checkedAt = time.Time{}
+		s.mu.Unlock()
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if revokedAt.After(s.revokedAt) {
+		s.revokedAt = revokedAt
+		s.cache = map[string]cachedIdentity{}
+	}
+	return true
+}
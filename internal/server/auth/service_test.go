@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuthServiceTestSuite struct {
+	suite.Suite
+	repository *MockRepository
+	service    *serviceImpl
+	now        time.Time
+}
+
+func (as *AuthServiceTestSuite) SetupTest() {
+	logger.Init("debug")
+	as.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	values := &config.Values{
+		Auth: config.AuthConfig{KeyCacheTTL: 60},
+		Tenancy: config.TenancyConfig{
+			Enabled: true,
+			Tenants: []config.TenantConfig{
+				{ID: "retail"},
+				{ID: "wholesale", APIKeys: []string{"configured-key"}},
+			},
+		},
+	}
+	as.repository = &MockRepository{}
+	as.service = NewService(values, as.repository, tenant.NewRegistry(values)).(*serviceImpl)
+	as.service.now = func() time.Time { return as.now }
+}
+
+func TestAuthServiceSuite(t *testing.T) {
+	suite.Run(t, new(AuthServiceTestSuite))
+}
+
+func (as *AuthServiceTestSuite) TestShouldStoreOnlyHashOfCreatedKey() {
+	var stored APIKey
+	as.repository.On("CreateKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(APIKey)
+	}).Return(&APIKey{ID: primitive.NewObjectID()}, nil)
+
+	_, secret, err := as.service.CreateKey(context.Background(), CreateKeyRequest{Name: " pim-sync ", TenantID: "wholesale", Roles: []string{"merchandiser"}})
+
+	as.Require().NoError(err)
+	assert.True(as.T(), strings.HasPrefix(secret, keyPrefix))
+	assert.Equal(as.T(), HashKey(secret), stored.Hash)
+	assert.True(as.T(), strings.HasPrefix(secret, stored.Prefix))
+	assert.Less(as.T(), len(stored.Prefix), len(secret))
+	assert.Equal(as.T(), APIKey{
+		Name:      "pim-sync",
+		TenantID:  "wholesale",
+		Prefix:    stored.Prefix,
+		Hash:      stored.Hash,
+		Roles:     []string{"merchandiser"},
+		CreatedAt: as.now,
+	}, stored)
+}
+
+func (as *AuthServiceTestSuite) TestShouldIssueKeyToDefaultTenant() {
+	as.repository.On("CreateKey", mock.Anything, mock.MatchedBy(func(key APIKey) bool {
+		return key.TenantID == tenant.Default()
+	})).Return(&APIKey{ID: primitive.NewObjectID(), TenantID: tenant.Default()}, nil)
+
+	_, _, err := as.service.CreateKey(context.Background(), CreateKeyRequest{Name: "pim-sync"})
+
+	as.Require().NoError(err)
+	as.repository.AssertExpectations(as.T())
+}
+
+func (as *AuthServiceTestSuite) TestShouldRefuseKeyForUnknownTenant() {
+	_, _, err := as.service.CreateKey(context.Background(), CreateKeyRequest{Name: "pim-sync", TenantID: "unknown"})
+
+	assert.Equal(as.T(), http.StatusBadRequest, types.ToStatusError(err).HTTPCode)
+	as.repository.AssertNotCalled(as.T(), "CreateKey", mock.Anything, mock.Anything)
+}
+
+func (as *AuthServiceTestSuite) TestShouldRefuseKeyWithUnknownRole() {
+	_, _, err := as.service.CreateKey(context.Background(), CreateKeyRequest{Name: "pim-sync", Roles: []string{"merchandiser:brand=Acme", "owner"}})
+
+	assert.Equal(as.T(), http.StatusBadRequest, types.ToStatusError(err).HTTPCode)
+	as.repository.AssertNotCalled(as.T(), "CreateKey", mock.Anything, mock.Anything)
+}
+
+func (as *AuthServiceTestSuite) TestShouldAuthenticateStoredKeyAndCacheIt() {
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).
+		Return(&APIKey{Name: "pim-sync", TenantID: "wholesale", Roles: []string{"merchandiser"}}, nil).Once()
+	as.repository.On("LatestRevocation", mock.Anything).Return(time.Time{}, nil)
+
+	first, err := as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	as.Require().NoError(err)
+	second, err := as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	as.Require().NoError(err)
+
+	assert.Equal(as.T(), Identity{Subject: "apikey:pim-sync", Method: MethodAPIKey, TenantID: "wholesale", Roles: []string{"merchandiser"}}, first)
+	assert.Equal(as.T(), first, second)
+	as.repository.AssertNumberOfCalls(as.T(), "GetKeyByHash", 1)
+}
+
+func (as *AuthServiceTestSuite) TestShouldLookKeyUpAgainOnceCacheExpires() {
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(&APIKey{Name: "pim-sync"}, nil)
+
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	as.now = as.now.Add(2 * time.Minute)
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+
+	as.repository.AssertNumberOfCalls(as.T(), "GetKeyByHash", 2)
+}
+
+func (as *AuthServiceTestSuite) TestShouldAcceptKeyConfiguredForTenant() {
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("configured-key")).Return(nil, types.NewNotFoundError("API key not found"))
+
+	identity, err := as.service.AuthenticateKey(context.Background(), "configured-key")
+
+	as.Require().NoError(err)
+	assert.Equal(as.T(), Identity{Subject: "tenant:wholesale", Method: MethodAPIKey, TenantID: "wholesale", Roles: []string{RoleAdmin}}, identity)
+}
+
+func (as *AuthServiceTestSuite) TestShouldRefuseUnknownKey() {
+	as.repository.On("GetKeyByHash", mock.Anything, mock.Anything).Return(nil, types.NewNotFoundError("API key not found"))
+
+	_, err := as.service.AuthenticateKey(context.Background(), "rpc_guessed")
+
+	assert.Equal(as.T(), http.StatusUnauthorized, types.ToStatusError(err).HTTPCode)
+}
+
+func (as *AuthServiceTestSuite) TestShouldNotMistakeUnavailableDatastoreForUnknownKey() {
+	as.repository.On("GetKeyByHash", mock.Anything, mock.Anything).Return(nil, types.NewDatastoreUnavailableError())
+
+	_, err := as.service.AuthenticateKey(context.Background(), "rpc_stored")
+
+	assert.Equal(as.T(), types.NewDatastoreUnavailableError().HTTPCode, types.ToStatusError(err).HTTPCode)
+}
+
+func (as *AuthServiceTestSuite) TestShouldForgetRevokedKeyAtOnce() {
+	keyID := primitive.NewObjectID()
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(&APIKey{Name: "pim-sync"}, nil).Once()
+	as.repository.On("RevokeKey", mock.Anything, keyID, as.now).Return(&APIKey{ID: keyID, Hash: HashKey("rpc_stored")}, nil)
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(nil, types.NewNotFoundError("API key not found"))
+
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	_, err := as.service.RevokeKey(context.Background(), keyID)
+	as.Require().NoError(err)
+	_, err = as.service.AuthenticateKey(context.Background(), "rpc_stored")
+
+	assert.Equal(as.T(), http.StatusUnauthorized, types.ToStatusError(err).HTTPCode)
+}
+
+func (as *AuthServiceTestSuite) TestShouldDropCachedKeysOnceAKeyIsRevokedElsewhere() {
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(&APIKey{Name: "pim-sync"}, nil).Once()
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(nil, types.NewNotFoundError("API key not found"))
+	as.repository.On("LatestRevocation", mock.Anything).Return(time.Time{}, nil).Once()
+	as.repository.On("LatestRevocation", mock.Anything).Return(as.now.Add(time.Second), nil)
+
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	_, err := as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	as.Require().NoError(err)
+
+	as.now = as.now.Add(revocationCheckInterval)
+	_, err = as.service.AuthenticateKey(context.Background(), "rpc_stored")
+
+	assert.Equal(as.T(), http.StatusUnauthorized, types.ToStatusError(err).HTTPCode)
+}
+
+func (as *AuthServiceTestSuite) TestShouldNotTrustCacheWhenRevocationsCannotBeChecked() {
+	as.repository.On("GetKeyByHash", mock.Anything, HashKey("rpc_stored")).Return(&APIKey{Name: "pim-sync"}, nil)
+	as.repository.On("LatestRevocation", mock.Anything).Return(time.Time{}, types.NewDatastoreUnavailableError())
+
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+	as.service.AuthenticateKey(context.Background(), "rpc_stored")
+
+	as.repository.AssertNumberOfCalls(as.T(), "GetKeyByHash", 2)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuthorizationHeader = "Authorization"
+	APIKeyHeader        = tenant.APIKeyHeader
+	bearerPrefix        = "Bearer "
+)
+
+// IdentityKey is the gin context key the authenticated caller's Identity is
+// stored under
+const IdentityKey = "identity"
+
+// Methods a caller can authenticate with
+const (
+	MethodAPIKey = "apiKey"
+	MethodJWT    = "jwt"
+)
+
+// Requirement is what a route asks of its callers
+type Requirement string
+
+const (
+	// RequirePublic serves callers without credentials. Credentials that
+	// are sent are still checked, so that the caller is known.
+	RequirePublic        Requirement = "public"
+	RequireAuthenticated Requirement = "authenticated"
+	RequireAPIKey        Requirement = "apiKey"
+	RequireJWT           Requirement = "jwt"
+)
+
+// Identity is the caller a request was authenticated as. TenantID is the
+// tenant its credential belongs to, empty when it belongs to none.
+type Identity struct {
+	Subject  string   `json:"subject"`
+	Method   string   `json:"method"`
+	TenantID string   `json:"tenantId,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+}
+
+// APIKey is an issued key. Only the SHA-256 hash of the key is stored; its
+// prefix is kept so that keys can be told apart when listed.
+type APIKey struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	TenantID  string             `json:"tenantId" bson:"tenantId"`
+	Prefix    string             `json:"prefix" bson:"prefix"`
+	Hash      string             `json:"-" bson:"hash"`
+	Roles     []string           `json:"roles,omitempty" bson:"roles,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	RevokedAt *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+type CreateKeyRequest struct {
+	Name     string
+	TenantID string
+	Roles    []string
+}
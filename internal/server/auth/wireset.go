@@ -0,0 +1,10 @@
+package auth
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+	NewVerifier,
+)
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const defaultRequestBodyLimit = 1 << 20
+
+// BodyLimiter refuses requests whose body is larger than the limit of their
+// route with 413, before handlers read it into memory. Bodies of declared
+// length are refused unread; others are read up to the limit.
+func BodyLimiter(conf config.LimitsConfig) gin.HandlerFunc {
+	fallback := int64(conf.MaxBodyBytes)
+	if fallback <= 0 {
+		fallback = defaultRequestBodyLimit
+	}
+	limits := make(map[string]int64, len(conf.Routes))
+	for _, route := range conf.Routes {
+		if route.MaxBodyBytes > 0 {
+			limits[strings.Join(strings.Fields(route.Route), " ")] = int64(route.MaxBodyBytes)
+		}
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		limit, ok := limits[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			limit = fallback
+		}
+
+		size := c.Request.ContentLength
+		if size < 0 {
+			body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+			if err != nil {
+				statusError := types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))
+				c.AbortWithStatusJSON(statusError.HTTPCode, requestid.ErrorResponse(c, statusError))
+				return
+			}
+			size = int64(len(body))
+			c.Request.Body = readCloser{Reader: bytes.NewReader(body), Closer: c.Request.Body}
+		}
+		if size > limit {
+			logging.Info(c.Request.Context(), logger.Format{
+				Message: "Rejected request body over limit",
+				Data: map[string]string{
+					"route": c.Request.Method + " " + c.FullPath(),
+					"limit": strconv.FormatInt(limit, 10),
+				},
+			})
+			statusError := types.NewPayloadTooLargeError(fmt.Sprintf("Request bodies are limited to %d bytes", limit))
+			c.AbortWithStatusJSON(statusError.HTTPCode, requestid.ErrorResponse(c, statusError))
+			return
+		}
+		c.Next()
+	}
+}
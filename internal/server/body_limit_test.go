@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BodyLimitTestSuite struct {
+	suite.Suite
+	router   *gin.Engine
+	received string
+}
+
+func (bl *BodyLimitTestSuite) SetupTest() {
+	logger.Init("debug")
+	gin.SetMode(gin.TestMode)
+	bl.router = gin.New()
+	bl.router.Use(BodyLimiter(config.LimitsConfig{
+		MaxBodyBytes: 16,
+		Routes:       []config.RouteBodyLimitConfig{{Route: "POST  /products/bulk", MaxBodyBytes: 64}},
+	}))
+	bl.received = ""
+	read := func(ctx *gin.Context) {
+		body, _ := ioutil.ReadAll(ctx.Request.Body)
+		bl.received = string(body)
+		ctx.Status(http.StatusOK)
+	}
+	bl.router.POST("/products/bulk", read)
+	bl.router.POST("/products/search", read)
+}
+
+func TestBodyLimitSuite(t *testing.T) {
+	suite.Run(t, new(BodyLimitTestSuite))
+}
+
+func (bl *BodyLimitTestSuite) perform(path, body string, declareLength bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if !declareLength {
+		req.ContentLength = -1
+	}
+	recorder := httptest.NewRecorder()
+	bl.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (bl *BodyLimitTestSuite) TestShouldRefuseDeclaredBodyOverLimitUnread() {
+	recorder := bl.perform("/products/search", strings.Repeat("x", 17), true)
+
+	assert.Equal(bl.T(), http.StatusRequestEntityTooLarge, recorder.Code)
+	var response types.ErrorResponse
+	bl.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(bl.T(), "payload_too_large", response.Error.Code)
+	assert.Equal(bl.T(), "Request bodies are limited to 16 bytes", response.Error.Message)
+	assert.Empty(bl.T(), bl.received)
+}
+
+func (bl *BodyLimitTestSuite) TestShouldRefuseBodyOfUnknownLengthOverLimit() {
+	recorder := bl.perform("/products/search", strings.Repeat("x", 17), false)
+
+	assert.Equal(bl.T(), http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Empty(bl.T(), bl.received)
+}
+
+func (bl *BodyLimitTestSuite) TestShouldPassBodiesWithinLimitWhole() {
+	assert.Equal(bl.T(), http.StatusOK, bl.perform("/products/search", strings.Repeat("x", 16), true).Code)
+	assert.Equal(bl.T(), strings.Repeat("x", 16), bl.received)
+
+	assert.Equal(bl.T(), http.StatusOK, bl.perform("/products/search", strings.Repeat("y", 16), false).Code)
+	assert.Equal(bl.T(), strings.Repeat("y", 16), bl.received)
+}
+
+func (bl *BodyLimitTestSuite) TestShouldApplyLimitOfRoute() {
+	assert.Equal(bl.T(), http.StatusOK, bl.perform("/products/bulk", strings.Repeat("x", 64), true).Code)
+	assert.Equal(bl.T(), http.StatusRequestEntityTooLarge, bl.perform("/products/bulk", strings.Repeat("x", 65), false).Code)
+}
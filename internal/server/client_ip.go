@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientAddress sets the remote address of requests relayed by one of the
+// trusted proxies, given as addresses or CIDR ranges, to the client they
+// forwarded it for: the nearest address in X-Forwarded-For that is not a
+// trusted proxy, else X-Real-IP. Forwarding headers from any other peer are
+// ignored, so that clients cannot pick the address they are limited and
+// logged under. It panics on an invalid proxy so that a typo does not go
+// unnoticed.
+func ClientAddress(trustedProxies []string) gin.HandlerFunc {
+	proxies := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		proxies = append(proxies, parseProxy(proxy))
+	}
+	trusted := func(ip net.IP) bool {
+		for _, network := range proxies {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *gin.Context) {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+		if err != nil || !trusted(net.ParseIP(host)) {
+			c.Next()
+			return
+		}
+		if client := forwardedClient(c, trusted); client != nil {
+			c.Request.RemoteAddr = net.JoinHostPort(client.String(), port)
+		}
+		c.Next()
+	}
+}
+
+func parseProxy(proxy string) *net.IPNet {
+	if strings.Contains(proxy, "/") {
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			panic(fmt.Sprintf("invalid trusted proxy %q", proxy))
+		}
+		return network
+	}
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		panic(fmt.Sprintf("invalid trusted proxy %q", proxy))
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// forwardedClient walks X-Forwarded-For from the nearest hop, which the
+// trusted peer appended, past the trusted proxies to the client. Hops beyond
+// the first untrusted one may have been sent by the client itself.
+func forwardedClient(c *gin.Context, trusted func(net.IP) bool) net.IP {
+	var hops []string
+	for _, header := range c.Request.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	var client net.IP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !trusted(ip) {
+			return client
+		}
+	}
+	if client != nil {
+		return client
+	}
+	return net.ParseIP(strings.TrimSpace(c.GetHeader("X-Real-IP")))
+}
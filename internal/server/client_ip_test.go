@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientAddressTestSuite struct {
+	suite.Suite
+	router   *gin.Engine
+	clientIP string
+}
+
+func (ca *ClientAddressTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	ca.router = gin.New()
+	ca.router.ForwardedByClientIP = false
+	ca.router.Use(ClientAddress([]string{"10.0.0.0/8", "192.0.2.7"}))
+	ca.router.GET("/products", func(ctx *gin.Context) {
+		ca.clientIP = ctx.ClientIP()
+		ctx.Status(http.StatusOK)
+	})
+}
+
+func TestClientAddressSuite(t *testing.T) {
+	suite.Run(t, new(ClientAddressTestSuite))
+}
+
+func (ca *ClientAddressTestSuite) perform(remoteAddr string, headers map[string]string) string {
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	ca.router.ServeHTTP(httptest.NewRecorder(), req)
+	return ca.clientIP
+}
+
+func (ca *ClientAddressTestSuite) TestShouldIgnoreForwardingHeadersFromUntrustedPeer() {
+	clientIP := ca.perform("203.0.113.5:4000", map[string]string{
+		"X-Forwarded-For": "198.51.100.1",
+		"X-Real-IP":       "198.51.100.2",
+	})
+
+	assert.Equal(ca.T(), "203.0.113.5", clientIP)
+}
+
+func (ca *ClientAddressTestSuite) TestShouldTakeNearestUntrustedHopFromTrustedProxy() {
+	// The client sent the first hop itself; the proxies appended the rest
+	clientIP := ca.perform("10.0.0.2:4000", map[string]string{
+		"X-Forwarded-For": "198.51.100.1, 203.0.113.5, 10.0.0.3",
+	})
+
+	assert.Equal(ca.T(), "203.0.113.5", clientIP)
+}
+
+func (ca *ClientAddressTestSuite) TestShouldTakeRealIPFromTrustedProxyWithoutForwardedFor() {
+	clientIP := ca.perform("192.0.2.7:4000", map[string]string{"X-Real-IP": "203.0.113.5"})
+
+	assert.Equal(ca.T(), "203.0.113.5", clientIP)
+}
+
+func (ca *ClientAddressTestSuite) TestShouldKeepTrustedProxyAddressWithoutForwardingHeaders() {
+	clientIP := ca.perform("10.0.0.2:4000", nil)
+
+	assert.Equal(ca.T(), "10.0.0.2", clientIP)
+}
+
+func (ca *ClientAddressTestSuite) TestShouldPanicOnInvalidProxy() {
+	assert.Panics(ca.T(), func() { ClientAddress([]string{"10.0.0.0/33"}) })
+}
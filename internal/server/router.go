@@ -2,34 +2,138 @@ package server
 
 import (
 	"github.com/gin-contrib/pprof"
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/audit"
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/currency"
 	"github.com/roppenlabs/rapid-product-catalog/internal/health"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/pricelist"
 	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/promotion"
+	"github.com/roppenlabs/rapid-product-catalog/internal/ratelimit"
+	"github.com/roppenlabs/rapid-product-catalog/internal/server/auth"
+	"github.com/roppenlabs/rapid-product-catalog/internal/stream"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"github.com/roppenlabs/rapid-product-catalog/internal/webhook"
 	logger "github.com/roppenlabs/rapido-logger-go"
 )
 
 type Handlers struct {
-	HealthHandler  *health.Handler
-	ProductHandler *product.Handler
+	MetricsHandler   *metrics.Handler
+	TracingHandler   *tracing.Handler
+	AuthHandler      *auth.Handler
+	RateLimitHandler *ratelimit.Handler
+	TenantHandler    *tenant.Handler
+	AuditHandler     *audit.Handler
+	HealthHandler    *health.Handler
+	ProductHandler   *product.Handler
+	CacheHandler     *product.CacheHandler
+	PriceHandler     *price.Handler
+	PromotionHandler *promotion.Handler
+	CurrencyHandler  *currency.Handler
+	PriceListHandler *pricelist.Handler
+	WebhookHandler   *webhook.Handler
+	StreamHandler    *stream.Handler
 }
 
 func (s *Server) InitRoutes(h Handlers, c config.Config) {
 	router := s.routerGroups.rootRouter
 
-	// Health routes
+	router.Use(h.MetricsHandler.Middleware)
+	// Oversized bodies are refused before any handler reads them
+	router.Use(BodyLimiter(c.Get().Limits))
+
+	// Health and metrics routes are served for the deployment, so they are
+	// registered before the tenant is resolved
+	router.GET("/metrics", h.MetricsHandler.Expose)
 	router.GET("/sanity", h.HealthHandler.CheckSanity)
 	router.GET("/health", h.HealthHandler.CheckHealth)
+	router.GET("/live", h.HealthHandler.CheckLive)
+	router.GET("/ready", h.HealthHandler.CheckReady)
+
+	// Probes and scrapes are not traced, stay public and are not rate
+	// limited. Addresses failing to authenticate are limited before their
+	// credentials are looked up, and clients once authenticated, so that
+	// each caller has its own quota.
+	router.Use(h.TracingHandler.Middleware)
+	router.Use(h.RateLimitHandler.Unauthenticated)
+	router.Use(h.AuthHandler.Middleware)
+	router.Use(h.RateLimitHandler.Middleware)
+	router.Use(h.TenantHandler.Middleware)
+	router.Use(h.AuditHandler.Middleware)
+
+	read := h.AuthHandler.RequireScoped(auth.PermissionRead)
+	writePrices := h.AuthHandler.Require(auth.PermissionPriceWrite)
+	remove := h.AuthHandler.Require(auth.PermissionDelete)
+	manage := h.AuthHandler.Require(auth.PermissionManage)
+
+	// Tenant routes
+	router.GET("/tenant", read, h.TenantHandler.GetTenantHandler)
+
+	// Product routes; the products written and deleted are checked against
+	// the scope of the caller's grants
+	router.POST("/products/bulk", h.AuthHandler.RequireScoped(auth.PermissionBulkWrite, auth.PermissionPriceWrite, auth.PermissionInventoryWrite), h.ProductHandler.CreateProductsHandler)
+	router.POST("/products/search", read, h.ProductHandler.SearchProductsHandler)
+	router.GET("/products/:productId", read, productOrStream(h))
+	router.DELETE("/products/:productId", h.AuthHandler.RequireScoped(auth.PermissionDelete), h.ProductHandler.DeleteProductHandler)
+
+	// Price routes
+	router.GET("/products/:productId/prices", read, h.PriceHandler.GetPriceHistoryHandler)
+	router.POST("/products/:productId/prices", writePrices, h.PriceHandler.SchedulePriceHandler)
 
-	// Product routes
-	router.POST("/products/bulk", h.ProductHandler.CreateProductsHandler)
-	router.POST("/products/search", h.ProductHandler.SearchProductsHandler)
-	router.GET("/products/:productId", h.ProductHandler.GetProductByIDHandler)
+	// Promotion routes
+	router.POST("/promotions", writePrices, h.PromotionHandler.CreatePromotionHandler)
+	router.GET("/promotions", read, h.PromotionHandler.ListPromotionsHandler)
+	router.GET("/promotions/:promotionId", read, h.PromotionHandler.GetPromotionByIDHandler)
+	router.PUT("/promotions/:promotionId", writePrices, h.PromotionHandler.UpdatePromotionHandler)
+	router.DELETE("/promotions/:promotionId", remove, h.PromotionHandler.DeletePromotionHandler)
+
+	// Price list routes
+	router.POST("/price-lists", writePrices, h.PriceListHandler.CreatePriceListHandler)
+	router.GET("/price-lists", read, h.PriceListHandler.ListPriceListsHandler)
+	router.GET("/price-lists/:code", read, h.PriceListHandler.GetPriceListHandler)
+	router.PUT("/price-lists/:code", writePrices, h.PriceListHandler.UpdatePriceListHandler)
+	router.DELETE("/price-lists/:code", remove, h.PriceListHandler.DeletePriceListHandler)
+	router.GET("/price-lists/:code/prices", read, h.PriceListHandler.GetPricesHandler)
+	router.PUT("/price-lists/:code/prices", writePrices, h.PriceListHandler.SetPricesHandler)
+	router.DELETE("/price-lists/:code/prices/:productId", writePrices, h.PriceListHandler.RemovePriceHandler)
+
+	// Webhook subscription routes
+	router.POST("/webhooks", manage, h.WebhookHandler.CreateSubscriptionHandler)
+	router.GET("/webhooks", manage, h.WebhookHandler.ListSubscriptionsHandler)
+	router.GET("/webhooks/:subscriptionId", manage, h.WebhookHandler.GetSubscriptionHandler)
+	router.PUT("/webhooks/:subscriptionId", manage, h.WebhookHandler.UpdateSubscriptionHandler)
+	router.DELETE("/webhooks/:subscriptionId", manage, h.WebhookHandler.DeleteSubscriptionHandler)
+	router.GET("/webhooks/:subscriptionId/deliveries", manage, h.WebhookHandler.ListDeliveriesHandler)
+	router.POST("/webhooks/:subscriptionId/replay", manage, h.WebhookHandler.ReplayHandler)
+
+	// Admin routes
+	router.GET("/audit-log", manage, h.AuditHandler.QueryHandler)
+	router.GET("/admin/cache-stats", manage, h.CacheHandler.GetStatsHandler)
+	router.GET("/admin/currency-rates", read, h.CurrencyHandler.GetRatesHandler)
+	router.PUT("/admin/currency-rates", manage, h.CurrencyHandler.UpdateRatesHandler)
 
 	// Register pprof handlers
 	if c.Get().ProfilingEnabled {
 		logger.Info(logger.Format{
 			Message: "ALERT! Profiling enabled. Please be aware of the performance impact it could have",
 		})
-		pprof.Register(router)
+		pprof.RouteRegister(router.Group("", manage))
+	}
+}
+
+// productOrStream serves GET /products/stream alongside GET
+// /products/:productId, since the router cannot register a static segment
+// next to a parameter
+func productOrStream(h Handlers) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Param("productId") == "stream" {
+			h.StreamHandler.StreamProductsHandler(ctx)
+			return
+		}
+		h.ProductHandler.GetProductByIDHandler(ctx)
 	}
 }
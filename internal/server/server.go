@@ -6,12 +6,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/health"
+	"github.com/roppenlabs/rapid-product-catalog/internal/price"
+	"github.com/roppenlabs/rapid-product-catalog/internal/product"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/stream"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"github.com/roppenlabs/rapid-product-catalog/internal/webhook"
 	logger "github.com/roppenlabs/rapido-logger-go"
 )
 
@@ -19,13 +27,48 @@ type Server struct {
 	config       config.Config
 	engine       *gin.Engine
 	routerGroups RouterGroups
+	checks       *health.Registry
 }
 
 type RouterGroups struct {
 	rootRouter *gin.Engine
 }
 
-func NewServer(c config.Config) *Server {
+// Worker is a background process that runs alongside the HTTP server until
+// its context is cancelled on shutdown
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+type Workers struct {
+	PriceScheduler   *price.Scheduler
+	EventDispatcher  *events.Dispatcher
+	WebhookDeliverer *webhook.Deliverer
+	StreamHub        *stream.Hub
+	ProductCache     *product.CachedRepository
+	CatalogMetrics   *product.CatalogMetrics
+	Tracer           *tracing.Tracer
+}
+
+// namedWorker is a worker with the name its health check is reported under
+type namedWorker struct {
+	name   string
+	worker Worker
+}
+
+func (w Workers) list() []namedWorker {
+	return []namedWorker{
+		{"priceScheduler", w.PriceScheduler},
+		{"eventDispatcher", w.EventDispatcher},
+		{"webhookDeliverer", w.WebhookDeliverer},
+		{"streamHub", w.StreamHub},
+		{"productCache", w.ProductCache},
+		{"catalogMetrics", w.CatalogMetrics},
+		{"tracer", w.Tracer},
+	}
+}
+
+func NewServer(c config.Config, checks *health.Registry) *Server {
 
 	if c.IsProductionEnv() {
 		logger.Info(logger.Format{Message: "Setting gin server to release mode for production environment"})
@@ -42,13 +85,21 @@ func NewServer(c config.Config) *Server {
 		}
 	}
 	engine := gin.New()
-	loggerConfig := gin.LoggerConfig{
-		SkipPaths: []string{"/sanity", "/health"},
-	}
-	engine.Use(LoggerWithConfig(loggerConfig), gin.Recovery())
+	// Forwarding headers are only read from trusted proxies, by
+	// ClientAddress, which runs first so that everything sees the client
+	engine.ForwardedByClientIP = false
+	// The request ID is assigned next, so that the access log and every
+	// error response carry it
+	engine.Use(
+		ClientAddress(c.Get().Server.TrustedProxies),
+		requestid.Middleware,
+		AccessLogger(c.Get().Log.Access, "/sanity", "/health", "/live", "/ready", "/metrics"),
+		gin.Recovery(),
+	)
 
 	return &Server{
 		config: c,
+		checks: checks,
 		engine: engine,
 		routerGroups: RouterGroups{
 			rootRouter: engine,
@@ -56,14 +107,33 @@ func NewServer(c config.Config) *Server {
 	}
 }
 
-func (s *Server) Run(h Handlers) {
+func (s *Server) Run(h Handlers, w Workers) {
 	s.InitRoutes(h, s.config)
 	srv := &http.Server{
 		Addr:    s.config.Get().ListenAddress(),
 		Handler: s.engine,
 	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, named := range w.list() {
+		running := &health.Running{}
+		running.Start()
+		s.checks.Register("worker:"+named.name, running.Check)
+
+		workers.Add(1)
+		go func(worker Worker) {
+			defer workers.Done()
+			defer running.Stop()
+			worker.Run(workerCtx)
+		}(named.worker)
+	}
+
 	go listenServer(srv)
-	waitForShutdown(srv)
+	s.waitForShutdown(srv)
+
+	stopWorkers()
+	workers.Wait()
 }
 
 func listenServer(server *http.Server) {
@@ -73,13 +143,20 @@ func listenServer(server *http.Server) {
 	}
 }
 
-func waitForShutdown(server *http.Server) {
+// waitForShutdown reports the instance not ready as soon as it is signalled
+// and keeps serving for the drain delay, so that load balancers stop routing
+// to it before it refuses connections
+func (s *Server) waitForShutdown(server *http.Server) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig,
 		syscall.SIGINT,
 		syscall.SIGTERM)
 	_ = <-sig
 	logger.Info(logger.Format{Message: "server shutting down"})
+	s.checks.Drain()
+	if drainDelay := s.config.Get().Health.DrainDelay; drainDelay > 0 {
+		time.Sleep(time.Duration(drainDelay) * time.Second)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	err := server.Shutdown(ctx)
@@ -88,64 +165,3 @@ func waitForShutdown(server *http.Server) {
 	}
 	logger.Info(logger.Format{Message: "server shutdown complete"})
 }
-
-// LoggerWithConfig instance a Logger middleware with config.
-func LoggerWithConfig(conf gin.LoggerConfig) gin.HandlerFunc {
-
-	notlogged := conf.SkipPaths
-
-	var skip map[string]struct{}
-
-	if length := len(notlogged); length > 0 {
-		skip = make(map[string]struct{}, length)
-
-		for _, path := range notlogged {
-			skip[path] = struct{}{}
-		}
-	}
-
-	return func(c *gin.Context) {
-		// Start timer
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
-
-		// Process request
-		c.Next()
-
-		// Log only when path is not being skipped
-		if _, ok := skip[path]; !ok {
-			param := gin.LogFormatterParams{
-				Request: c.Request,
-				Keys:    c.Keys,
-			}
-
-			// Stop timer
-			param.TimeStamp = time.Now()
-			param.Latency = param.TimeStamp.Sub(start)
-
-			param.ClientIP = c.ClientIP()
-			param.Method = c.Request.Method
-			param.StatusCode = c.Writer.Status()
-			param.ErrorMessage = c.Errors.ByType(gin.ErrorTypePrivate).String()
-
-			param.BodySize = c.Writer.Size()
-
-			if raw != "" {
-				path = path + "?" + raw
-			}
-
-			logger.Debug(logger.Format{
-				Message: fmt.Sprintf("Accessing %s", path),
-				Data: map[string]string{
-					"method":     param.Method,
-					"clientIP":   param.ClientIP,
-					"statusCode": strconv.Itoa(param.StatusCode),
-					"error":      param.ErrorMessage,
-					"latency":    param.Latency.String(),
-					"size":       strconv.Itoa(param.BodySize),
-				},
-			})
-		}
-	}
-}
@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultKeepAlive = 15 * time.Second
+
+type Handler struct {
+	hub       *Hub
+	keepAlive time.Duration
+}
+
+func NewHandler(cfg config.Config, hub *Hub) *Handler {
+	keepAlive := time.Duration(cfg.Get().Stream.KeepAlive) * time.Second
+	if keepAlive <= 0 {
+		keepAlive = defaultKeepAlive
+	}
+
+	return &Handler{
+		hub:       hub,
+		keepAlive: keepAlive,
+	}
+}
+
+// StreamProductsHandler pushes product changes as Server-Sent Events, named
+// by event type and carrying the notification as JSON. The category, brand
+// and productIds query parameters take comma separated values. Clients resume
+// with the Last-Event-ID header, or the lastEventId parameter on first
+// connect.
+func (h *Handler) StreamProductsHandler(ctx *gin.Context) {
+	filter, err := parseFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(err.Error())))
+		return
+	}
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("lastEventId")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Last-Event-ID must be a non-negative integer")))
+			return
+		}
+	}
+
+	client, replay, resync := h.hub.Subscribe(filter, lastID)
+	defer h.hub.Unsubscribe(client)
+
+	logging.Info(ctx.Request.Context(), logger.Format{Message: "Product stream client connected", Data: map[string]string{"lastEventId": lastEventID}})
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if resync {
+		fmt.Fprintf(ctx.Writer, "event: %s\ndata: {}\n\n", ResyncEvent)
+	}
+	for _, notification := range replay {
+		if err := writeNotification(ctx, notification); err != nil {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case notification, ok := <-client.C:
+			if !ok {
+				return
+			}
+			if err := writeNotification(ctx, notification); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeNotification(ctx *gin.Context, notification Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", notification.ID, notification.Type, data)
+	return err
+}
+
+func parseFilter(ctx *gin.Context) (Filter, error) {
+	filter := Filter{
+		TenantID:   tenant.ID(ctx.Request.Context()),
+		Categories: splitList(ctx.Query("category")),
+		Brands:     splitList(ctx.Query("brand")),
+	}
+	for _, id := range splitList(ctx.Query("productIds")) {
+		productID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return Filter{}, fmt.Errorf("Invalid product ID format: %s", id)
+		}
+		filter.ProductIDs = append(filter.ProductIDs, productID)
+	}
+	return filter, nil
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const (
+	defaultReplayBuffer = 1000
+	defaultClientBuffer = 64
+)
+
+// Hub follows the in-process event broker, keeps the latest notifications
+// for clients resuming with Last-Event-ID, and fans new ones out to the
+// connected clients
+type Hub struct {
+	broker       *events.Broker
+	repository   events.Repository
+	capacity     int
+	clientBuffer int
+
+	mu      sync.Mutex
+	buffer  []Notification
+	clients map[*Client]struct{}
+	// complete is the ID after which the buffer holds every notification
+	complete int64
+}
+
+// Client is one connected stream. C is closed when the hub stops or drops
+// the client for falling behind; the client then reconnects and resumes.
+type Client struct {
+	C      <-chan Notification
+	events chan Notification
+	filter Filter
+}
+
+func NewHub(cfg config.Config, broker *events.Broker, repository events.Repository) *Hub {
+	streamConfig := cfg.Get().Stream
+	hub := &Hub{
+		broker:       broker,
+		repository:   repository,
+		capacity:     streamConfig.ReplayBuffer,
+		clientBuffer: streamConfig.ClientBuffer,
+		clients:      map[*Client]struct{}{},
+	}
+	if hub.capacity <= 0 {
+		hub.capacity = defaultReplayBuffer
+	}
+	if hub.clientBuffer <= 0 {
+		hub.clientBuffer = defaultClientBuffer
+	}
+	return hub
+}
+
+// Run blocks until ctx is cancelled, following the broker. If the broker
+// drops the hub for falling behind, the hub resubscribes with an empty buffer.
+func (h *Hub) Run(ctx context.Context) {
+	logger.Info(logger.Format{Message: "Product stream hub started"})
+
+	for {
+		subscription := h.broker.Subscribe(h.capacity)
+		h.reset(ctx)
+		if !h.follow(ctx, subscription) {
+			subscription.Close()
+			h.closeClients()
+			logger.Info(logger.Format{Message: "Product stream hub stopped"})
+			return
+		}
+
+		// Connected clients may have missed events too; they reconnect and
+		// are told to resync
+		logger.Error(logger.Format{Message: "Product stream hub fell behind, resetting replay buffer"})
+		h.closeClients()
+	}
+}
+
+// follow publishes the subscription's events until ctx is cancelled, when it
+// returns false, or the broker drops the subscription
+func (h *Hub) follow(ctx context.Context, subscription *events.Subscription) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-subscription.Done:
+			return true
+		case event := <-subscription.C:
+			h.publish(newNotification(event))
+		}
+	}
+}
+
+func (h *Hub) publish(notification Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.buffer) == h.capacity {
+		h.complete = h.buffer[0].ID
+		h.buffer = h.buffer[1:]
+	}
+	h.buffer = append(h.buffer, notification)
+
+	for client := range h.clients {
+		if !client.filter.matches(notification) {
+			continue
+		}
+		select {
+		case client.events <- notification:
+		default:
+			h.remove(client)
+		}
+	}
+}
+
+// Subscribe registers a client and returns the buffered notifications after
+// lastID that match filter. A lastID of 0 starts from now. resync reports
+// that notifications after lastID may have been missed.
+func (h *Hub) Subscribe(filter Filter, lastID int64) (client *Client, replay []Notification, resync bool) {
+	notifications := make(chan Notification, h.clientBuffer)
+	client = &Client{C: notifications, events: notifications, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if lastID > 0 {
+		resync = lastID < h.complete
+		for _, notification := range h.buffer {
+			if notification.ID > lastID && filter.matches(notification) {
+				replay = append(replay, notification)
+			}
+		}
+	}
+	h.clients[client] = struct{}{}
+	return client, replay, resync
+}
+
+// Unsubscribe stops delivery to the client
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(client)
+}
+
+// remove must be called with mu held
+func (h *Hub) remove(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	close(client.events)
+}
+
+// reset empties the buffer on (re)subscribing to the broker. Every event
+// recorded so far was either missed or is about to be received again, so the
+// buffer is complete after the latest sequence number. If that cannot be
+// read, every resuming client is told to resync.
+func (h *Hub) reset(ctx context.Context) {
+	complete, err := h.repository.LastSeq(ctx)
+	if err != nil {
+		complete = math.MaxInt64
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buffer = nil
+	h.complete = complete
+}
+
+func (h *Hub) closeClients() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		h.remove(client)
+	}
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StreamHubTestSuite struct {
+	suite.Suite
+	repository *events.MockRepository
+	hub        *Hub
+}
+
+func (hs *StreamHubTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		hs.T().Fatalf("Failed to initialize config: %v", err)
+	}
+	logger.Init(cfg.Get().Log.Level)
+	hs.repository = new(events.MockRepository)
+	hs.repository.On("LastSeq", mock.Anything).Return(int64(10), nil)
+	hs.hub = NewHub(cfg, events.NewBroker(), hs.repository)
+	hs.hub.capacity = 3
+	hs.hub.clientBuffer = 1
+	hs.hub.reset(context.Background())
+}
+
+func TestStreamHubSuite(t *testing.T) {
+	suite.Run(t, new(StreamHubTestSuite))
+}
+
+func (hs *StreamHubTestSuite) publish(seq int64, category string, changedFields ...string) {
+	hs.hub.publish(newNotification(events.Event{
+		Seq:           seq,
+		Type:          events.TypeProductUpdated,
+		ProductID:     primitive.NewObjectID(),
+		ChangedFields: changedFields,
+		Product:       events.Snapshot{Category: category},
+	}))
+}
+
+func (hs *StreamHubTestSuite) TestShouldReplayMatchingNotificationsAfterLastEventID() {
+	hs.publish(11, "watch", "price")
+	hs.publish(12, "shoes", "availableQty")
+	hs.publish(13, "watch", "availableQty", "name")
+
+	_, replay, resync := hs.hub.Subscribe(Filter{Categories: []string{"watch"}}, 11)
+
+	assert.False(hs.T(), resync)
+	assert.Len(hs.T(), replay, 1)
+	assert.Equal(hs.T(), int64(13), replay[0].ID)
+	assert.Equal(hs.T(), []string{ChangeStock, ChangeDetails}, replay[0].Changes)
+}
+
+func (hs *StreamHubTestSuite) TestShouldAskForResyncWhenLastEventIDWasEvicted() {
+	hs.publish(11, "watch", "price")
+	hs.publish(12, "watch", "price")
+	hs.publish(13, "watch", "price")
+	hs.publish(14, "watch", "price")
+
+	_, replay, resync := hs.hub.Subscribe(Filter{}, 10)
+
+	assert.True(hs.T(), resync)
+	assert.Len(hs.T(), replay, 3)
+
+	_, _, resync = hs.hub.Subscribe(Filter{}, 11)
+	assert.False(hs.T(), resync)
+}
+
+func (hs *StreamHubTestSuite) TestShouldDropClientThatFallsBehind() {
+	client, _, _ := hs.hub.Subscribe(Filter{}, 0)
+
+	hs.publish(11, "watch", "price")
+	hs.publish(12, "watch", "price")
+
+	assert.Equal(hs.T(), int64(11), (<-client.C).ID)
+	_, open := <-client.C
+	assert.False(hs.T(), open)
+}
+
+func (hs *StreamHubTestSuite) TestShouldOnlyStreamNotificationsOfClientTenant() {
+	client, _, _ := hs.hub.Subscribe(Filter{TenantID: "wholesale"}, 0)
+
+	hs.hub.publish(newNotification(events.Event{Seq: 11, TenantID: "retail", Type: events.TypeProductCreated}))
+	hs.hub.publish(newNotification(events.Event{Seq: 12, TenantID: "wholesale", Type: events.TypeProductCreated}))
+
+	assert.Equal(hs.T(), int64(12), (<-client.C).ID)
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of change a notification reports
+const (
+	ChangePrice   = "price"
+	ChangeStock   = "stock"
+	ChangeStatus  = "status"
+	ChangeDetails = "details"
+)
+
+const (
+	StatusActive  = "active"
+	StatusDeleted = "deleted"
+)
+
+// ResyncEvent tells a client that notifications it asked to resume from are
+// no longer buffered, so it should refetch the products it shows
+const ResyncEvent = "resync"
+
+// Notification is a product change as pushed to stream clients. Its ID is the
+// event's sequence number, which clients send back as Last-Event-ID.
+type Notification struct {
+	ID        int64              `json:"id"`
+	TenantID  string             `json:"-"`
+	Type      string             `json:"type"`
+	ProductID primitive.ObjectID `json:"productId"`
+	Version   int64              `json:"version"`
+	// Changes is empty when any field may have changed
+	Changes    []string    `json:"changes,omitempty"`
+	Status     string      `json:"status"`
+	Name       string      `json:"name"`
+	Category   string      `json:"category"`
+	Brand      string      `json:"brand"`
+	Price      money.Money `json:"price"`
+	Inventory  int         `json:"inventory"`
+	OccurredAt time.Time   `json:"occurredAt"`
+}
+
+// Filter selects the notifications a client receives, only ever from the
+// client's own tenant; an empty list matches everything
+type Filter struct {
+	TenantID   string
+	Categories []string
+	Brands     []string
+	ProductIDs []primitive.ObjectID
+}
+
+func (f Filter) matches(n Notification) bool {
+	return n.TenantID == f.TenantID &&
+		containsString(f.Categories, n.Category) &&
+		containsString(f.Brands, n.Brand) &&
+		containsID(f.ProductIDs, n.ProductID)
+}
+
+func containsString(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(values []primitive.ObjectID, value primitive.ObjectID) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newNotification maps the event's changed fields onto the kinds of change
+// clients follow
+func newNotification(event events.Event) Notification {
+	notification := Notification{
+		ID:         event.Seq,
+		TenantID:   event.TenantID,
+		Type:       event.Type,
+		ProductID:  event.ProductID,
+		Version:    event.Version,
+		Status:     StatusActive,
+		Name:       event.Product.Name,
+		Category:   event.Product.Category,
+		Brand:      event.Product.Brand,
+		Price:      event.Product.Price,
+		Inventory:  event.Product.Inventory,
+		OccurredAt: event.OccurredAt,
+	}
+
+	switch event.Type {
+	case events.TypeProductCreated:
+		notification.Changes = []string{ChangeStatus}
+	case events.TypeProductDeleted:
+		notification.Status = StatusDeleted
+		notification.Changes = []string{ChangeStatus}
+	default:
+		seen := map[string]bool{}
+		for _, field := range event.ChangedFields {
+			change := ChangeDetails
+			switch field {
+			case "price", "priceLists", "currencyPrices":
+				change = ChangePrice
+			case "availableQty":
+				change = ChangeStock
+			}
+			if !seen[change] {
+				seen[change] = true
+				notification.Changes = append(notification.Changes, change)
+			}
+		}
+	}
+	return notification
+}
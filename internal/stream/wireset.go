@@ -0,0 +1,8 @@
+package stream
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewHub,
+)
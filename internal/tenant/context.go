@@ -0,0 +1,65 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type contextKey struct{}
+
+var (
+	defaultIDMu sync.RWMutex
+	defaultID   = "default"
+)
+
+// SetDefault sets the tenant that owns work done outside a request, and
+// requests that name no tenant
+func SetDefault(id string) {
+	if id == "" {
+		return
+	}
+	defaultIDMu.Lock()
+	defer defaultIDMu.Unlock()
+	defaultID = id
+}
+
+func Default() string {
+	defaultIDMu.RLock()
+	defer defaultIDMu.RUnlock()
+	return defaultID
+}
+
+// WithTenant returns a context whose reads and writes are confined to t
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant ctx is confined to, if any
+func FromContext(ctx context.Context) (Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(Tenant)
+	return t, ok
+}
+
+// ID returns the ID of the tenant ctx is confined to, falling back to the
+// default tenant
+func ID(ctx context.Context) string {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return Default()
+}
+
+// Filter returns a copy of filter that only matches records of the tenant
+// of ctx. Every query on tenant owned collections goes through it.
+func Filter(ctx context.Context, filter bson.M) bson.M {
+	scoped := bson.M{Field: ID(ctx)}
+	for field, value := range filter {
+		if field == Field {
+			continue
+		}
+		scoped[field] = value
+	}
+	return scoped
+}
@@ -0,0 +1,56 @@
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+type Handler struct {
+	registry *Registry
+}
+
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{
+		registry: registry,
+	}
+}
+
+// Middleware resolves the tenant of each request and confines the request's
+// context to it, rejecting requests that name an unknown tenant or key. The
+// tenant of a request authenticated beforehand is the one its credential
+// belongs to, unless the caller may serve any tenant.
+func (h *Handler) Middleware(ctx *gin.Context) {
+	var t Tenant
+	var err error
+	if bound, ok := ctx.Get(BoundKey); ok {
+		t, err = h.registry.ResolveBound(bound.(string), ctx.GetHeader(IDHeader), ctx.GetBool(AnyTenantKey))
+	} else {
+		t, err = h.registry.Resolve(ctx.GetHeader(IDHeader), ctx.GetHeader(APIKeyHeader))
+	}
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		logging.Info(ctx.Request.Context(), logger.Format{
+			Message: "Rejected request for unresolved tenant",
+			Data: map[string]string{
+				"error":    statusError.Message,
+				"tenantID": ctx.GetHeader(IDHeader),
+			},
+		})
+		ctx.AbortWithStatusJSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Request = ctx.Request.WithContext(WithTenant(ctx.Request.Context(), t))
+	ctx.Next()
+}
+
+// GetTenantHandler returns the tenant the request was resolved to
+func (h *Handler) GetTenantHandler(ctx *gin.Context) {
+	t, _ := FromContext(ctx.Request.Context())
+	ctx.JSON(http.StatusOK, Response{Success: true, Tenant: t})
+}
@@ -0,0 +1,174 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type TenantHandlerTestSuite struct {
+	suite.Suite
+	server *testutils.TestServer
+	values *config.Values
+}
+
+func (th *TenantHandlerTestSuite) SetupTest() {
+	th.values = &config.Values{
+		Tenancy: config.TenancyConfig{
+			Enabled: true,
+			Default: "retail",
+			Tenants: []config.TenantConfig{
+				{ID: "retail", Name: "Retail"},
+				{ID: "wholesale", Name: "Wholesale", APIKeys: []string{"wholesale-key"}, MaxProducts: 500},
+			},
+		},
+	}
+	th.server = testutils.NewServer()
+	handler := NewHandler(NewRegistry(th.values))
+
+	router := th.server.Router()
+	router.Use(handler.Middleware)
+	router.GET("/tenant", handler.GetTenantHandler)
+
+	logger.Init("debug")
+}
+
+func TestTenantHandlerSuite(t *testing.T) {
+	suite.Run(t, new(TenantHandlerTestSuite))
+}
+
+func (th *TenantHandlerTestSuite) resolve(headers map[string]string) (int, Tenant) {
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	th.server.Start(req)
+
+	var response Response
+	json.Unmarshal(th.server.Recorder().Body.Bytes(), &response)
+	return th.server.Recorder().Code, response.Tenant
+}
+
+func (th *TenantHandlerTestSuite) TestShouldResolveTenantFromAPIKey() {
+	code, resolved := th.resolve(map[string]string{APIKeyHeader: "wholesale-key"})
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), Tenant{ID: "wholesale", Name: "Wholesale", MaxProducts: 500}, resolved)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldResolveTenantFromHeader() {
+	code, resolved := th.resolve(map[string]string{IDHeader: "wholesale"})
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "wholesale", resolved.ID)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldFallBackToDefaultTenant() {
+	code, resolved := th.resolve(nil)
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "retail", resolved.ID)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldRejectUnknownAPIKey() {
+	code, _ := th.resolve(map[string]string{APIKeyHeader: "unknown-key"})
+
+	assert.Equal(th.T(), http.StatusUnauthorized, code)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldRejectAPIKeyOfAnotherTenant() {
+	code, _ := th.resolve(map[string]string{IDHeader: "retail", APIKeyHeader: "wholesale-key"})
+
+	assert.Equal(th.T(), http.StatusForbidden, code)
+}
+
+func (th *TenantHandlerTestSuite) bind(tenantID string) {
+	handler := NewHandler(NewRegistry(th.values))
+	th.server = testutils.NewServer()
+	th.server.Router().Use(func(ctx *gin.Context) {
+		ctx.Set(BoundKey, tenantID)
+	}, handler.Middleware)
+	th.server.Router().GET("/tenant", handler.GetTenantHandler)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldResolveTenantOfAuthenticatedCredential() {
+	th.bind("wholesale")
+
+	code, resolved := th.resolve(map[string]string{APIKeyHeader: "key-stored-in-mongo"})
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "wholesale", resolved.ID)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldRejectTenantOtherThanCredentials() {
+	th.bind("wholesale")
+
+	code, _ := th.resolve(map[string]string{IDHeader: "retail"})
+
+	assert.Equal(th.T(), http.StatusForbidden, code)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldServeUnboundCredentialAsDefaultTenant() {
+	th.bind("")
+
+	code, resolved := th.resolve(nil)
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "retail", resolved.ID)
+
+	th.bind("")
+	code, _ = th.resolve(map[string]string{IDHeader: "wholesale"})
+
+	assert.Equal(th.T(), http.StatusForbidden, code)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldLetCallerAllowedAnyTenantNameTenant() {
+	handler := NewHandler(NewRegistry(th.values))
+	th.server = testutils.NewServer()
+	th.server.Router().Use(func(ctx *gin.Context) {
+		ctx.Set(BoundKey, "")
+		ctx.Set(AnyTenantKey, true)
+	}, handler.Middleware)
+	th.server.Router().GET("/tenant", handler.GetTenantHandler)
+
+	code, resolved := th.resolve(map[string]string{IDHeader: "wholesale"})
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "wholesale", resolved.ID)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldRejectUnknownTenant() {
+	code, _ := th.resolve(map[string]string{IDHeader: "unknown"})
+
+	assert.Equal(th.T(), http.StatusNotFound, code)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldIgnoreHeadersWhenTenancyIsDisabled() {
+	th.values.Tenancy.Enabled = false
+	handler := NewHandler(NewRegistry(th.values))
+	th.server = testutils.NewServer()
+	th.server.Router().Use(handler.Middleware)
+	th.server.Router().GET("/tenant", handler.GetTenantHandler)
+
+	code, resolved := th.resolve(map[string]string{IDHeader: "wholesale"})
+
+	assert.Equal(th.T(), http.StatusOK, code)
+	assert.Equal(th.T(), "retail", resolved.ID)
+}
+
+func (th *TenantHandlerTestSuite) TestShouldConfineFiltersToTenant() {
+	ctx := WithTenant(context.Background(), Tenant{ID: "wholesale"})
+
+	assert.Equal(th.T(), bson.M{Field: "wholesale", "code": "vip"}, Filter(ctx, bson.M{"code": "vip", Field: "retail"}))
+	assert.Equal(th.T(), bson.M{Field: Default()}, Filter(context.Background(), bson.M{}))
+}
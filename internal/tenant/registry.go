@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+)
+
+// Registry holds the configured tenants and resolves the tenant of a request
+type Registry struct {
+	enabled   bool
+	defaultID string
+	tenants   map[string]Tenant
+	apiKeys   map[string]string
+}
+
+func NewRegistry(cfg config.Config) *Registry {
+	values := cfg.Get()
+	registry := &Registry{
+		enabled:   values.Tenancy.Enabled,
+		defaultID: values.DefaultTenant(),
+		tenants:   map[string]Tenant{},
+		apiKeys:   map[string]string{},
+	}
+
+	for _, tenantConfig := range values.Tenancy.Tenants {
+		if tenantConfig.ID == "" {
+			panic("tenant ID cannot be empty")
+		}
+		if _, ok := registry.tenants[tenantConfig.ID]; ok {
+			panic(fmt.Sprintf("tenant %q is configured more than once", tenantConfig.ID))
+		}
+		registry.tenants[tenantConfig.ID] = Tenant{
+			ID:          tenantConfig.ID,
+			Name:        tenantConfig.Name,
+			MaxProducts: tenantConfig.MaxProducts,
+		}
+		for _, apiKey := range tenantConfig.APIKeys {
+			if owner, ok := registry.apiKeys[apiKey]; ok && owner != tenantConfig.ID {
+				panic(fmt.Sprintf("API key is shared by tenants %q and %q", owner, tenantConfig.ID))
+			}
+			registry.apiKeys[apiKey] = tenantConfig.ID
+		}
+	}
+
+	// The default tenant always exists, so a deployment without tenancy
+	// keeps serving its single catalog
+	if _, ok := registry.tenants[registry.defaultID]; !ok {
+		registry.tenants[registry.defaultID] = Tenant{ID: registry.defaultID, Name: registry.defaultID}
+	}
+
+	return registry
+}
+
+// Resolve returns the tenant named by a request's API key or tenant ID
+// header. When both are given they must agree; with neither, or with tenancy
+// disabled, the request is served as the default tenant.
+func (r *Registry) Resolve(id, apiKey string) (Tenant, error) {
+	if !r.enabled {
+		return r.tenants[r.defaultID], nil
+	}
+
+	if apiKey != "" {
+		owner, ok := r.apiKeys[apiKey]
+		if !ok {
+			return Tenant{}, types.NewUnauthorizedError("Invalid API key")
+		}
+		if id != "" && id != owner {
+			return Tenant{}, types.NewForbiddenError("API key does not belong to the requested tenant")
+		}
+		id = owner
+	}
+	return r.lookup(id)
+}
+
+// ResolveBound returns the tenant of a request whose credential was
+// authenticated as belonging to bound, the default tenant when it belongs to
+// none. The tenant ID header, when given, must agree with it unless the
+// caller may serve any tenant.
+func (r *Registry) ResolveBound(bound, id string, anyTenant bool) (Tenant, error) {
+	if !r.enabled {
+		return r.tenants[r.defaultID], nil
+	}
+
+	if anyTenant && id != "" {
+		return r.lookup(id)
+	}
+	if bound == "" {
+		bound = r.defaultID
+	}
+	if id != "" && id != bound {
+		return Tenant{}, types.NewForbiddenError("Credential does not belong to the requested tenant")
+	}
+	return r.lookup(bound)
+}
+
+func (r *Registry) lookup(id string) (Tenant, error) {
+	if id == "" {
+		id = r.defaultID
+	}
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return Tenant{}, types.NewNotFoundError("Tenant not found")
+	}
+	return t, nil
+}
+
+// KeyOwner returns the tenant a configured API key belongs to
+func (r *Registry) KeyOwner(apiKey string) (string, bool) {
+	owner, ok := r.apiKeys[apiKey]
+	return owner, ok
+}
+
+// Get returns the configured tenant with id
+func (r *Registry) Get(id string) (Tenant, bool) {
+	t, ok := r.tenants[id]
+	return t, ok
+}
+
+// All returns every tenant in ID order, for work that runs across them
+func (r *Registry) All() []Tenant {
+	tenants := make([]Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
@@ -0,0 +1,30 @@
+package tenant
+
+// Field is the document field holding the tenant that owns a record
+const Field = "tenantId"
+
+const (
+	IDHeader     = "X-Tenant-ID"
+	APIKeyHeader = "X-API-Key"
+)
+
+// BoundKey is the gin context key authentication sets to the tenant the
+// caller's credential belongs to, empty when it belongs to none
+const BoundKey = "boundTenant"
+
+// AnyTenantKey is the gin context key authentication sets when the caller
+// may choose its tenant with the tenant ID header
+const AnyTenantKey = "anyTenant"
+
+// Tenant is one catalog hosted by the deployment. MaxProducts of zero leaves
+// its catalog unbounded.
+type Tenant struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	MaxProducts int    `json:"maxProducts,omitempty"`
+}
+
+type Response struct {
+	Success bool   `json:"success"`
+	Tenant  Tenant `json:"tenant"`
+}
@@ -0,0 +1,8 @@
+package tenant
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewRegistry,
+)
@@ -56,8 +56,12 @@ func (suite *TestServer) Recorder() *httptest.ResponseRecorder {
 
 func (suite *TestServer) PerformRequest(url, method string, requestBody interface{}) {
 	buf := new(bytes.Buffer)
-	if requestBody != nil {
-		if err := json.NewEncoder(buf).Encode(requestBody); err != nil {
+	switch body := requestBody.(type) {
+	case nil:
+	case []byte:
+		buf.Write(body)
+	default:
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
 			panic(err)
 		}
 	}
@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	otlpTracesPath      = "/v1/traces"
+	defaultOTLPEndpoint = "http://localhost:4318"
+	scopeName           = "github.com/roppenlabs/rapid-product-catalog"
+)
+
+// OTLP status codes
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+type exporter interface {
+	export(ctx context.Context, spans []*Span) error
+}
+
+// otlpExporter sends spans to a collector over OTLP/HTTP, encoded as JSON
+type otlpExporter struct {
+	client      *http.Client
+	url         string
+	serviceName string
+}
+
+// newOTLPExporter accepts the collector's base endpoint or its full traces
+// URL
+func newOTLPExporter(endpoint, serviceName string) *otlpExporter {
+	if endpoint == "" {
+		endpoint = defaultOTLPEndpoint
+	}
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	return &otlpExporter{
+		client:      &http.Client{},
+		url:         url,
+		serviceName: serviceName,
+	}
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	TraceState        string          `json:"traceState,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue holds exactly one of its fields; integers are strings, as OTLP
+// JSON encodes 64-bit integers
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func attributeValue(value interface{}) otlpValue {
+	switch v := value.(type) {
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	}
+	s := fmt.Sprint(value)
+	return otlpValue{StringValue: &s}
+}
+
+func (e *otlpExporter) export(ctx context.Context, spans []*Span) error {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, encodeSpan(span))
+	}
+	payload := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{{Key: "service.name", Value: attributeValue(e.serviceName)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusMultipleChoices {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("collector responded %d: %s", response.StatusCode, message)
+	}
+	return nil
+}
+
+func encodeSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	encoded := otlpSpan{
+		TraceID:           span.context.TraceID.String(),
+		SpanID:            span.context.SpanID.String(),
+		TraceState:        span.context.TraceState,
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: statusUnset},
+	}
+	if span.parent.IsValid() {
+		encoded.ParentSpanID = span.parent.String()
+	}
+	for _, key := range sortedKeys(span.attributes) {
+		encoded.Attributes = append(encoded.Attributes, otlpAttribute{Key: key, Value: attributeValue(span.attributes[key])})
+	}
+	if span.failed {
+		encoded.Status = otlpStatus{Code: statusError, Message: span.message}
+	}
+	return encoded
+}
+
+func sortedKeys(attributes map[string]interface{}) []string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stdoutExporter writes each span as a line of JSON, for local use
+type stdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newStdoutExporter(w io.Writer) *stdoutExporter {
+	return &stdoutExporter{w: w}
+}
+
+type stdoutSpan struct {
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	Start        time.Time              `json:"start"`
+	DurationMs   float64                `json:"durationMs"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+func (e *stdoutExporter) export(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		span.mu.Lock()
+		line := stdoutSpan{
+			TraceID:    span.context.TraceID.String(),
+			SpanID:     span.context.SpanID.String(),
+			Name:       span.name,
+			Kind:       span.kind.String(),
+			Start:      span.start,
+			DurationMs: float64(span.end.Sub(span.start).Microseconds()) / 1000,
+			Attributes: span.attributes,
+			Error:      span.message,
+		}
+		if span.parent.IsValid() {
+			line.ParentSpanID = span.parent.String()
+		}
+		span.mu.Unlock()
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute names the spans of requests matching no route
+const unmatchedRoute = "unmatched"
+
+type Handler struct {
+	tracer *Tracer
+}
+
+func NewHandler(tracer *Tracer) *Handler {
+	return &Handler{
+		tracer: tracer,
+	}
+}
+
+// Middleware serves each request in a server span, continuing the caller's
+// trace when the request carries a traceparent header. The span is named
+// after the route template, so that requests for different products share a
+// name.
+func (h *Handler) Middleware(ctx *gin.Context) {
+	requestCtx := ctx.Request.Context()
+	if remote, ok := Extract(ctx.Request.Header); ok {
+		requestCtx = ContextWithRemote(requestCtx, remote)
+	}
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	requestCtx, span := h.tracer.Start(requestCtx, ctx.Request.Method+" "+route, KindServer)
+	defer span.End()
+	span.SetAttribute("http.method", ctx.Request.Method)
+	span.SetAttribute("http.route", route)
+	span.SetAttribute("http.target", ctx.Request.URL.RequestURI())
+
+	ctx.Request = ctx.Request.WithContext(requestCtx)
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttribute("http.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.RecordError(fmt.Errorf("%d %s", status, http.StatusText(status)))
+	}
+}
@@ -0,0 +1,190 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+
+	defaultServiceName = "rapid-product-catalog"
+	queueSize          = 2048
+	batchSize          = 512
+	exportInterval     = 5 * time.Second
+	exportTimeout      = 10 * time.Second
+)
+
+var droppedSpans = metrics.NewCounterVec("tracing_spans_dropped_total",
+	"Finished spans dropped because the export queue was full.")
+
+// Tracer starts spans and exports the sampled ones in batches while it runs
+// as a worker
+type Tracer struct {
+	exporter    exporter
+	sampleRatio float64
+	queue       chan *Span
+}
+
+// NewTracer builds the tracer from config and makes it the one the
+// package-level Start reports to. With tracing disabled spans are still
+// started, so that a caller's trace context is passed on, but none are
+// sampled.
+func NewTracer(cfg config.Config) *Tracer {
+	tracingConfig := cfg.Get().Tracing
+	tracer := &Tracer{}
+	if tracingConfig.Enabled {
+		serviceName := tracingConfig.ServiceName
+		if serviceName == "" {
+			serviceName = defaultServiceName
+		}
+		if tracingConfig.Exporter == ExporterStdout {
+			tracer.exporter = newStdoutExporter(os.Stdout)
+		} else {
+			tracer.exporter = newOTLPExporter(tracingConfig.Endpoint, serviceName)
+		}
+		tracer.sampleRatio = tracingConfig.SampleRatio
+		if tracer.sampleRatio <= 0 {
+			tracer.sampleRatio = 1
+		}
+		tracer.queue = make(chan *Span, queueSize)
+	}
+
+	SetDefault(tracer)
+	return tracer
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer = &Tracer{}
+)
+
+func SetDefault(tracer *Tracer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTracer = tracer
+}
+
+func Default() *Tracer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+// Start starts a span of the default tracer; see Tracer.Start
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind)
+}
+
+func (t *Tracer) enabled() bool {
+	return t.exporter != nil
+}
+
+// Start starts a span as a child of the current span of ctx, or of the
+// caller's when ctx carries a propagated context, and returns a context
+// holding it. Callers must End the span.
+func (t *Tracer) Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+	}
+	if parent.IsValid() {
+		span.context = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled, TraceState: parent.TraceState}
+		span.parent = parent.SpanID
+	} else {
+		span.context = SpanContext{TraceID: newTraceID()}
+		span.context.Sampled = t.sample(span.context.TraceID)
+	}
+	span.context.SpanID = newSpanID()
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides on the trace ID rather than at random, so that every
+// instance starting spans of a trace without a parent decides alike
+func (t *Tracer) sample(traceID TraceID) bool {
+	if !t.enabled() {
+		return false
+	}
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])>>11)/(1<<53) < t.sampleRatio
+}
+
+// enqueue drops the span rather than block the request when the exporter
+// falls behind
+func (t *Tracer) enqueue(span *Span) {
+	select {
+	case t.queue <- span:
+	default:
+		droppedSpans.Inc()
+	}
+}
+
+// Run exports queued spans in batches until ctx is cancelled, then exports
+// the spans still queued
+func (t *Tracer) Run(ctx context.Context) {
+	if !t.enabled() {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					t.export(batch)
+					return
+				}
+			}
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				t.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			t.export(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (t *Tracer) export(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := t.exporter.export(ctx, batch); err != nil {
+		logger.Error(logger.Format{
+			Message: "Failed to export spans",
+			Data: map[string]string{
+				"error": err.Error(),
+				"spans": strconv.Itoa(len(batch)),
+			},
+		})
+	}
+}
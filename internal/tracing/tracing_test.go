@@ -0,0 +1,216 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID  = "00f067aa0ba902b7"
+)
+
+type recordingExporter struct {
+	spans []*Span
+}
+
+func (e *recordingExporter) export(ctx context.Context, spans []*Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+type TracingTestSuite struct {
+	suite.Suite
+	exporter *recordingExporter
+	tracer   *Tracer
+}
+
+func (tt *TracingTestSuite) SetupTest() {
+	logger.Init("debug")
+	tt.exporter = &recordingExporter{}
+	tt.tracer = &Tracer{exporter: tt.exporter, sampleRatio: 1, queue: make(chan *Span, 16)}
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+// finished stops the tracer after it exported the spans ended so far
+func (tt *TracingTestSuite) finished() []*Span {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tt.tracer.Run(ctx)
+	return tt.exporter.spans
+}
+
+func header(traceparent string) http.Header {
+	h := http.Header{}
+	h.Set(TraceparentHeader, traceparent)
+	return h
+}
+
+func (tt *TracingTestSuite) TestShouldExtractTraceparent() {
+	sc, ok := Extract(header("00-" + callerTraceID + "-" + callerSpanID + "-01"))
+
+	tt.Require().True(ok)
+	assert.Equal(tt.T(), callerTraceID, sc.TraceID.String())
+	assert.Equal(tt.T(), callerSpanID, sc.SpanID.String())
+	assert.True(tt.T(), sc.Sampled)
+}
+
+func (tt *TracingTestSuite) TestShouldRejectMalformedTraceparent() {
+	for _, traceparent := range []string{
+		"",
+		"00-" + callerTraceID + "-" + callerSpanID,
+		"00-" + callerTraceID + "-" + callerSpanID + "-01-extra",
+		"ff-" + callerTraceID + "-" + callerSpanID + "-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + callerSpanID + "-01",
+		"00-00000000000000000000000000000000-" + callerSpanID + "-01",
+		"00-" + callerTraceID + "-0000000000000000-01",
+		"00-" + callerTraceID + "-00f067aa0ba902-01",
+	} {
+		_, ok := Extract(header(traceparent))
+		assert.False(tt.T(), ok, traceparent)
+	}
+}
+
+func (tt *TracingTestSuite) TestShouldPropagateChildOfCurrentSpan() {
+	remote, _ := Extract(header("00-" + callerTraceID + "-" + callerSpanID + "-01"))
+	ctx, span := tt.tracer.Start(ContextWithRemote(context.Background(), remote), "search", KindInternal)
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+
+	assert.Equal(tt.T(), "00-"+callerTraceID+"-"+span.Context().SpanID.String()+"-01", outgoing.Get(TraceparentHeader))
+}
+
+func (tt *TracingTestSuite) TestShouldExportSpansWithParentsAttributesAndErrors() {
+	ctx, parent := tt.tracer.Start(context.Background(), "service", KindInternal)
+	_, child := tt.tracer.Start(ctx, "repository", KindInternal)
+	child.SetAttribute("result.count", 3)
+	child.RecordError(errors.New("datastore unavailable"))
+	child.End()
+	parent.End()
+
+	spans := tt.finished()
+	tt.Require().Len(spans, 2)
+	assert.Equal(tt.T(), parent.Context().TraceID, spans[0].context.TraceID)
+	assert.Equal(tt.T(), parent.Context().SpanID, spans[0].parent)
+	assert.Equal(tt.T(), int64(3), spans[0].attributes["result.count"])
+	assert.Equal(tt.T(), "datastore unavailable", spans[0].message)
+	assert.False(tt.T(), spans[1].parent.IsValid())
+}
+
+func (tt *TracingTestSuite) TestShouldFollowCallerDecisionNotToSample() {
+	remote, _ := Extract(header("00-" + callerTraceID + "-" + callerSpanID + "-00"))
+	ctx, span := tt.tracer.Start(ContextWithRemote(context.Background(), remote), "search", KindInternal)
+	span.End()
+
+	outgoing := http.Header{}
+	Inject(ctx, outgoing)
+	assert.Empty(tt.T(), tt.finished())
+	assert.Equal(tt.T(), "00-"+callerTraceID+"-"+span.Context().SpanID.String()+"-00", outgoing.Get(TraceparentHeader))
+}
+
+func (tt *TracingTestSuite) TestShouldRecordNothingWhenDisabled() {
+	disabled := &Tracer{}
+	remote, _ := Extract(header("00-" + callerTraceID + "-" + callerSpanID + "-01"))
+
+	ctx, span := disabled.Start(ContextWithRemote(context.Background(), remote), "search", KindInternal)
+	span.SetAttribute("result.count", 3)
+	span.End()
+
+	assert.Nil(tt.T(), span.attributes)
+	assert.Equal(tt.T(), callerTraceID, SpanContextFromContext(ctx).TraceID.String(), "the caller's trace is still passed on")
+}
+
+func (tt *TracingTestSuite) TestShouldServeRequestsInServerSpans() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewHandler(tt.tracer).Middleware)
+	var handlerTraceID string
+	router.GET("/products/:productId", func(ctx *gin.Context) {
+		handlerTraceID = SpanContextFromContext(ctx.Request.Context()).TraceID.String()
+		ctx.Status(http.StatusServiceUnavailable)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/products/abc", nil)
+	request.Header.Set(TraceparentHeader, "00-"+callerTraceID+"-"+callerSpanID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.Equal(tt.T(), callerTraceID, handlerTraceID)
+	spans := tt.finished()
+	tt.Require().Len(spans, 1)
+	assert.Equal(tt.T(), "GET /products/:productId", spans[0].name)
+	assert.Equal(tt.T(), KindServer, spans[0].kind)
+	assert.Equal(tt.T(), callerSpanID, spans[0].parent.String())
+	assert.Equal(tt.T(), int64(http.StatusServiceUnavailable), spans[0].attributes["http.status_code"])
+	assert.True(tt.T(), spans[0].failed)
+}
+
+func (tt *TracingTestSuite) TestShouldSendSpansToCollectorOverOTLP() {
+	var received otlpRequest
+	var path string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer collector.Close()
+
+	ctx, parent := tt.tracer.Start(context.Background(), "service", KindInternal)
+	_, child := tt.tracer.Start(ctx, "mongo.find", KindClient)
+	child.SetAttribute("db.system", "mongodb")
+	child.RecordError(errors.New("timeout"))
+	child.End()
+
+	err := newOTLPExporter(collector.URL, "catalog").export(context.Background(), []*Span{child})
+
+	tt.Require().NoError(err)
+	assert.Equal(tt.T(), "/v1/traces", path)
+	tt.Require().Len(received.ResourceSpans, 1)
+	assert.Equal(tt.T(), "catalog", *received.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	span := received.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(tt.T(), parent.Context().TraceID.String(), span.TraceID)
+	assert.Equal(tt.T(), parent.Context().SpanID.String(), span.ParentSpanID)
+	assert.Equal(tt.T(), KindClient, span.Kind)
+	assert.Equal(tt.T(), "db.system", span.Attributes[0].Key)
+	assert.Equal(tt.T(), statusError, span.Status.Code)
+}
+
+func (tt *TracingTestSuite) TestShouldReportCollectorRejections() {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer collector.Close()
+	_, span := tt.tracer.Start(context.Background(), "service", KindInternal)
+	span.End()
+
+	err := newOTLPExporter(collector.URL+"/v1/traces", "catalog").export(context.Background(), []*Span{span})
+
+	assert.Error(tt.T(), err)
+}
+
+func (tt *TracingTestSuite) TestShouldWriteSpansAsJSONLines() {
+	var out bytes.Buffer
+	_, span := tt.tracer.Start(context.Background(), "service", KindInternal)
+	span.SetAttribute("search.text", true)
+	span.End()
+
+	tt.Require().NoError(newStdoutExporter(&out).export(context.Background(), []*Span{span}))
+
+	var line stdoutSpan
+	tt.Require().NoError(json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(tt.T(), "service", line.Name)
+	assert.Equal(tt.T(), "internal", line.Kind)
+	assert.Equal(tt.T(), true, line.Attributes["search.text"])
+}
@@ -0,0 +1,8 @@
+package tracing
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewTracer,
+	NewHandler,
+)
@@ -1,7 +1,8 @@
 package types
 
 type ErrorResponse struct {
-	Error Error `json:"info,omitempty"`
+	Error     Error  `json:"info,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
 }
 
 type Error struct {
@@ -10,3 +11,13 @@ type Error struct {
 	Code           string `json:"code,omitempty"`
 	Status         string `json:"status,omitempty"`
 }
+
+func NewErrorResponse(err *StatusError) ErrorResponse {
+	return ErrorResponse{
+		Error: Error{
+			Message: err.Message,
+			Code:    err.Code,
+			Status:  "error",
+		},
+	}
+}
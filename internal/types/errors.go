@@ -35,3 +35,88 @@ func NewNotFoundError(message string) *StatusError {
 		HTTPCode: http.StatusNotFound,
 	}
 }
+
+func NewUnauthorizedError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "unauthorized",
+		HTTPCode: http.StatusUnauthorized,
+	}
+}
+
+func NewForbiddenError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "forbidden",
+		HTTPCode: http.StatusForbidden,
+	}
+}
+
+// NewLimitExceededError reports a write refused because it would take the
+// caller past one of its configured limits
+func NewLimitExceededError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "limit_exceeded",
+		HTTPCode: http.StatusForbidden,
+	}
+}
+
+// NewBatchTooLargeError reports a request carrying more than the service
+// accepts in one go
+func NewBatchTooLargeError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "batch_too_large",
+		HTTPCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// NewPayloadTooLargeError reports a request body larger than its route
+// accepts
+func NewPayloadTooLargeError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "payload_too_large",
+		HTTPCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// NewTooManyRequestsError reports a request refused because its caller sent
+// more than its rate limit allows
+func NewTooManyRequestsError(message string) *StatusError {
+	return &StatusError{
+		Message:  message,
+		Code:     "rate_limited",
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
+// NewDatastoreUnavailableError reports a datastore that could not be reached
+// or was failing over; the request may succeed if retried
+func NewDatastoreUnavailableError() *StatusError {
+	return &StatusError{
+		Message:  "The datastore is temporarily unavailable",
+		Code:     "datastore_unavailable",
+		HTTPCode: http.StatusServiceUnavailable,
+	}
+}
+
+// NewDatastoreTimeoutError reports a datastore operation that did not
+// complete in time
+func NewDatastoreTimeoutError() *StatusError {
+	return &StatusError{
+		Message:  "The datastore did not respond in time",
+		Code:     "datastore_timeout",
+		HTTPCode: http.StatusGatewayTimeout,
+	}
+}
+
+// ToStatusError returns err as a *StatusError, masking any other error type
+// behind a generic internal server error.
+func ToStatusError(err error) *StatusError {
+	if statusError, ok := err.(*StatusError); ok {
+		return statusError
+	}
+	return NewInternalServerError()
+}
@@ -6,42 +6,190 @@ import (
 	"time"
 
 	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/health"
 	logger "github.com/roppenlabs/rapido-logger-go"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
-// DBInstance contains the MongoDB database instance
+const (
+	// CatalogStore is the name of the datastore configured as testDB, which
+	// holds the catalog
+	CatalogStore          = "testDB"
+	defaultReadPreference = "secondaryPreferred"
+)
+
+// DBInstance contains the MongoDB database instances. TestDB takes writes
+// and the reads that must see them; Reads is the same database with the
+// store's read preference, for reads that may lag behind. Collections
+// placed in another datastore are reached through Collection and
+// ReadCollection.
 type DBInstance struct {
 	TestDB *mongo.Database
+	Reads  *mongo.Database
+	// Policy bounds the operations run through Read and Write; nil applies
+	// the defaults
+	Policy *OperationPolicy
+
+	stores []store
+	// placed holds the databases of the collections placed outside testDB,
+	// keyed by collection
+	placed map[string]placement
+}
+
+// store is a connected datastore
+type store struct {
+	name   string
+	client *mongo.Client
+}
+
+// placement is the database pair of a collection placed in another
+// datastore
+type placement struct {
+	writes *mongo.Database
+	reads  *mongo.Database
+}
+
+// catalogCollections are updated together, in transactions and causal
+// sessions on testDB, so they cannot be placed elsewhere
+var catalogCollections = []string{
+	"rapidProducts", "rapidProductPrices", "rapidPriceLists", "rapidProductEvents",
+	"rapidSequences", "rapidEventLeases", "rapidMigrations", "rapidMigrationLocks",
 }
 
 func NewDBInstance(conf config.Config) (*DBInstance, error) {
-	mongoConf := conf.Get().Datastores.TestDB
+	datastores := conf.Get().Datastores
+	stores := map[string]config.MongoDB{CatalogStore: datastores.TestDB}
+	for _, storeConfig := range datastores.Mongo {
+		if _, ok := stores[storeConfig.Name]; ok || storeConfig.Name == "" {
+			return nil, fmt.Errorf("datastore name %q is empty or used more than once", storeConfig.Name)
+		}
+		stores[storeConfig.Name] = storeConfig
+	}
+	placements, err := placeCollections(datastores.Collections, stores)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := NewOperationPolicy(datastores.Operations)
+	db := &DBInstance{Policy: &policy, placed: map[string]placement{}}
+	opened := map[string]placement{}
+	open := func(name string) (placement, error) {
+		if databases, ok := opened[name]; ok {
+			return databases, nil
+		}
+		storeConfig := stores[name]
+		database, err := initDB(name, storeConfig)
+		if err != nil {
+			logger.Error(logger.Format{
+				Message: "Failed to initialize mongo instance",
+				Data: map[string]string{
+					"error":     err.Error(),
+					"datastore": name,
+				},
+			})
+			return placement{}, err
+		}
+		db.stores = append(db.stores, store{name: name, client: database.Client()})
+		reads, err := readDatabase(database, storeConfig)
+		if err != nil {
+			return placement{}, err
+		}
+		opened[name] = placement{writes: database, reads: reads}
+		return opened[name], nil
+	}
 
-	testDB, err := initDB(mongoConf)
+	catalog, err := open(CatalogStore)
 	if err != nil {
-		logger.Error(logger.Format{
-			Message: "Failed to initialize mongo instance",
-			Data: map[string]string{
-				"error": err.Error(),
-			},
-		})
+		db.Close(context.Background())
 		return nil, err
 	}
+	db.TestDB, db.Reads = catalog.writes, catalog.reads
+	for collection, name := range placements {
+		databases, err := open(name)
+		if err != nil {
+			db.Close(context.Background())
+			return nil, err
+		}
+		db.placed[collection] = databases
+	}
+	return db, nil
+}
+
+// placeCollections returns the datastore of each collection placed outside
+// testDB, refusing unknown datastores and catalog collections
+func placeCollections(collections []config.CollectionConfig, stores map[string]config.MongoDB) (map[string]string, error) {
+	placements := map[string]string{}
+	seen := map[string]bool{}
+	for _, collection := range collections {
+		if _, ok := stores[collection.Store]; !ok {
+			return nil, fmt.Errorf("datastore %q of collection %q is not configured", collection.Store, collection.Name)
+		}
+		if seen[collection.Name] || collection.Name == "" {
+			return nil, fmt.Errorf("collection name %q is empty or placed more than once", collection.Name)
+		}
+		seen[collection.Name] = true
+		for _, catalogCollection := range catalogCollections {
+			if collection.Name == catalogCollection && collection.Store != CatalogStore {
+				return nil, fmt.Errorf("collection %q must stay with the catalog in %s", collection.Name, CatalogStore)
+			}
+		}
+		if collection.Store != CatalogStore {
+			placements[collection.Name] = collection.Store
+		}
+	}
+	return placements, nil
+}
+
+// NewCheckedDBInstance connects the datastores like NewDBInstance and
+// registers their health checks
+func NewCheckedDBInstance(conf config.Config, checks *health.Registry) (*DBInstance, error) {
+	db, err := NewDBInstance(conf)
+	if err != nil {
+		return nil, err
+	}
+	db.RegisterChecks(checks)
+	return db, nil
+}
 
-	return &DBInstance{
-		TestDB: testDB,
-	}, nil
+// readDatabase returns database with the read preference of conf
+func readDatabase(database *mongo.Database, conf config.MongoDB) (*mongo.Database, error) {
+	preference := conf.ReadPreference
+	if preference == "" {
+		preference = defaultReadPreference
+	}
+	mode, err := readpref.ModeFromString(preference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference %q: %w", preference, err)
+	}
+	readPref, err := readpref.New(mode)
+	if err != nil {
+		return nil, err
+	}
+	return database.Client().Database(database.Name(), options.Database().SetReadPreference(readPref)), nil
 }
 
-func initDB(conf config.MongoDB) (*mongo.Database, error) {
-	uri := fmt.Sprintf("mongodb://%s:%s@%s/%s?authSource=%s&readPreference=secondaryPreferred&replicaSet=%s&appName=%s",
+// connectionURI is the configured URI, which may be a mongodb+srv:// SRV
+// record, or one assembled from the host and credential fields
+func connectionURI(conf config.MongoDB) string {
+	if conf.URI != "" {
+		return conf.URI
+	}
+	return fmt.Sprintf("mongodb://%s:%s@%s/%s?authSource=%s&replicaSet=%s&appName=%s",
 		conf.User, conf.Password, conf.Hosts, conf.Database, conf.AuthSource, conf.ReplicaSet, conf.AppName)
+}
 
+// initDB connects with reads on the primary; reads that may lag go through
+// the database readDatabase returns
+func initDB(name string, conf config.MongoDB) (*mongo.Database, error) {
 	clientOptions := options.Client().
-		ApplyURI(uri).
+		ApplyURI(connectionURI(conf)).
+		SetMonitor(commandMonitor(name)).
+		SetPoolMonitor(poolMonitor(name)).
+		SetReadPreference(readpref.Primary()).
 		SetMaxPoolSize(uint64(conf.Options.MaxPoolSize)).
 		SetMinPoolSize(uint64(conf.Options.MinPoolSize)).
 		SetMaxConnIdleTime(time.Duration(conf.Options.IdleTimeout) * time.Second)
@@ -66,18 +214,101 @@ func initDB(conf config.MongoDB) (*mongo.Database, error) {
 	return client.Database(conf.Database), nil
 }
 
-// Close closes the MongoDB connection
+// ReadDB returns the database for reads that may lag behind writes
+func (db *DBInstance) ReadDB() *mongo.Database {
+	if db.Reads != nil {
+		return db.Reads
+	}
+	return db.TestDB
+}
+
+// Collection returns the collection name in the datastore it is placed in,
+// for writes and the reads that must see them
+func (db *DBInstance) Collection(name string, opts ...*options.CollectionOptions) *mongo.Collection {
+	if placed, ok := db.placed[name]; ok {
+		return placed.writes.Collection(name, opts...)
+	}
+	return db.TestDB.Collection(name, opts...)
+}
+
+// ReadCollection returns the collection name in the datastore it is placed
+// in, for reads that may lag behind writes
+func (db *DBInstance) ReadCollection(name string, opts ...*options.CollectionOptions) *mongo.Collection {
+	if placed, ok := db.placed[name]; ok {
+		return placed.reads.Collection(name, opts...)
+	}
+	return db.ReadDB().Collection(name, opts...)
+}
+
+// CausalSession runs fn in a causally consistent session, so reads fn makes
+// through ReadDB see the writes it made before them, on whichever member
+// serves them
+func (db *DBInstance) CausalSession(ctx context.Context, fn func(ctx context.Context) error) error {
+	sessionOptions := options.Session().
+		SetCausalConsistency(true).
+		SetDefaultReadConcern(readconcern.Majority()).
+		SetDefaultWriteConcern(writeconcern.Majority())
+	return db.TestDB.Client().UseSessionWithOptions(ctx, sessionOptions, func(sessionCtx mongo.SessionContext) error {
+		return fn(sessionCtx)
+	})
+}
+
+type transactionKey struct{}
+
+// Transaction runs fn in a transaction on the session CausalSession started
+// for ctx. The driver retries fn as a whole on transient errors, so Read and
+// Write run the operations in it once.
+func (db *DBInstance) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	transactionOptions := options.Transaction().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+	_, err := mongo.SessionFromContext(ctx).WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(context.WithValue(sessionCtx, transactionKey{}, true))
+	}, transactionOptions)
+	return err
+}
+
+func inTransaction(ctx context.Context) bool {
+	within, _ := ctx.Value(transactionKey{}).(bool)
+	return within
+}
+
+// connected lists the datastores connected, or the catalog's alone for a
+// DBInstance built around an existing database
+func (db *DBInstance) connected() []store {
+	if len(db.stores) == 0 && db.TestDB != nil {
+		return []store{{name: CatalogStore, client: db.TestDB.Client()}}
+	}
+	return db.stores
+}
+
+// RegisterChecks reports each datastore to checks, as the reachability of
+// its primary
+func (db *DBInstance) RegisterChecks(checks *health.Registry) {
+	for _, store := range db.connected() {
+		checks.Register("mongo:"+store.name, store.ping)
+	}
+}
+
+func (s store) ping(ctx context.Context) error {
+	return s.client.Ping(ctx, readpref.Primary())
+}
+
+// Close closes the MongoDB connections
 func (db *DBInstance) Close(ctx context.Context) {
 	logger.Info(logger.Format{
 		Message: "Closing mongo connection",
 	})
 
-	if err := db.TestDB.Client().Disconnect(ctx); err != nil {
-		logger.Error(logger.Format{
-			Message: "Failed to close testdb connection",
-			Data: map[string]string{
-				"error": err.Error(),
-			},
-		})
+	for _, store := range db.connected() {
+		if err := store.client.Disconnect(ctx); err != nil {
+			logger.Error(logger.Format{
+				Message: "Failed to close mongo connection",
+				Data: map[string]string{
+					"error": err.Error(),
+				},
+			})
+		}
 	}
 }
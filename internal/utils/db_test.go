@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DBTestSuite struct {
+	suite.Suite
+	stores map[string]config.MongoDB
+}
+
+func (dt *DBTestSuite) SetupTest() {
+	dt.stores = map[string]config.MongoDB{CatalogStore: {}, "reports": {Name: "reports"}}
+}
+
+func TestDBSuite(t *testing.T) {
+	suite.Run(t, new(DBTestSuite))
+}
+
+func (dt *DBTestSuite) TestShouldPlaceCollectionsInNamedDatastores() {
+	placements, err := placeCollections([]config.CollectionConfig{
+		{Name: "rapidAuditLog", Store: "reports"},
+		{Name: "rapidWebhookDeliveries", Store: "reports"},
+		{Name: "rapidPromotions", Store: CatalogStore},
+	}, dt.stores)
+
+	dt.Require().NoError(err)
+	assert.Equal(dt.T(), map[string]string{"rapidAuditLog": "reports", "rapidWebhookDeliveries": "reports"}, placements)
+}
+
+func (dt *DBTestSuite) TestShouldRefuseUnknownDatastore() {
+	_, err := placeCollections([]config.CollectionConfig{{Name: "rapidAuditLog", Store: "archive"}}, dt.stores)
+
+	assert.EqualError(dt.T(), err, `datastore "archive" of collection "rapidAuditLog" is not configured`)
+}
+
+func (dt *DBTestSuite) TestShouldKeepCatalogCollectionsInCatalogStore() {
+	_, err := placeCollections([]config.CollectionConfig{{Name: "rapidProducts", Store: "reports"}}, dt.stores)
+
+	assert.EqualError(dt.T(), err, `collection "rapidProducts" must stay with the catalog in testDB`)
+}
+
+func (dt *DBTestSuite) TestShouldRefuseCollectionPlacedTwice() {
+	_, err := placeCollections([]config.CollectionConfig{
+		{Name: "rapidAuditLog", Store: CatalogStore},
+		{Name: "rapidAuditLog", Store: "reports"},
+	}, dt.stores)
+
+	assert.Error(dt.T(), err)
+}
+
+func (dt *DBTestSuite) TestShouldResolveCollectionsToTheirDatastore() {
+	// The client is never used to reach a server
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	dt.Require().NoError(err)
+	defer client.Disconnect(context.Background())
+
+	reports := client.Database("reports")
+	db := &DBInstance{
+		TestDB: client.Database("catalog"),
+		Reads:  client.Database("catalogReads"),
+		placed: map[string]placement{"rapidAuditLog": {writes: reports, reads: client.Database("reportsReads")}},
+	}
+
+	assert.Equal(dt.T(), "catalog", db.Collection("rapidProducts").Database().Name())
+	assert.Equal(dt.T(), "catalogReads", db.ReadCollection("rapidProducts").Database().Name())
+	assert.Equal(dt.T(), "reports", db.Collection("rapidAuditLog").Database().Name())
+	assert.Equal(dt.T(), "reportsReads", db.ReadCollection("rapidAuditLog").Database().Name())
+}
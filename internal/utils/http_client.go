@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
 )
 
 const ApplicationJSON = "application/json"
@@ -17,7 +19,10 @@ const (
 	POST string = "POST"
 )
 
+// HTTPPayload Context bounds the request along with Timeout, and its trace
+// is continued by the service called; a nil Context is context.Background
 type HTTPPayload struct {
+	Context context.Context
 	Client  *http.Client
 	URL     string
 	Body    interface{}
@@ -40,65 +45,52 @@ type httpClientImpl struct {
 }
 
 func (h *httpClientImpl) Put(hp HTTPPayload) (HTTPResponse, error) {
-	body := new(bytes.Buffer)
-	if hp.Body != nil {
-		_ = json.NewEncoder(body).Encode(hp.Body)
-	}
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), hp.Timeout)
-	defer cancel()
-
-	httpRequest, _ := http.NewRequestWithContext(ctxWithTimeout, PUT, hp.URL, body)
-	httpRequest.Header.Set("Content-Type", ApplicationJSON)
-	response, err := hp.Client.Do(httpRequest)
-	if err != nil {
-		return HTTPResponse{StatusCode: 500}, err
-	}
-
-	defer response.Body.Close()
-	responseBody, err := ioutil.ReadAll(response.Body)
-	return HTTPResponse{
-		StatusCode: response.StatusCode,
-		Body:       responseBody,
-	}, err
+	return h.do(PUT, hp)
 }
 
 func (h *httpClientImpl) Post(hp HTTPPayload) (HTTPResponse, error) {
-	body := new(bytes.Buffer)
-	if hp.Body != nil {
-		_ = json.NewEncoder(body).Encode(hp.Body)
-	}
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), hp.Timeout)
-	defer cancel()
-
-	httpRequest, _ := http.NewRequestWithContext(ctxWithTimeout, POST, hp.URL, body)
-	httpRequest.Header.Set("Content-Type", ApplicationJSON)
-	response, err := hp.Client.Do(httpRequest)
-	if err != nil {
-		return HTTPResponse{StatusCode: 500}, err
-	}
-
-	defer response.Body.Close()
-	responseBody, err := ioutil.ReadAll(response.Body)
-	return HTTPResponse{
-		StatusCode: response.StatusCode,
-		Body:       responseBody,
-	}, err
+	return h.do(POST, hp)
 }
 
 func (h *httpClientImpl) Get(hp HTTPPayload) (HTTPResponse, error) {
+	return h.do(GET, hp)
+}
+
+// do sends the request; a []byte Body is sent as is, any other Body is
+// encoded as JSON
+func (h *httpClientImpl) do(method string, hp HTTPPayload) (HTTPResponse, error) {
 	body := new(bytes.Buffer)
-	if hp.Body != nil {
-		_ = json.NewEncoder(body).Encode(hp.Body)
+	switch payload := hp.Body.(type) {
+	case nil:
+	case []byte:
+		body.Write(payload)
+	default:
+		_ = json.NewEncoder(body).Encode(payload)
+	}
+	ctx := hp.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), hp.Timeout)
+	ctx, span := tracing.Start(ctx, "HTTP "+method, tracing.KindClient)
+	defer span.End()
+	span.SetAttribute("http.method", method)
+	span.SetAttribute("http.url", hp.URL)
+
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, hp.Timeout)
 	defer cancel()
 
-	httpRequest, _ := http.NewRequestWithContext(ctxWithTimeout, GET, hp.URL, body)
+	httpRequest, _ := http.NewRequestWithContext(ctxWithTimeout, method, hp.URL, body)
 	httpRequest.Header.Set("Content-Type", ApplicationJSON)
+	for key, value := range hp.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+	tracing.Inject(ctx, httpRequest.Header)
 	response, err := hp.Client.Do(httpRequest)
 	if err != nil {
+		span.RecordError(err)
 		return HTTPResponse{StatusCode: 500}, err
 	}
+	span.SetAttribute("http.status_code", response.StatusCode)
 
 	defer response.Body.Close()
 	responseBody, err := ioutil.ReadAll(response.Body)
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClientShouldContinueCallersTrace(t *testing.T) {
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	remote, _ := tracing.Extract(header)
+	ctx := tracing.ContextWithRemote(context.Background(), remote)
+
+	var sent *http.Request
+	client := testutils.NewTestHTTPClient(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	_, err := GetHTTPClient().Post(HTTPPayload{Context: ctx, Client: client, URL: "http://example.com/hook", Timeout: time.Second})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(sent.Header.Get(tracing.TraceparentHeader), "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/metrics"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tracing"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoCommandDuration = metrics.NewHistogramVec("mongo_command_duration_seconds",
+		"Time taken by Mongo commands, by datastore and command.", metrics.DurationBuckets, "datastore", "command")
+	mongoCommandErrors = metrics.NewCounterVec("mongo_command_errors_total",
+		"Mongo commands that failed, by datastore and command.", "datastore", "command")
+	mongoPoolConnections = metrics.NewGaugeVec("mongo_pool_connections",
+		"Connections in the Mongo pools, open or checked out (in_use), by datastore.", "datastore", "state")
+	mongoPoolCheckoutFailures = metrics.NewCounterVec("mongo_pool_checkout_failures_total",
+		"Failures to check a connection out of a Mongo pool, by datastore and reason.", "datastore", "reason")
+
+	mongoOperationDuration = metrics.NewHistogramVec("mongo_operation_duration_seconds",
+		"Time taken by repository operations, retries included, by operation.", metrics.DurationBuckets, "operation")
+	mongoOperationErrors = metrics.NewCounterVec("mongo_operation_errors_total",
+		"Repository operations that failed, by operation and the code reported for them.", "operation", "code")
+	mongoOperationRetries = metrics.NewCounterVec("mongo_operation_retries_total",
+		"Repository operation attempts retried, by operation.", "operation")
+)
+
+// commandMonitor records the latency and failures of every command sent to
+// the datastore, and traces each in a client span of the operation that
+// sent it
+func commandMonitor(datastore string) *event.CommandMonitor {
+	var spans sync.Map
+	end := func(requestID int64, err error) {
+		if span, ok := spans.LoadAndDelete(requestID); ok {
+			span.(*tracing.Span).RecordError(err)
+			span.(*tracing.Span).End()
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, started *event.CommandStartedEvent) {
+			_, span := tracing.Start(ctx, "mongo."+started.CommandName, tracing.KindClient)
+			span.SetAttribute("db.system", "mongodb")
+			span.SetAttribute("db.name", started.DatabaseName)
+			span.SetAttribute("db.operation", started.CommandName)
+			span.SetAttribute("db.datastore", datastore)
+			if collection, ok := started.Command.Lookup(started.CommandName).StringValueOK(); ok {
+				span.SetAttribute("db.mongodb.collection", collection)
+			}
+			spans.Store(started.RequestID, span)
+		},
+		Succeeded: func(ctx context.Context, succeeded *event.CommandSucceededEvent) {
+			mongoCommandDuration.Observe(succeeded.Duration.Seconds(), datastore, succeeded.CommandName)
+			end(succeeded.RequestID, nil)
+		},
+		Failed: func(ctx context.Context, failed *event.CommandFailedEvent) {
+			mongoCommandDuration.Observe(failed.Duration.Seconds(), datastore, failed.CommandName)
+			mongoCommandErrors.Inc(datastore, failed.CommandName)
+			end(failed.RequestID, errors.New(failed.Failure))
+		},
+	}
+}
+
+// poolMonitor tracks the connections of the datastore's pools
+func poolMonitor(datastore string) *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(poolEvent *event.PoolEvent) {
+			switch poolEvent.Type {
+			case event.ConnectionCreated:
+				mongoPoolConnections.Add(1, datastore, "open")
+			case event.ConnectionClosed:
+				mongoPoolConnections.Add(-1, datastore, "open")
+			case event.GetSucceeded:
+				mongoPoolConnections.Add(1, datastore, "in_use")
+			case event.ConnectionReturned:
+				mongoPoolConnections.Add(-1, datastore, "in_use")
+			case event.GetFailed:
+				mongoPoolCheckoutFailures.Inc(datastore, poolEvent.Reason)
+			}
+		},
+	}
+}
+
+// observeOperation records an operation run through Read or Write
+func observeOperation(operation string, start time.Time, err error) {
+	mongoOperationDuration.Observe(time.Since(start).Seconds(), operation)
+	if err != nil {
+		mongoOperationErrors.Inc(operation, DatastoreError(err).Code)
+	}
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+	defaultMaxRetries   = 2
+	defaultMinBackoff   = 50 * time.Millisecond
+	defaultMaxBackoff   = time.Second
+)
+
+// notPrimaryCodes are the server errors of a member that cannot serve the
+// operation while the replica set elects or steps down a primary. The
+// operation was refused, so it is safe to send again.
+var notPrimaryCodes = []int{
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// networkCodes are the server errors of a member that could not reach
+// another
+var networkCodes = []int{
+	6,    // HostUnreachable
+	7,    // HostNotFound
+	89,   // NetworkTimeout
+	9001, // SocketException
+}
+
+// OperationPolicy bounds each Mongo operation: every attempt gets a deadline
+// within the caller's, and an attempt that fails because the datastore is
+// unavailable is retried after a backoff doubling from MinBackoff up to
+// MaxBackoff
+type OperationPolicy struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	MaxRetries   int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+}
+
+// NewOperationPolicy reads the policy from config; a negative maxRetries
+// disables retries
+func NewOperationPolicy(conf config.OperationsConfig) OperationPolicy {
+	maxRetries := conf.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return OperationPolicy{
+		ReadTimeout:  millis(conf.ReadTimeout, defaultReadTimeout),
+		WriteTimeout: millis(conf.WriteTimeout, defaultWriteTimeout),
+		MaxRetries:   maxRetries,
+		MinBackoff:   millis(conf.MinBackoff, defaultMinBackoff),
+		MaxBackoff:   millis(conf.MaxBackoff, defaultMaxBackoff),
+	}
+}
+
+func millis(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Millisecond
+}
+
+// Read runs fn, which must only read, under the read timeout. It is retried
+// on any error showing the datastore unavailable, including a timed out
+// attempt while the caller's deadline allows another. Its metrics are
+// reported under operation.
+func (db *DBInstance) Read(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	policy := db.policy()
+	start := time.Now()
+	err := policy.run(ctx, operation, policy.ReadTimeout, IsUnavailable, fn)
+	observeOperation(operation, start, err)
+	return err
+}
+
+// Write runs fn under the write timeout. It is only retried when the
+// datastore refused the write before applying any of it, since a write
+// interrupted on the wire may have been applied.
+func (db *DBInstance) Write(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	policy := db.policy()
+	start := time.Now()
+	err := policy.run(ctx, operation, policy.WriteTimeout, isRefused, fn)
+	observeOperation(operation, start, err)
+	return err
+}
+
+func (db *DBInstance) policy() OperationPolicy {
+	if db.Policy == nil {
+		return NewOperationPolicy(config.OperationsConfig{})
+	}
+	return *db.Policy
+}
+
+// run leaves operations in a transaction to it: an error aborts the
+// transaction, which is retried as a whole, and the transaction's deadline
+// covers its operations
+func (p OperationPolicy) run(ctx context.Context, operation string, timeout time.Duration, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	if inTransaction(ctx) {
+		return fn(ctx)
+	}
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := fn(attemptCtx)
+		cancel()
+		if err == nil || attempt >= p.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		mongoOperationRetries.Inc(operation)
+		timer := time.NewTimer(p.backoff(attempt + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff is the wait after the given number of failed attempts
+func (p OperationPolicy) backoff(attempts int) time.Duration {
+	wait := p.MinBackoff
+	for i := 1; i < attempts && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// isRefused reports whether err shows the operation was never applied: no
+// server could be selected or connected to, or the member refused it as not
+// primary
+func isRefused(err error) bool {
+	if errors.As(err, &topology.ServerSelectionError{}) || errors.As(err, &topology.WaitQueueTimeoutError{}) {
+		return true
+	}
+	return hasErrorCode(err, notPrimaryCodes)
+}
+
+// IsUnavailable reports whether err is the datastore being unreachable,
+// failing over or too slow, rather than a problem with the operation
+func IsUnavailable(err error) bool {
+	if isRefused(err) || mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return true
+	}
+	if errors.As(err, &topology.ConnectionError{}) {
+		return true
+	}
+	return hasErrorCode(err, networkCodes)
+}
+
+func hasErrorCode(err error, codes []int) bool {
+	var serverError mongo.ServerError
+	if !errors.As(err, &serverError) {
+		return false
+	}
+	for _, code := range codes {
+		if serverError.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// DatastoreError is the error reported to clients for a failed Mongo
+// operation: datastore_timeout or datastore_unavailable when the datastore
+// could not serve it in time, and internal_server_error otherwise
+func DatastoreError(err error) *types.StatusError {
+	if statusError, ok := err.(*types.StatusError); ok {
+		return statusError
+	}
+	if mongo.IsTimeout(err) {
+		return types.NewDatastoreTimeoutError()
+	}
+	if IsUnavailable(err) {
+		return types.NewDatastoreUnavailableError()
+	}
+	return types.NewInternalServerError()
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	notPrimary    = mongo.CommandError{Code: 10107, Name: "NotWritablePrimary"}
+	networkError  = mongo.CommandError{Labels: []string{"NetworkError"}, Message: "connection reset"}
+	duplicateKey  = mongo.CommandError{Code: 11000, Name: "DuplicateKey"}
+	testOperation = config.OperationsConfig{ReadTimeout: 50, WriteTimeout: 50, MaxRetries: 2, MinBackoff: 1, MaxBackoff: 2}
+)
+
+type OperationTestSuite struct {
+	suite.Suite
+	db *DBInstance
+}
+
+func (ot *OperationTestSuite) SetupTest() {
+	policy := NewOperationPolicy(testOperation)
+	ot.db = &DBInstance{Policy: &policy}
+}
+
+func TestOperationSuite(t *testing.T) {
+	suite.Run(t, new(OperationTestSuite))
+}
+
+// failing returns an operation failing with errs in turn, then succeeding,
+// and the count of its attempts
+func failing(errs ...error) (func(ctx context.Context) error, *int) {
+	attempts := 0
+	return func(ctx context.Context) error {
+		attempts++
+		if attempts <= len(errs) {
+			return errs[attempts-1]
+		}
+		return nil
+	}, &attempts
+}
+
+func (ot *OperationTestSuite) TestShouldRetryReadWhileDatastoreUnavailable() {
+	operation, attempts := failing(networkError, notPrimary)
+
+	err := ot.db.Read(context.Background(), "test.read", operation)
+
+	assert.NoError(ot.T(), err)
+	assert.Equal(ot.T(), 3, *attempts)
+}
+
+func (ot *OperationTestSuite) TestShouldStopRetryingAfterMaxRetries() {
+	operation, attempts := failing(notPrimary, notPrimary, notPrimary, notPrimary)
+
+	err := ot.db.Read(context.Background(), "test.read", operation)
+
+	assert.Equal(ot.T(), notPrimary, err)
+	assert.Equal(ot.T(), 3, *attempts)
+}
+
+func (ot *OperationTestSuite) TestShouldNotRetryOperationErrors() {
+	operation, attempts := failing(duplicateKey)
+
+	err := ot.db.Write(context.Background(), "test.write", operation)
+
+	assert.Equal(ot.T(), duplicateKey, err)
+	assert.Equal(ot.T(), 1, *attempts)
+}
+
+func (ot *OperationTestSuite) TestShouldRetryWriteOnlyWhenRefused() {
+	refused, refusedAttempts := failing(notPrimary)
+	assert.NoError(ot.T(), ot.db.Write(context.Background(), "test.write", refused))
+	assert.Equal(ot.T(), 2, *refusedAttempts)
+
+	interrupted, interruptedAttempts := failing(networkError)
+	assert.Equal(ot.T(), networkError, ot.db.Write(context.Background(), "test.write", interrupted))
+	assert.Equal(ot.T(), 1, *interruptedAttempts, "a write interrupted on the wire may have been applied")
+}
+
+func (ot *OperationTestSuite) TestShouldBoundEachAttemptWithinCallerDeadline() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var deadline time.Time
+	err := ot.db.Read(ctx, "test.read", func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	})
+
+	assert.NoError(ot.T(), err)
+	assert.WithinDuration(ot.T(), time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+}
+
+func (ot *OperationTestSuite) TestShouldNotRetryAfterCallerIsDone() {
+	ctx, cancel := context.WithCancel(context.Background())
+	operation, attempts := failing(notPrimary, notPrimary)
+	cancel()
+
+	err := ot.db.Read(ctx, "test.read", operation)
+
+	assert.Equal(ot.T(), notPrimary, err)
+	assert.Equal(ot.T(), 1, *attempts)
+}
+
+func (ot *OperationTestSuite) TestShouldRunOperationsInTransactionOnce() {
+	operation, attempts := failing(notPrimary)
+
+	err := ot.db.Read(context.WithValue(context.Background(), transactionKey{}, true), "test.read", operation)
+
+	assert.Equal(ot.T(), notPrimary, err)
+	assert.Equal(ot.T(), 1, *attempts)
+}
+
+func (ot *OperationTestSuite) TestShouldDoubleBackoffUpToMax() {
+	policy := OperationPolicy{MinBackoff: 50 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(ot.T(), 50*time.Millisecond, policy.backoff(1))
+	assert.Equal(ot.T(), 200*time.Millisecond, policy.backoff(3))
+	assert.Equal(ot.T(), time.Second, policy.backoff(10))
+}
+
+func (ot *OperationTestSuite) TestShouldDisableRetriesWithNegativeMaxRetries() {
+	assert.Equal(ot.T(), 0, NewOperationPolicy(config.OperationsConfig{MaxRetries: -1}).MaxRetries)
+	assert.Equal(ot.T(), defaultMaxRetries, NewOperationPolicy(config.OperationsConfig{}).MaxRetries)
+}
+
+func (ot *OperationTestSuite) TestShouldReportDatastoreErrorsByCause() {
+	assert.Equal(ot.T(), http.StatusServiceUnavailable, DatastoreError(notPrimary).HTTPCode)
+	assert.Equal(ot.T(), "datastore_unavailable", DatastoreError(networkError).Code)
+	assert.Equal(ot.T(), "datastore_unavailable", DatastoreError(mongo.ErrClientDisconnected).Code)
+	assert.Equal(ot.T(), "datastore_timeout", DatastoreError(context.DeadlineExceeded).Code)
+	assert.Equal(ot.T(), http.StatusGatewayTimeout, DatastoreError(context.DeadlineExceeded).HTTPCode)
+	assert.Equal(ot.T(), "internal_server_error", DatastoreError(duplicateKey).Code)
+	assert.Equal(ot.T(), "internal_server_error", DatastoreError(errors.New("decode failed")).Code)
+	assert.Equal(ot.T(), "not_found", DatastoreError(types.NewNotFoundError("Product not found")).Code)
+}
@@ -4,5 +4,5 @@ import "github.com/google/wire"
 
 var WireSet = wire.NewSet(
 	GetHTTPClient,
-	NewDBInstance,
+	NewCheckedDBInstance,
 )
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	logger "github.com/roppenlabs/rapido-logger-go"
+)
+
+const defaultPollInterval = time.Second
+
+// Deliverer periodically sends the webhook deliveries that are due, for
+// every tenant
+type Deliverer struct {
+	service  Service
+	tenants  *tenant.Registry
+	interval time.Duration
+}
+
+func NewDeliverer(cfg config.Config, s Service, tenants *tenant.Registry) *Deliverer {
+	return &Deliverer{
+		service:  s,
+		tenants:  tenants,
+		interval: seconds(cfg.Get().Webhooks.PollInterval, defaultPollInterval),
+	}
+}
+
+// Run blocks until ctx is cancelled, sending due deliveries on every tick
+func (d *Deliverer) Run(ctx context.Context) {
+	logger.Info(logger.Format{Message: "Webhook deliverer started", Data: map[string]string{"interval": d.interval.String()}})
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(logger.Format{Message: "Webhook deliverer stopped"})
+			return
+		case <-ticker.C:
+			d.tick(ctx)
+		}
+	}
+}
+
+func (d *Deliverer) tick(ctx context.Context) {
+	for _, t := range d.tenants.All() {
+		d.tickTenant(tenant.WithTenant(ctx, t))
+	}
+}
+
+func (d *Deliverer) tickTenant(ctx context.Context) {
+	result, err := d.service.DeliverDue(ctx, time.Now().UTC())
+	if err != nil {
+		logger.Error(logger.Format{Message: "Failed to send webhook deliveries", Data: map[string]string{"error": err.Error(), "tenantID": tenant.ID(ctx)}})
+		return
+	}
+
+	if result.Succeeded > 0 || result.Retried > 0 || result.Failed > 0 {
+		logger.Info(logger.Format{
+			Message: "Sent webhook deliveries",
+			Data: map[string]string{
+				"tenantID":  tenant.ID(ctx),
+				"succeeded": strconv.Itoa(result.Succeeded),
+				"retried":   strconv.Itoa(result.Retried),
+				"failed":    strconv.Itoa(result.Failed),
+			},
+		})
+	}
+}
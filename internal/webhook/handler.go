@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/requestid"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) CreateSubscriptionHandler(ctx *gin.Context) {
+	var req SubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	subscription, err := h.service.CreateSubscription(ctx.Request.Context(), req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, SubscriptionResponse{
+		Success:      true,
+		Message:      "Webhook subscription created",
+		Subscription: *subscription,
+	})
+}
+
+func (h *Handler) UpdateSubscriptionHandler(ctx *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	subscription, err := h.service.UpdateSubscription(ctx.Request.Context(), subscriptionID, req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, SubscriptionResponse{
+		Success:      true,
+		Message:      "Webhook subscription updated",
+		Subscription: *subscription,
+	})
+}
+
+func (h *Handler) DeleteSubscriptionHandler(ctx *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(ctx.Request.Context(), subscriptionID); err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetSubscriptionHandler(ctx *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.service.GetSubscription(ctx.Request.Context(), subscriptionID)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+func (h *Handler) ListSubscriptionsHandler(ctx *gin.Context) {
+	subscriptions, err := h.service.ListSubscriptions(ctx.Request.Context())
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListSubscriptionsResponse{
+		Success:       true,
+		Count:         len(subscriptions),
+		Subscriptions: subscriptions,
+	})
+}
+
+func (h *Handler) ListDeliveriesHandler(ctx *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	status := ctx.Query("status")
+	if status != "" && status != DeliveryPending && status != DeliverySucceeded && status != DeliveryFailed {
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("status must be pending, succeeded or failed")))
+		return
+	}
+	limit := 0
+	if limitParam := ctx.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("limit must be a positive integer")))
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.service.ListDeliveries(ctx.Request.Context(), subscriptionID, status, limit)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ListDeliveriesResponse{
+		Success:    true,
+		Count:      len(deliveries),
+		Deliveries: deliveries,
+	})
+}
+
+func (h *Handler) ReplayHandler(ctx *gin.Context) {
+	subscriptionID, ok := parseSubscriptionID(ctx)
+	if !ok {
+		return
+	}
+
+	var req ReplayRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid request body: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError(fmt.Sprintf("Invalid request: %v", err))))
+		return
+	}
+
+	enqueued, err := h.service.Replay(ctx.Request.Context(), subscriptionID, req)
+	if err != nil {
+		statusError := types.ToStatusError(err)
+		ctx.JSON(statusError.HTTPCode, requestid.ErrorResponse(ctx, statusError))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, ReplayResponse{
+		Success:  true,
+		Message:  fmt.Sprintf("Enqueued %d deliveries", enqueued),
+		Enqueued: enqueued,
+	})
+}
+
+func parseSubscriptionID(ctx *gin.Context) (primitive.ObjectID, bool) {
+	subscriptionID, err := primitive.ObjectIDFromHex(ctx.Param("subscriptionId"))
+	if err != nil {
+		logging.Error(ctx.Request.Context(), logger.Format{Message: fmt.Sprintf("Invalid subscription ID format: %v", err)})
+		ctx.JSON(http.StatusBadRequest, requestid.ErrorResponse(ctx, types.NewValidationError("Invalid subscription ID format")))
+		return primitive.NilObjectID, false
+	}
+	return subscriptionID, true
+}
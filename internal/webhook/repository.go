@@ -0,0 +1,371 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error
+	GetSubscriptionByID(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	GetActiveSubscriptions(ctx context.Context) ([]Subscription, error)
+	// EnqueueDeliveries adds a delivery per subscription and event, owned by
+	// the subscription's tenant. A
+	// delivery that already exists is left alone, unless reset is set, in
+	// which case it is made pending again for an immediate attempt.
+	EnqueueDeliveries(ctx context.Context, deliveries []Delivery, reset bool) (int, error)
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	// GetEarlierPendingDelivery returns the pending delivery of an earlier
+	// event of the same product to the same subscription as delivery, or nil
+	// when there is none
+	GetEarlierPendingDelivery(ctx context.Context, delivery Delivery) (*Delivery, error)
+	// ClaimDelivery pushes a due delivery's next attempt to until, so that
+	// no other instance attempts it meanwhile, and reports whether it was
+	// still due
+	ClaimDelivery(ctx context.Context, deliveryID primitive.ObjectID, now, until time.Time) (bool, error)
+	RecordAttempt(ctx context.Context, deliveryID primitive.ObjectID, result AttemptResult) error
+	ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error)
+}
+
+type repositoryImpl struct {
+	db            *utils.DBInstance
+	subscriptions *mongo.Collection
+	deliveries    *mongo.Collection
+}
+
+func NewRepository(db *utils.DBInstance) Repository {
+	if db == nil || db.TestDB == nil {
+		panic("database cannot be nil")
+	}
+
+	return &repositoryImpl{
+		db:            db,
+		subscriptions: db.Collection("rapidWebhookSubscriptions"),
+		deliveries:    db.Collection("rapidWebhookDeliveries"),
+	}
+}
+
+func (r *repositoryImpl) CreateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error) {
+	subscription.TenantID = tenant.ID(ctx)
+	var result *mongo.InsertOneResult
+	err := r.db.Write(ctx, "webhook.CreateSubscription", func(ctx context.Context) error {
+		var err error
+		result, err = r.subscriptions.InsertOne(ctx, subscription)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error creating webhook subscription",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	subscription.ID = result.InsertedID.(primitive.ObjectID)
+	return &subscription, nil
+}
+
+func (r *repositoryImpl) UpdateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error) {
+	subscription.TenantID = tenant.ID(ctx)
+	var result *mongo.UpdateResult
+	err := r.db.Write(ctx, "webhook.UpdateSubscription", func(ctx context.Context) error {
+		var err error
+		result, err = r.subscriptions.ReplaceOne(ctx, tenant.Filter(ctx, bson.M{"_id": subscription.ID}), subscription)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error updating webhook subscription",
+			Data: map[string]string{
+				"error":          err.Error(),
+				"subscriptionID": subscription.ID.Hex(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, types.NewNotFoundError("Webhook subscription not found")
+	}
+
+	return &subscription, nil
+}
+
+// DeleteSubscription removes the subscription and its delivery log
+func (r *repositoryImpl) DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error {
+	var result *mongo.DeleteResult
+	err := r.db.Write(ctx, "webhook.DeleteSubscription", func(ctx context.Context) error {
+		var err error
+		result, err = r.subscriptions.DeleteOne(ctx, tenant.Filter(ctx, bson.M{"_id": subscriptionID}))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error deleting webhook subscription",
+			Data: map[string]string{
+				"error":          err.Error(),
+				"subscriptionID": subscriptionID.Hex(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+	if result.DeletedCount == 0 {
+		return types.NewNotFoundError("Webhook subscription not found")
+	}
+
+	err = r.db.Write(ctx, "webhook.DeleteSubscription", func(ctx context.Context) error {
+		_, err := r.deliveries.DeleteMany(ctx, bson.M{"subscriptionId": subscriptionID})
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error deleting webhook deliveries",
+			Data: map[string]string{
+				"error":          err.Error(),
+				"subscriptionID": subscriptionID.Hex(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) GetSubscriptionByID(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error) {
+	var subscription Subscription
+	err := r.db.Read(ctx, "webhook.GetSubscriptionByID", func(ctx context.Context) error {
+		return r.subscriptions.FindOne(ctx, tenant.Filter(ctx, bson.M{"_id": subscriptionID})).Decode(&subscription)
+	})
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, types.NewNotFoundError("Webhook subscription not found")
+		}
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching webhook subscription by ID",
+			Data: map[string]string{
+				"error":          err.Error(),
+				"subscriptionID": subscriptionID.Hex(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return &subscription, nil
+}
+
+func (r *repositoryImpl) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	return r.findSubscriptions(ctx, "webhook.ListSubscriptions", bson.M{})
+}
+
+func (r *repositoryImpl) GetActiveSubscriptions(ctx context.Context) ([]Subscription, error) {
+	return r.findSubscriptions(ctx, "webhook.GetActiveSubscriptions", bson.M{"active": true})
+}
+
+func (r *repositoryImpl) findSubscriptions(ctx context.Context, operation string, filter bson.M) ([]Subscription, error) {
+	subscriptions := []Subscription{}
+	err := r.db.Read(ctx, operation, func(ctx context.Context) error {
+		cursor, err := r.subscriptions.Find(ctx, tenant.Filter(ctx, filter), options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &subscriptions)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching webhook subscriptions",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *repositoryImpl) EnqueueDeliveries(ctx context.Context, deliveries []Delivery, reset bool) (int, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		filter := bson.M{"subscriptionId": delivery.SubscriptionID, "eventSeq": delivery.EventSeq}
+		onInsert := bson.M{
+			"tenantId":  delivery.TenantID,
+			"eventType": delivery.EventType,
+			"productId": delivery.ProductID,
+			"event":     delivery.Event,
+			"createdAt": delivery.CreatedAt,
+		}
+		pending := bson.M{
+			"status":        DeliveryPending,
+			"attempts":      0,
+			"nextAttemptAt": delivery.NextAttemptAt,
+			"updatedAt":     delivery.UpdatedAt,
+		}
+
+		update := bson.M{"$setOnInsert": onInsert, "$set": pending}
+		if !reset {
+			for field, value := range pending {
+				onInsert[field] = value
+			}
+			update = bson.M{"$setOnInsert": onInsert}
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	var result *mongo.BulkWriteResult
+	err := r.db.Write(ctx, "webhook.EnqueueDeliveries", func(ctx context.Context) error {
+		var err error
+		result, err = r.deliveries.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error enqueuing webhook deliveries",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return 0, utils.DatastoreError(err)
+	}
+
+	return int(result.UpsertedCount + result.ModifiedCount), nil
+}
+
+func (r *repositoryImpl) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	filter := bson.M{"status": DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "eventSeq", Value: 1}}).SetLimit(int64(limit))
+	return r.findDeliveries(ctx, "webhook.GetDueDeliveries", filter, findOptions)
+}
+
+func (r *repositoryImpl) GetEarlierPendingDelivery(ctx context.Context, delivery Delivery) (*Delivery, error) {
+	filter := bson.M{
+		"subscriptionId": delivery.SubscriptionID,
+		"productId":      delivery.ProductID,
+		"status":         DeliveryPending,
+		"eventSeq":       bson.M{"$lt": delivery.EventSeq},
+		"nextAttemptAt":  bson.M{"$exists": true},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "eventSeq", Value: 1}}).SetLimit(1)
+	deliveries, err := r.findDeliveries(ctx, "webhook.GetEarlierPendingDelivery", filter, findOptions)
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+func (r *repositoryImpl) ClaimDelivery(ctx context.Context, deliveryID primitive.ObjectID, now, until time.Time) (bool, error) {
+	filter := bson.M{"_id": deliveryID, "status": DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	var result *mongo.UpdateResult
+	err := r.db.Write(ctx, "webhook.ClaimDelivery", func(ctx context.Context) error {
+		var err error
+		result, err = r.deliveries.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"nextAttemptAt": until}})
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error claiming webhook delivery",
+			Data: map[string]string{
+				"error":      err.Error(),
+				"deliveryID": deliveryID.Hex(),
+			},
+		})
+		return false, utils.DatastoreError(err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *repositoryImpl) RecordAttempt(ctx context.Context, deliveryID primitive.ObjectID, result AttemptResult) error {
+	set := bson.M{"status": result.Status, "updatedAt": result.Attempt.At}
+	unset := bson.M{}
+	if result.NextAttemptAt != nil {
+		set["nextAttemptAt"] = result.NextAttemptAt
+	} else {
+		unset["nextAttemptAt"] = ""
+	}
+	if result.Status == DeliverySucceeded {
+		set["deliveredAt"] = result.Attempt.At
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attempts": 1},
+		"$push": bson.M{"attemptLog": bson.M{
+			"$each":  []Attempt{result.Attempt},
+			"$slice": -maxAttemptLog,
+		}},
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	err := r.db.Write(ctx, "webhook.RecordAttempt", func(ctx context.Context) error {
+		_, err := r.deliveries.UpdateOne(ctx, bson.M{"_id": deliveryID}, update)
+		return err
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error recording webhook attempt",
+			Data: map[string]string{
+				"error":      err.Error(),
+				"deliveryID": deliveryID.Hex(),
+			},
+		})
+		return utils.DatastoreError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error) {
+	filter := bson.M{"subscriptionId": subscriptionID}
+	if status != "" {
+		filter["status"] = status
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "eventSeq", Value: -1}}).SetLimit(int64(limit))
+	return r.findDeliveries(ctx, "webhook.ListDeliveries", filter, findOptions)
+}
+
+func (r *repositoryImpl) findDeliveries(ctx context.Context, operation string, filter bson.M, findOptions *options.FindOptions) ([]Delivery, error) {
+	deliveries := []Delivery{}
+	err := r.db.Read(ctx, operation, func(ctx context.Context) error {
+		cursor, err := r.deliveries.Find(ctx, tenant.Filter(ctx, filter), findOptions)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, &deliveries)
+	})
+	if err != nil {
+		logging.Error(ctx, logger.Format{
+			Message: "Error fetching webhook deliveries",
+			Data: map[string]string{
+				"error": err.Error(),
+			},
+		})
+		return nil, utils.DatastoreError(err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,499 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/logging"
+	"github.com/roppenlabs/rapid-product-catalog/internal/tenant"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultBatchSize   = 50
+	defaultTimeout     = 5 * time.Second
+	defaultMaxAttempts = 8
+	defaultMinBackoff  = 5 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultCacheTTL    = 30 * time.Second
+	defaultReplayLimit = 1000
+	maxDeliveriesLimit = 200
+	replayBatchSize    = 100
+)
+
+type Service interface {
+	CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, subscriptionID primitive.ObjectID, req SubscriptionRequest) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error
+	GetSubscription(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]Subscription, error)
+	ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error)
+	Replay(ctx context.Context, subscriptionID primitive.ObjectID, req ReplayRequest) (int, error)
+	// Enqueue adds a delivery of event for every active subscription to its
+	// type held by the tenant that owns the product
+	Enqueue(ctx context.Context, event events.Event) error
+	// DeliverDue attempts the deliveries of the tenant of ctx that are due
+	// at now
+	DeliverDue(ctx context.Context, now time.Time) (DeliveryResult, error)
+}
+
+type serviceImpl struct {
+	repository  Repository
+	events      events.Repository
+	httpClient  utils.HTTPClient
+	client      *http.Client
+	batchSize   int
+	timeout     time.Duration
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	cacheTTL    time.Duration
+	replayLimit int
+	// allowPrivate lets subscriptions target private, loopback and
+	// link-local addresses, for local development
+	allowPrivate bool
+	lookupIP     func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu sync.Mutex
+	// active caches each tenant's active subscriptions, keyed by tenant ID
+	active map[string]activeSubscriptions
+}
+
+type activeSubscriptions struct {
+	subscriptions []Subscription
+	expiresAt     time.Time
+}
+
+func NewService(cfg config.Config, repo Repository, eventsRepository events.Repository, httpClient utils.HTTPClient) Service {
+	webhooksConfig := cfg.Get().Webhooks
+	service := &serviceImpl{
+		repository:   repo,
+		events:       eventsRepository,
+		httpClient:   httpClient,
+		client:       newDeliveryClient(webhooksConfig.AllowPrivateTargets),
+		batchSize:    webhooksConfig.BatchSize,
+		timeout:      seconds(webhooksConfig.Timeout, defaultTimeout),
+		maxAttempts:  webhooksConfig.MaxAttempts,
+		minBackoff:   seconds(webhooksConfig.MinBackoff, defaultMinBackoff),
+		maxBackoff:   seconds(webhooksConfig.MaxBackoff, defaultMaxBackoff),
+		cacheTTL:     seconds(webhooksConfig.CacheTTL, defaultCacheTTL),
+		replayLimit:  webhooksConfig.ReplayLimit,
+		active:       map[string]activeSubscriptions{},
+		allowPrivate: webhooksConfig.AllowPrivateTargets,
+		lookupIP:     net.DefaultResolver.LookupIPAddr,
+	}
+	if service.batchSize <= 0 {
+		service.batchSize = defaultBatchSize
+	}
+	if service.maxAttempts <= 0 {
+		service.maxAttempts = defaultMaxAttempts
+	}
+	if service.replayLimit <= 0 {
+		service.replayLimit = defaultReplayLimit
+	}
+	return service
+}
+
+func seconds(value int, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+func (s *serviceImpl) CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error) {
+	if err := s.checkTarget(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := newSecret()
+		if err != nil {
+			logging.Error(ctx, logger.Format{Message: "Error generating webhook secret", Data: map[string]string{"error": err.Error()}})
+			return nil, types.NewInternalServerError()
+		}
+		secret = generated
+	}
+
+	now := time.Now().UTC()
+	subscription := Subscription{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		Active:     req.Active == nil || *req.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	created, err := s.repository.CreateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return created, nil
+}
+
+func (s *serviceImpl) UpdateSubscription(ctx context.Context, subscriptionID primitive.ObjectID, req SubscriptionRequest) (*Subscription, error) {
+	if err := s.checkTarget(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repository.GetSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := *existing
+	subscription.URL = req.URL
+	subscription.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	subscription.UpdatedAt = time.Now().UTC()
+
+	updated, err := s.repository.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+
+	// A rotated secret is shown once, like a new one
+	if req.Secret == "" {
+		updated.Secret = ""
+	}
+	return updated, nil
+}
+
+func (s *serviceImpl) DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error {
+	if err := s.repository.DeleteSubscription(ctx, subscriptionID); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+func (s *serviceImpl) GetSubscription(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error) {
+	subscription, err := s.repository.GetSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func (s *serviceImpl) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	subscriptions, err := s.repository.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *serviceImpl) ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error) {
+	if _, err := s.repository.GetSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+	return s.repository.ListDeliveries(ctx, subscriptionID, status, limit)
+}
+
+// Replay re-sends the events in the requested range that the subscription
+// listens to, whether or not they were delivered before
+func (s *serviceImpl) Replay(ctx context.Context, subscriptionID primitive.ObjectID, req ReplayRequest) (int, error) {
+	if req.ToSeq != 0 && req.ToSeq < req.FromSeq {
+		return 0, types.NewValidationError("toSeq cannot be before fromSeq")
+	}
+	if req.ToSeq != 0 && req.ToSeq-req.FromSeq >= int64(s.replayLimit) {
+		return 0, types.NewValidationError(fmt.Sprintf("cannot replay more than %d events at once", s.replayLimit))
+	}
+
+	subscription, err := s.repository.GetSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	enqueued := 0
+	after, read, done := req.FromSeq-1, 0, false
+	for !done && read < s.replayLimit {
+		batch, err := s.events.ReadAfter(ctx, after, replayBatchSize)
+		if err != nil {
+			return enqueued, err
+		}
+
+		deliveries := []Delivery{}
+		for _, event := range batch {
+			if req.ToSeq != 0 && event.Seq > req.ToSeq {
+				done = true
+				break
+			}
+			after = event.Seq
+			read++
+			if event.TenantID == subscription.TenantID && subscribes(*subscription, event.Type) {
+				deliveries = append(deliveries, newDelivery(*subscription, event, now))
+			}
+		}
+
+		count, err := s.repository.EnqueueDeliveries(ctx, deliveries, true)
+		if err != nil {
+			return enqueued, err
+		}
+		enqueued += count
+
+		if len(batch) < replayBatchSize {
+			done = true
+		}
+	}
+
+	logging.Info(ctx, logger.Format{
+		Message: "Replayed webhook events",
+		Data: map[string]string{
+			"subscriptionID": subscriptionID.Hex(),
+			"fromSeq":        strconv.FormatInt(req.FromSeq, 10),
+			"enqueued":       strconv.Itoa(enqueued),
+		},
+	})
+	return enqueued, nil
+}
+
+func (s *serviceImpl) Enqueue(ctx context.Context, event events.Event) error {
+	// Events are dispatched outside any request, so only the subscriptions
+	// of the tenant that owns the product receive them
+	ctx = tenant.WithTenant(ctx, tenant.Tenant{ID: event.TenantID})
+	subscriptions, err := s.activeSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	deliveries := []Delivery{}
+	for _, subscription := range subscriptions {
+		if subscribes(subscription, event.Type) {
+			deliveries = append(deliveries, newDelivery(subscription, event, now))
+		}
+	}
+
+	// The dispatcher may hand over an event twice; the delivery of the
+	// first is kept as is
+	_, err = s.repository.EnqueueDeliveries(ctx, deliveries, false)
+	return err
+}
+
+func (s *serviceImpl) DeliverDue(ctx context.Context, now time.Time) (DeliveryResult, error) {
+	result := DeliveryResult{}
+
+	deliveries, err := s.repository.GetDueDeliveries(ctx, now, s.batchSize)
+	if err != nil {
+		return result, err
+	}
+	if len(deliveries) == 0 {
+		return result, nil
+	}
+
+	subscriptions, err := s.repository.ListSubscriptions(ctx)
+	if err != nil {
+		return result, err
+	}
+	byID := make(map[primitive.ObjectID]Subscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		byID[subscription.ID] = subscription
+	}
+
+	for _, delivery := range deliveries {
+		// Deliveries of a product are sent in event order, so one waits
+		// behind an earlier delivery of the product being retried
+		earlier, err := s.repository.GetEarlierPendingDelivery(ctx, delivery)
+		if err != nil {
+			return result, err
+		}
+		if earlier != nil {
+			if _, err := s.repository.ClaimDelivery(ctx, delivery.ID, now, *earlier.NextAttemptAt); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		// Claim for longer than an attempt can take
+		claimed, err := s.repository.ClaimDelivery(ctx, delivery.ID, now, now.Add(2*s.timeout))
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+
+		attemptResult := s.attempt(ctx, byID[delivery.SubscriptionID], delivery)
+		if err := s.repository.RecordAttempt(ctx, delivery.ID, attemptResult); err != nil {
+			return result, err
+		}
+		switch attemptResult.Status {
+		case DeliverySucceeded:
+			result.Succeeded++
+		case DeliveryPending:
+			result.Retried++
+		default:
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// attempt sends delivery to subscription once and decides what happens to
+// it next
+func (s *serviceImpl) attempt(ctx context.Context, subscription Subscription, delivery Delivery) AttemptResult {
+	start := time.Now().UTC()
+	attempt := Attempt{At: start}
+
+	if !subscription.Active {
+		// Deliveries of a paused subscription fail rather than pile up; they
+		// can be replayed once it is active again
+		attempt.Error = "subscription is not active"
+		return AttemptResult{Attempt: attempt, Status: DeliveryFailed}
+	}
+
+	// The host may resolve differently than when the subscription was made
+	if err := s.checkTarget(ctx, subscription.URL); err != nil {
+		attempt.Error = types.ToStatusError(err).Message
+		return AttemptResult{Attempt: attempt, Status: DeliveryFailed}
+	}
+
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return AttemptResult{Attempt: attempt, Status: DeliveryFailed}
+	}
+
+	timestamp := start.Unix()
+	response, err := s.httpClient.Post(utils.HTTPPayload{
+		Context: ctx,
+		Client:  s.client,
+		URL:     subscription.URL,
+		Body:    body,
+		Headers: map[string]string{
+			HeaderDeliveryID: delivery.ID.Hex(),
+			HeaderEvent:      delivery.EventType,
+			HeaderTimestamp:  strconv.FormatInt(timestamp, 10),
+			HeaderSignature:  Sign(subscription.Secret, timestamp, body),
+		},
+		Timeout: s.timeout,
+	})
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err == nil {
+		attempt.StatusCode = response.StatusCode
+		if response.StatusCode >= 200 && response.StatusCode <= 299 {
+			return AttemptResult{Attempt: attempt, Status: DeliverySucceeded}
+		}
+		err = fmt.Errorf("endpoint responded with status %d", response.StatusCode)
+	}
+	attempt.Error = err.Error()
+
+	attempts := delivery.Attempts + 1
+	if attempts >= s.maxAttempts {
+		return AttemptResult{Attempt: attempt, Status: DeliveryFailed}
+	}
+	next := start.Add(s.backoff(attempts))
+	return AttemptResult{Attempt: attempt, Status: DeliveryPending, NextAttemptAt: &next}
+}
+
+// backoff is the wait after the given number of failed attempts: minBackoff,
+// doubling with each attempt up to maxBackoff
+func (s *serviceImpl) backoff(attempts int) time.Duration {
+	wait := s.minBackoff
+	for i := 1; i < attempts && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+	return wait
+}
+
+func (s *serviceImpl) activeSubscriptions(ctx context.Context) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantID := tenant.ID(ctx)
+	if cached, ok := s.active[tenantID]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.subscriptions, nil
+	}
+
+	subscriptions, err := s.repository.GetActiveSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.active[tenantID] = activeSubscriptions{subscriptions: subscriptions, expiresAt: time.Now().Add(s.cacheTTL)}
+	return subscriptions, nil
+}
+
+func (s *serviceImpl) invalidate(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, tenant.ID(ctx))
+}
+
+// Sign returns the signature header value for a delivery body sent at
+// timestamp, in unix seconds
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+func subscribes(subscription Subscription, eventType string) bool {
+	for _, subscribed := range subscription.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newDelivery(subscription Subscription, event events.Event, now time.Time) Delivery {
+	return Delivery{
+		SubscriptionID: subscription.ID,
+		TenantID:       subscription.TenantID,
+		EventSeq:       event.Seq,
+		EventType:      event.Type,
+		ProductID:      event.ProductID,
+		Event:          event,
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
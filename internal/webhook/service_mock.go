@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (s *MockService) CreateSubscription(ctx context.Context, req SubscriptionRequest) (*Subscription, error) {
+	ret := s.Mock.Called(ctx, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (s *MockService) UpdateSubscription(ctx context.Context, subscriptionID primitive.ObjectID, req SubscriptionRequest) (*Subscription, error) {
+	ret := s.Mock.Called(ctx, subscriptionID, req)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (s *MockService) DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error {
+	ret := s.Mock.Called(ctx, subscriptionID)
+	return ret.Error(0)
+}
+
+func (s *MockService) GetSubscription(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error) {
+	ret := s.Mock.Called(ctx, subscriptionID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (s *MockService) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	ret := s.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Subscription), ret.Error(1)
+}
+
+func (s *MockService) ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error) {
+	ret := s.Mock.Called(ctx, subscriptionID, status, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Delivery), ret.Error(1)
+}
+
+func (s *MockService) Replay(ctx context.Context, subscriptionID primitive.ObjectID, req ReplayRequest) (int, error) {
+	ret := s.Mock.Called(ctx, subscriptionID, req)
+	return ret.Int(0), ret.Error(1)
+}
+
+func (s *MockService) Enqueue(ctx context.Context, event events.Event) error {
+	ret := s.Mock.Called(ctx, event)
+	return ret.Error(0)
+}
+
+func (s *MockService) DeliverDue(ctx context.Context, now time.Time) (DeliveryResult, error) {
+	ret := s.Mock.Called(ctx, now)
+	return ret.Get(0).(DeliveryResult), ret.Error(1)
+}
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error) {
+	ret := m.Mock.Called(ctx, subscription)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (m *MockRepository) UpdateSubscription(ctx context.Context, subscription Subscription) (*Subscription, error) {
+	ret := m.Mock.Called(ctx, subscription)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (m *MockRepository) DeleteSubscription(ctx context.Context, subscriptionID primitive.ObjectID) error {
+	ret := m.Mock.Called(ctx, subscriptionID)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) GetSubscriptionByID(ctx context.Context, subscriptionID primitive.ObjectID) (*Subscription, error) {
+	ret := m.Mock.Called(ctx, subscriptionID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Subscription), ret.Error(1)
+}
+
+func (m *MockRepository) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	ret := m.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Subscription), ret.Error(1)
+}
+
+func (m *MockRepository) GetActiveSubscriptions(ctx context.Context) ([]Subscription, error) {
+	ret := m.Mock.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Subscription), ret.Error(1)
+}
+
+func (m *MockRepository) EnqueueDeliveries(ctx context.Context, deliveries []Delivery, reset bool) (int, error) {
+	ret := m.Mock.Called(ctx, deliveries, reset)
+	return ret.Int(0), ret.Error(1)
+}
+
+func (m *MockRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	ret := m.Mock.Called(ctx, now, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Delivery), ret.Error(1)
+}
+
+func (m *MockRepository) GetEarlierPendingDelivery(ctx context.Context, delivery Delivery) (*Delivery, error) {
+	ret := m.Mock.Called(ctx, delivery)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*Delivery), ret.Error(1)
+}
+
+func (m *MockRepository) ClaimDelivery(ctx context.Context, deliveryID primitive.ObjectID, now, until time.Time) (bool, error) {
+	ret := m.Mock.Called(ctx, deliveryID, now, until)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (m *MockRepository) RecordAttempt(ctx context.Context, deliveryID primitive.ObjectID, result AttemptResult) error {
+	ret := m.Mock.Called(ctx, deliveryID, result)
+	return ret.Error(0)
+}
+
+func (m *MockRepository) ListDeliveries(ctx context.Context, subscriptionID primitive.ObjectID, status string, limit int) ([]Delivery, error) {
+	ret := m.Mock.Called(ctx, subscriptionID, status, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]Delivery), ret.Error(1)
+}
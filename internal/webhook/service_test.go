@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/config"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"github.com/roppenlabs/rapid-product-catalog/internal/testutils"
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+	"github.com/roppenlabs/rapid-product-catalog/internal/utils"
+	logger "github.com/roppenlabs/rapido-logger-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookServiceTestSuite struct {
+	suite.Suite
+	repository   *MockRepository
+	events       *events.MockRepository
+	service      *serviceImpl
+	subscription Subscription
+	requests     []*http.Request
+	bodies       [][]byte
+	statusCode   int
+}
+
+func (ws *WebhookServiceTestSuite) SetupTest() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		ws.T().Fatalf("Failed to initialize config: %v", err)
+	}
+	logger.Init(cfg.Get().Log.Level)
+	ws.repository = new(MockRepository)
+	ws.events = new(events.MockRepository)
+	ws.service = NewService(cfg, ws.repository, ws.events, utils.GetHTTPClient()).(*serviceImpl)
+	ws.service.maxAttempts = 3
+	ws.service.minBackoff = 10 * time.Second
+	ws.service.maxBackoff = time.Minute
+	ws.service.lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}}, nil
+	}
+
+	ws.requests, ws.bodies, ws.statusCode = nil, nil, http.StatusOK
+	ws.service.client = testutils.NewTestHTTPClient(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		ws.requests = append(ws.requests, req)
+		ws.bodies = append(ws.bodies, body)
+		return &http.Response{StatusCode: ws.statusCode, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	ws.subscription = Subscription{
+		ID:         primitive.NewObjectID(),
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{events.TypeProductUpdated},
+		Secret:     "whsec_test",
+		Active:     true,
+	}
+	ws.repository.On("ListSubscriptions", mock.Anything).Return([]Subscription{ws.subscription}, nil)
+	ws.repository.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	ws.repository.On("GetEarlierPendingDelivery", mock.Anything, mock.Anything).Return(nil, nil)
+}
+
+func TestWebhookServiceSuite(t *testing.T) {
+	suite.Run(t, new(WebhookServiceTestSuite))
+}
+
+func (ws *WebhookServiceTestSuite) dueDelivery(attempts int) Delivery {
+	return Delivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: ws.subscription.ID,
+		EventSeq:       42,
+		EventType:      events.TypeProductUpdated,
+		Event:          events.Event{Seq: 42, Type: events.TypeProductUpdated, ChangedFields: []string{"price"}},
+		Status:         DeliveryPending,
+		Attempts:       attempts,
+	}
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldSignDelivery() {
+	delivery := ws.dueDelivery(0)
+	ws.repository.On("GetDueDeliveries", mock.Anything, mock.Anything, 50).Return([]Delivery{delivery}, nil)
+	ws.repository.On("RecordAttempt", mock.Anything, delivery.ID, mock.MatchedBy(func(result AttemptResult) bool {
+		return result.Status == DeliverySucceeded && result.Attempt.StatusCode == http.StatusOK
+	})).Return(nil)
+
+	result, err := ws.service.DeliverDue(context.Background(), time.Now().UTC())
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), DeliveryResult{Succeeded: 1}, result)
+	assert.Len(ws.T(), ws.requests, 1)
+
+	request, body := ws.requests[0], ws.bodies[0]
+	timestamp, _ := strconv.ParseInt(request.Header.Get(HeaderTimestamp), 10, 64)
+	assert.Equal(ws.T(), Sign("whsec_test", timestamp, body), request.Header.Get(HeaderSignature))
+	assert.Equal(ws.T(), events.TypeProductUpdated, request.Header.Get(HeaderEvent))
+	assert.Equal(ws.T(), delivery.ID.Hex(), request.Header.Get(HeaderDeliveryID))
+
+	var sent events.Event
+	assert.NoError(ws.T(), json.Unmarshal(body, &sent))
+	assert.Equal(ws.T(), int64(42), sent.Seq)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldBackOffExponentiallyThenFail() {
+	ws.statusCode = http.StatusServiceUnavailable
+	retried, exhausted := ws.dueDelivery(1), ws.dueDelivery(2)
+	ws.repository.On("GetDueDeliveries", mock.Anything, mock.Anything, 50).Return([]Delivery{retried, exhausted}, nil)
+
+	var results []AttemptResult
+	ws.repository.On("RecordAttempt", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		results = append(results, args.Get(2).(AttemptResult))
+	})
+
+	result, err := ws.service.DeliverDue(context.Background(), time.Now().UTC())
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), DeliveryResult{Retried: 1, Failed: 1}, result)
+	assert.Equal(ws.T(), DeliveryPending, results[0].Status)
+	// The second attempt failed, so the wait doubles from minBackoff
+	assert.Equal(ws.T(), 20*time.Second, results[0].NextAttemptAt.Sub(results[0].Attempt.At))
+	assert.Equal(ws.T(), DeliveryFailed, results[1].Status)
+	assert.Nil(ws.T(), results[1].NextAttemptAt)
+	assert.Equal(ws.T(), "endpoint responded with status 503", results[1].Attempt.Error)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldEnqueueOnlyForSubscribedEventTypes() {
+	deletions := Subscription{ID: primitive.NewObjectID(), EventTypes: []string{events.TypeProductDeleted}, Active: true}
+	ws.repository.On("GetActiveSubscriptions", mock.Anything).Return([]Subscription{ws.subscription, deletions}, nil)
+	ws.repository.On("EnqueueDeliveries", mock.Anything, mock.MatchedBy(func(deliveries []Delivery) bool {
+		return len(deliveries) == 1 && deliveries[0].SubscriptionID == ws.subscription.ID && deliveries[0].EventSeq == 7
+	}), false).Return(1, nil)
+
+	err := ws.service.Enqueue(context.Background(), events.Event{Seq: 7, Type: events.TypeProductUpdated})
+
+	assert.NoError(ws.T(), err)
+	ws.repository.AssertCalled(ws.T(), "EnqueueDeliveries", mock.Anything, mock.Anything, false)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldReplayRangeResettingDeliveries() {
+	ws.repository.On("GetSubscriptionByID", mock.Anything, ws.subscription.ID).Return(&ws.subscription, nil)
+	ws.events.On("ReadAfter", mock.Anything, int64(9), replayBatchSize).Return([]events.Event{
+		{Seq: 10, Type: events.TypeProductUpdated},
+		{Seq: 11, Type: events.TypeProductCreated},
+		{Seq: 12, Type: events.TypeProductUpdated},
+		{Seq: 13, Type: events.TypeProductUpdated},
+	}, nil)
+	ws.repository.On("EnqueueDeliveries", mock.Anything, mock.MatchedBy(func(deliveries []Delivery) bool {
+		return len(deliveries) == 2 && deliveries[0].EventSeq == 10 && deliveries[1].EventSeq == 12
+	}), true).Return(2, nil)
+
+	enqueued, err := ws.service.Replay(context.Background(), ws.subscription.ID, ReplayRequest{FromSeq: 10, ToSeq: 12})
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), 2, enqueued)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldRefuseSubscriptionToPrivateAddress() {
+	ws.service.lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IPAddr{{IP: ip}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+	}
+
+	for _, url := range []string{
+		"https://internal.example.com/hooks",
+		"http://127.0.0.1:8080/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hooks",
+		"ftp://partner.example.com/hooks",
+	} {
+		_, err := ws.service.CreateSubscription(context.Background(), SubscriptionRequest{URL: url, EventTypes: []string{events.TypeProductUpdated}})
+
+		assert.Equal(ws.T(), http.StatusBadRequest, types.ToStatusError(err).HTTPCode, url)
+	}
+	ws.repository.AssertNotCalled(ws.T(), "CreateSubscription", mock.Anything, mock.Anything)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldNotSendToHostThatNowResolvesToPrivateAddress() {
+	ws.service.lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	delivery := ws.dueDelivery(0)
+	ws.repository.On("GetDueDeliveries", mock.Anything, mock.Anything, 50).Return([]Delivery{delivery}, nil)
+	ws.repository.On("RecordAttempt", mock.Anything, delivery.ID, mock.MatchedBy(func(result AttemptResult) bool {
+		return result.Status == DeliveryFailed
+	})).Return(nil)
+
+	result, err := ws.service.DeliverDue(context.Background(), time.Now().UTC())
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), DeliveryResult{Failed: 1}, result)
+	assert.Empty(ws.T(), ws.requests)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldRefuseToConnectToPrivateAddress() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := newDeliveryClient(false).Post(server.URL, utils.ApplicationJSON, nil)
+
+	assert.True(ws.T(), errors.Is(err, errBlockedTarget), "%v", err)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldNotFollowRedirects() {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { redirected = true }))
+	defer target.Close()
+	endpoint := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer endpoint.Close()
+	ws.service.allowPrivate = true
+	ws.service.client = newDeliveryClient(true)
+	ws.subscription.URL = endpoint.URL
+	ws.repository.ExpectedCalls = nil
+	ws.repository.On("ListSubscriptions", mock.Anything).Return([]Subscription{ws.subscription}, nil)
+	ws.repository.On("ClaimDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	ws.repository.On("GetEarlierPendingDelivery", mock.Anything, mock.Anything).Return(nil, nil)
+	delivery := ws.dueDelivery(0)
+	ws.repository.On("GetDueDeliveries", mock.Anything, mock.Anything, 50).Return([]Delivery{delivery}, nil)
+	ws.repository.On("RecordAttempt", mock.Anything, delivery.ID, mock.MatchedBy(func(result AttemptResult) bool {
+		return result.Status == DeliveryPending && result.Attempt.StatusCode == http.StatusFound
+	})).Return(nil)
+
+	result, err := ws.service.DeliverDue(context.Background(), time.Now().UTC())
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), DeliveryResult{Retried: 1}, result)
+	assert.False(ws.T(), redirected)
+}
+
+func (ws *WebhookServiceTestSuite) TestShouldHoldDeliveryBehindEarlierDeliveryOfProductBeingRetried() {
+	retryAt := time.Now().UTC().Add(time.Minute)
+	earlier := ws.dueDelivery(1)
+	earlier.NextAttemptAt = &retryAt
+	later := ws.dueDelivery(0)
+	later.EventSeq = 43
+	ws.repository.ExpectedCalls = nil
+	ws.repository.On("ListSubscriptions", mock.Anything).Return([]Subscription{ws.subscription}, nil)
+	ws.repository.On("GetDueDeliveries", mock.Anything, mock.Anything, 50).Return([]Delivery{later}, nil)
+	ws.repository.On("GetEarlierPendingDelivery", mock.Anything, later).Return(&earlier, nil)
+	ws.repository.On("ClaimDelivery", mock.Anything, later.ID, mock.Anything, retryAt).Return(true, nil)
+
+	result, err := ws.service.DeliverDue(context.Background(), time.Now().UTC())
+
+	assert.NoError(ws.T(), err)
+	assert.Equal(ws.T(), DeliveryResult{}, result)
+	assert.Empty(ws.T(), ws.requests)
+	ws.repository.AssertExpectations(ws.T())
+}
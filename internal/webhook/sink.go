@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+)
+
+// Sink turns each product event into deliveries for the subscriptions that
+// listen to it; the Deliverer then sends them
+type Sink struct {
+	service Service
+}
+
+func NewSink(s Service) *Sink {
+	return &Sink{service: s}
+}
+
+func (s *Sink) Name() string {
+	return "webhook-subscriptions"
+}
+
+func (s *Sink) Exclusive() bool {
+	return true
+}
+
+func (s *Sink) Publish(ctx context.Context, event events.Event) error {
+	return s.service.Enqueue(ctx, event)
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/types"
+)
+
+// privateNetworks are the ranges, besides loopback and link-local ones,
+// that webhooks may not be sent to
+var privateNetworks = parseNetworks(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"fc00::/7",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// blockedIP reports whether ip is one webhooks may not be sent to
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTarget refuses webhook URLs that are not HTTP or whose host resolves
+// to an address webhooks may not be sent to
+func (s *serviceImpl) checkTarget(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return types.NewValidationError("Webhook URL must be an http or https URL")
+	}
+	if s.allowPrivate {
+		return nil
+	}
+
+	addresses, err := s.lookupIP(ctx, target.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return types.NewValidationError(fmt.Sprintf("Webhook URL host %s cannot be resolved", target.Hostname()))
+	}
+	for _, address := range addresses {
+		if blockedIP(address.IP) {
+			return types.NewValidationError("Webhook URL must not point at a private, loopback or link-local address")
+		}
+	}
+	return nil
+}
+
+// newDeliveryClient returns the client webhooks are sent with. It does not
+// follow redirects, and refuses to connect to addresses webhooks may not be
+// sent to, so a host that resolves differently once checked is still
+// refused.
+func newDeliveryClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return errBlockedTarget
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var errBlockedTarget = errors.New("webhook target is a private, loopback or link-local address")
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed "sha256=".
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// maxAttemptLog is the number of most recent attempts kept on a delivery
+const maxAttemptLog = 20
+
+// Subscription is a partner endpoint that receives the product events of the
+// listed types. The secret is only returned when the subscription is created
+// or the secret is rotated.
+type Subscription struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID   string             `json:"-" bson:"tenantId"`
+	URL        string             `json:"url" bson:"url"`
+	EventTypes []string           `json:"eventTypes" bson:"eventTypes"`
+	Secret     string             `json:"secret,omitempty" bson:"secret"`
+	Active     bool               `json:"active" bson:"active"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type SubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1,dive,oneof=product.created product.updated product.deleted"`
+	// Secret is generated when empty; on update, an empty secret keeps the
+	// current one
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
+}
+
+// Delivery is one event sent to one subscription, with its attempts
+type Delivery struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SubscriptionID primitive.ObjectID `json:"subscriptionId" bson:"subscriptionId"`
+	TenantID       string             `json:"-" bson:"tenantId"`
+	EventSeq       int64              `json:"eventSeq" bson:"eventSeq"`
+	EventType      string             `json:"eventType" bson:"eventType"`
+	ProductID      primitive.ObjectID `json:"productId" bson:"productId"`
+	Event          events.Event       `json:"-" bson:"event"`
+	Status         string             `json:"status" bson:"status"`
+	Attempts       int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt  *time.Time         `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time         `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+	AttemptLog     []Attempt          `json:"attemptLog,omitempty" bson:"attemptLog,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Attempt is the outcome of one delivery attempt
+type Attempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"statusCode,omitempty" bson:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64     `json:"durationMs" bson:"durationMs"`
+}
+
+// AttemptResult moves a delivery on after an attempt: to succeeded, back to
+// pending with NextAttemptAt, or to failed
+type AttemptResult struct {
+	Attempt       Attempt
+	Status        string
+	NextAttemptAt *time.Time
+}
+
+// ReplayRequest re-sends the subscription's events with sequence numbers in
+// [FromSeq, ToSeq]; a ToSeq of 0 replays to the latest event
+type ReplayRequest struct {
+	FromSeq int64 `json:"fromSeq" binding:"min=1"`
+	ToSeq   int64 `json:"toSeq" binding:"min=0"`
+}
+
+// DeliveryResult counts the outcomes of one delivery round
+type DeliveryResult struct {
+	Succeeded int
+	Retried   int
+	Failed    int
+}
+
+type SubscriptionResponse struct {
+	Success      bool         `json:"success"`
+	Message      string       `json:"message"`
+	Subscription Subscription `json:"subscription"`
+}
+
+type ListSubscriptionsResponse struct {
+	Success       bool           `json:"success"`
+	Count         int            `json:"count"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+type ListDeliveriesResponse struct {
+	Success    bool       `json:"success"`
+	Count      int        `json:"count"`
+	Deliveries []Delivery `json:"deliveries"`
+}
+
+type ReplayResponse struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Enqueued int    `json:"enqueued"`
+}
@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"github.com/google/wire"
+	"github.com/roppenlabs/rapid-product-catalog/internal/events"
+)
+
+var WireSet = wire.NewSet(
+	NewHandler,
+	NewService,
+	NewRepository,
+	NewSink,
+	NewDeliverer,
+	wire.Bind(new(events.SubscriptionSink), new(*Sink)),
+)